@@ -65,6 +65,15 @@ func ABIOpenURL(contractURL string) (*abi.ABI, error) {
 	return readAbi(resp.Body)
 }
 
+// ParseABI parses a contract ABI from JSON, ie: as output by solc.
+func ParseABI(abiJSON string) (*abi.ABI, error) {
+	a, err := readAbi(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+	return a, nil
+}
+
 func readAbi(reader io.Reader) (*abi.ABI, error) {
 	abi, err := abi.JSON(reader)
 	if err != nil {
@@ -3,11 +3,78 @@ package web3
 import (
 	"crypto/ecdsa"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
 	"github.com/gochain/gochain/v3/crypto"
 )
 
+// ErrInvalidPrivateKey is returned, wrapped, when a private key can't be parsed.
+var ErrInvalidPrivateKey = errors.New("invalid private key")
+
+// ErrInvalidAddress is returned, wrapped, when a hex address is malformed or fails its checksum.
+var ErrInvalidAddress = errors.New("invalid address")
+
+// ValidateAddress checks that s is a 20 byte hex address, with or without the 0x prefix. Addresses in mixed
+// case must match their EIP-55 checksum, while all lower and all upper case addresses are accepted unchecked.
+func ValidateAddress(s string) error {
+	_, err := parseAddress(s)
+	return err
+}
+
+// ChecksumAddress validates s and returns it in EIP-55 checksum form.
+func ChecksumAddress(s string) (string, error) {
+	addr, err := parseAddress(s)
+	if err != nil {
+		return "", err
+	}
+	return addr.Hex(), nil
+}
+
+// IsValidChecksum reports whether s is a valid address in its EIP-55 checksum form, with or without the 0x prefix.
+// Unlike ValidateAddress, all lower and all upper case addresses are rejected, since they carry no checksum, unless
+// they have no letters.
+func IsValidChecksum(s string) bool {
+	addr, err := parseAddress(s)
+	if err != nil {
+		return false
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X") == addr.Hex()[2:]
+}
+
+// parseAddress is like ValidateAddress, but also returns the parsed address.
+func parseAddress(s string) (common.Address, error) {
+	h := s
+	if len(h) >= 2 && h[0] == '0' && (h[1] == 'x' || h[1] == 'X') {
+		h = h[2:]
+	}
+	if len(h) != 2*common.AddressLength {
+		return common.Address{}, fmt.Errorf("%w %q: expected %d hex characters but got %d", ErrInvalidAddress, s, 2*common.AddressLength, len(h))
+	}
+	b, err := hex.DecodeString(h)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w %q: not hex", ErrInvalidAddress, s)
+	}
+	addr := common.BytesToAddress(b)
+	if h != strings.ToLower(h) && h != strings.ToUpper(h) && addr.Hex()[2:] != h {
+		return common.Address{}, fmt.Errorf("%w %q: bad checksum, expected %s", ErrInvalidAddress, s, addr.Hex())
+	}
+	return addr, nil
+}
+
+// KeyFromHex parses a hex private key, with or without the 0x prefix, and derives its address.
+func KeyFromHex(privateKeyHex string) (*ecdsa.PrivateKey, common.Address, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+	}
+	return privateKey, crypto.PubkeyToAddress(privateKey.PublicKey), nil
+}
+
+// CreateAccount generates a new random private key, from crypto/rand.
 func CreateAccount() (*Account, error) {
 	key, err := crypto.GenerateKey()
 	if err != nil {
@@ -18,9 +85,9 @@ func CreateAccount() (*Account, error) {
 	}, nil
 }
 
+// ParsePrivateKey parses a hex private key, with or without the 0x prefix.
 func ParsePrivateKey(pkHex string) (*Account, error) {
-	fromPK := strings.TrimPrefix(pkHex, "0x")
-	key, err := crypto.HexToECDSA(fromPK)
+	key, _, err := KeyFromHex(pkHex)
 	if err != nil {
 		return nil, err
 	}
@@ -29,6 +96,7 @@ func ParsePrivateKey(pkHex string) (*Account, error) {
 	}, nil
 }
 
+// Account is a private key and its address.
 type Account struct {
 	key *ecdsa.PrivateKey
 }
@@ -37,10 +105,22 @@ func (a *Account) Key() *ecdsa.PrivateKey {
 	return a.key
 }
 
+// Address returns the address derived from the public key.
+func (a *Account) Address() common.Address {
+	return crypto.PubkeyToAddress(a.key.PublicKey)
+}
+
+// PublicKey returns the EIP-55 hex address. Despite the name, it is not the public key itself.
 func (a *Account) PublicKey() string {
-	return crypto.PubkeyToAddress(a.key.PublicKey).Hex()
+	return a.Address().Hex()
+}
+
+// PublicKeyHex returns the uncompressed public key in hex, with the 0x prefix.
+func (a *Account) PublicKeyHex() string {
+	return hexutil.Encode(crypto.FromECDSAPub(&a.key.PublicKey))
 }
 
+// PrivateKey returns the private key in hex, with the 0x prefix.
 func (a *Account) PrivateKey() string {
 	return "0x" + hex.EncodeToString(crypto.FromECDSA(a.key))
 }
@@ -0,0 +1,183 @@
+package web3
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+)
+
+func TestKeyFromHex(t *testing.T) {
+	// Well known development key and address.
+	const key = "8f2a55949038a9610f50fb23b5883af3b4ecb3c3bb792cbcefbd1542c692be63"
+	addr := common.HexToAddress("0xFE3B557E8Fb62b89F4916B721be55cEb828dBd73")
+	for _, tt := range []struct {
+		name    string
+		hex     string
+		wantErr bool
+	}{
+		{name: "plain", hex: key},
+		{name: "prefixed", hex: "0x" + key},
+		{name: "empty", hex: "", wantErr: true},
+		{name: "short", hex: key[:62], wantErr: true},
+		{name: "non-hex", hex: "zz" + key[2:], wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			k, got, err := KeyFromHex(tt.hex)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("unexpected error: %v", err)
+				} else if !errors.Is(err, ErrInvalidPrivateKey) {
+					t.Errorf("expected %v but got: %v", ErrInvalidPrivateKey, err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatalf("expected error, but got address %s", got.Hex())
+			}
+			if k == nil {
+				t.Fatal("expected key")
+			}
+			if got != addr {
+				t.Errorf("expected address %s but got %s", addr.Hex(), got.Hex())
+			}
+		})
+	}
+}
+
+func TestChecksumAddress(t *testing.T) {
+	// Test vectors from EIP-55.
+	for _, want := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		for _, in := range []string{want, strings.ToLower(want), "0x" + strings.ToUpper(want[2:])} {
+			got, err := ChecksumAddress(in)
+			if err != nil {
+				t.Errorf("unexpected error for %s: %v", in, err)
+			} else if got != want {
+				t.Errorf("expected %s for %s but got %s", want, in, got)
+			}
+		}
+	}
+	// All caps and all lower vectors have no checksum.
+	for _, s := range []string{
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+		"0xde709f2102306220921060314715629080e2fb77",
+		"0x27b1fdb04752bbc536007a920d24acb045561c26",
+	} {
+		if err := ValidateAddress(s); err != nil {
+			t.Errorf("unexpected error for %s: %v", s, err)
+		}
+	}
+}
+
+func TestIsValidChecksum(t *testing.T) {
+	// Test vectors from EIP-55.
+	for _, s := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+		"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0x0000000000000000000000000000000000000000",
+	} {
+		if !IsValidChecksum(s) {
+			t.Errorf("expected %s to be valid", s)
+		}
+	}
+	for _, s := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", // last letter's case flipped
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+		"",
+	} {
+		if IsValidChecksum(s) {
+			t.Errorf("expected %s to be invalid", s)
+		}
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "checksum", address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "unprefixed", address: "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "lower", address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		{name: "upper", address: "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"},
+		{name: "bad-checksum", address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", wantErr: true},
+		{name: "short", address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", wantErr: true},
+		{name: "long", address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed00", wantErr: true},
+		{name: "non-hex", address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", wantErr: true},
+		{name: "empty", address: "", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAddress(tt.address)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidAddress) {
+				t.Errorf("expected %v but got: %v", ErrInvalidAddress, err)
+			} else if !strings.Contains(err.Error(), tt.address) {
+				t.Errorf("expected error to name the input but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	// Well known development key and address.
+	const key = "0x8f2a55949038a9610f50fb23b5883af3b4ecb3c3bb792cbcefbd1542c692be63"
+	const addr = "0xFE3B557E8Fb62b89F4916B721be55cEb828dBd73"
+	acct, err := ParsePrivateKey(key[2:])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := acct.Address().Hex(); got != addr {
+		t.Errorf("expected address %s but got %s", addr, got)
+	}
+	if got := acct.PublicKey(); got != addr {
+		t.Errorf("expected address %s but got %s", addr, got)
+	}
+	if got := acct.PrivateKey(); got != key {
+		t.Errorf("expected private key %s but got %s", key, got)
+	}
+	if _, err := ParsePrivateKey("0x1234"); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("expected %v but got: %v", ErrInvalidPrivateKey, err)
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	a, err := CreateAccount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := CreateAccount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.Address() == b.Address() {
+		t.Errorf("expected distinct accounts but both are %s", a.Address().Hex())
+	}
+	parsed, err := ParsePrivateKey(a.PrivateKey())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed.Address() != a.Address() || parsed.PublicKeyHex() != a.PublicKeyHex() {
+		t.Errorf("expected %s to round trip but got %s", a.Address().Hex(), parsed.Address().Hex())
+	}
+	if got := len(a.PublicKeyHex()); got != 2+2*65 {
+		t.Errorf("expected 65 byte uncompressed public key but got %d hex characters", got-2)
+	}
+}
@@ -0,0 +1,132 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v3/common"
+)
+
+// DefaultBlockRangeConcurrency is the number of blocks fetched at once by GetBlockRange, unless set in
+// BlockRangeOptions.
+const DefaultBlockRangeConcurrency = 8
+
+// BlockRangeOptions configures GetBlockRange.
+type BlockRangeOptions struct {
+	Concurrency  int  // blocks fetched at once, 0 for DefaultBlockRangeConcurrency
+	Transactions bool // include the full transactions, instead of only their hashes
+	Receipts     bool // fetch the receipts of each block's transactions too
+}
+
+// BlockResult is a block delivered by GetBlockRange, or the error which stopped it.
+type BlockResult struct {
+	Number   uint64
+	Block    *Block
+	Receipts []*Receipt // in transaction order, if BlockRangeOptions.Receipts is set
+	Err      error
+}
+
+// GetBlockRange fetches the blocks numbered from to to, inclusive, up to opts.Concurrency at once, and delivers them
+// in ascending order on the returned channel, which is closed when done. It stops at the first error, including ctx
+// being cancelled, which is delivered in the final BlockResult, so the channel must be read until it is closed.
+func GetBlockRange(ctx context.Context, client Client, from, to uint64, opts BlockRangeOptions) (<-chan BlockResult, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+	concurrency := opts.Concurrency
+	if concurrency == 0 {
+		concurrency = DefaultBlockRangeConcurrency
+	} else if concurrency < 0 {
+		return nil, fmt.Errorf("invalid concurrency: %d", concurrency)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Each block is queued in order for delivery before being fetched, so the queue bounds how far ahead of the
+	// consumer the workers get, and results completed out of order wait in their job.
+	type job struct {
+		number uint64
+		result chan BlockResult
+	}
+	jobs := make(chan job)
+	queue := make(chan job, concurrency)
+	go func() {
+		defer close(jobs)
+		defer close(queue)
+		for n := from; ; n++ {
+			j := job{number: n, result: make(chan BlockResult, 1)}
+			select {
+			case queue <- j:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+			if n == to {
+				return
+			}
+		}
+	}()
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			for j := range jobs {
+				j.result <- fetchBlockResult(ctx, client, j.number, opts)
+			}
+		}()
+	}
+
+	results := make(chan BlockResult)
+	go func() {
+		defer close(results)
+		defer cancel()
+		next := from
+		for j := range queue {
+			var r BlockResult
+			select {
+			case r = <-j.result:
+			case <-ctx.Done():
+				r = BlockResult{Number: j.number, Err: ctx.Err()}
+			}
+			results <- r
+			if r.Err != nil || r.Number == to {
+				return
+			}
+			next++
+		}
+		// ctx was cancelled before the next block was queued.
+		results <- BlockResult{Number: next, Err: ctx.Err()}
+	}()
+	return results, nil
+}
+
+// fetchBlockResult fetches block number, and its receipts if opts.Receipts is set.
+func fetchBlockResult(ctx context.Context, client Client, number uint64, opts BlockRangeOptions) BlockResult {
+	r := BlockResult{Number: number}
+	r.Block, r.Err = client.GetBlockByNumber(ctx, new(big.Int).SetUint64(number), opts.Transactions)
+	if r.Err != nil {
+		r.Err = fmt.Errorf("cannot get block %d: %w", number, r.Err)
+		return r
+	}
+	if !opts.Receipts {
+		return r
+	}
+	hashes := r.Block.TxHashes
+	if opts.Transactions {
+		hashes = make([]common.Hash, len(r.Block.TxDetails))
+		for i, tx := range r.Block.TxDetails {
+			hashes[i] = tx.Hash
+		}
+	}
+	r.Receipts = make([]*Receipt, len(hashes))
+	for i, hash := range hashes {
+		receipt, err := client.GetTransactionReceipt(ctx, hash)
+		if err != nil {
+			return BlockResult{Number: number, Err: fmt.Errorf("cannot get receipt %s in block %d: %w", hash.Hex(), number, err)}
+		}
+		r.Receipts[i] = receipt
+	}
+	return r
+}
@@ -0,0 +1,45 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/rpc"
+)
+
+// ErrStateOverrideUnsupported is returned, wrapped, by CallAt when the node rejects the state overrides parameter.
+var ErrStateOverrideUnsupported = errors.New("node does not support state overrides")
+
+// CallAt executes msg with eth_call against the state at blockNumber (nil for latest), without creating a
+// transaction. Historical blocks require an archive node, unless they are recent. overrides, if any, replace parts of
+// the state of their accounts for this call only, ie: to simulate a balance or an approval. They aren't sent if
+// empty, so plain calls work with any node. If the call would revert, the error is a *RevertError.
+func CallAt(ctx context.Context, client Client, msg CallMsg, blockNumber *big.Int, overrides map[common.Address]StateOverride) ([]byte, error) {
+	for addr, o := range overrides {
+		if o.State != nil && o.StateDiff != nil {
+			return nil, fmt.Errorf("invalid state override for %s: both State and StateDiff set", addr.Hex())
+		}
+	}
+	args := []interface{}{toCallArg(msg), toBlockNumArg(blockNumber)}
+	if len(overrides) > 0 {
+		args = append(args, overrides)
+	}
+	var result hexutil.Bytes
+	if err := client.RawCall(ctx, &result, "eth_call", args...); err != nil {
+		if len(overrides) > 0 && isInvalidParams(err) {
+			return nil, fmt.Errorf("%w: %v", ErrStateOverrideUnsupported, err)
+		}
+		return nil, toRevertError(err)
+	}
+	return result, nil
+}
+
+// isInvalidParams reports whether err is a JSON-RPC invalid params error, ie: for too many arguments.
+func isInvalidParams(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32602
+}
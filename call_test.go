@@ -0,0 +1,78 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+)
+
+func TestCallAt(t *testing.T) {
+	ctx := context.Background()
+	to := common.HexToAddress("0x01")
+	msg := CallMsg{To: &to, Data: []byte{1, 2}}
+	eth := &FakeEthService{CallFunc: func(map[string]interface{}) ([]byte, error) {
+		return []byte{42}, nil
+	}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+
+	for _, block := range []*big.Int{nil, big.NewInt(7)} {
+		res, err := CallAt(ctx, c, msg, block, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if !reflect.DeepEqual(res, []byte{42}) {
+			t.Errorf("expected result 2a but got %x", res)
+		}
+	}
+	if want := []string{"latest", "0x7"}; !reflect.DeepEqual(eth.Tags, want) {
+		t.Errorf("expected block tags %v but got %v", want, eth.Tags)
+	}
+
+	// FakeEthService takes no overrides, like older nodes.
+	balance := StateOverride{Balance: Base(1)}
+	_, err := CallAt(ctx, c, msg, nil, map[common.Address]StateOverride{to: balance})
+	if !errors.Is(err, ErrStateOverrideUnsupported) {
+		t.Errorf("expected %v but got: %v", ErrStateOverrideUnsupported, err)
+	}
+	both := StateOverride{State: map[common.Hash]common.Hash{}, StateDiff: map[common.Hash]common.Hash{}}
+	if _, err := CallAt(ctx, c, msg, nil, map[common.Address]StateOverride{to: both}); err == nil {
+		t.Error("expected error for both State and StateDiff")
+	}
+
+	eth.CallFunc = func(map[string]interface{}) ([]byte, error) {
+		return nil, errors.New("execution reverted: paused")
+	}
+	_, err = CallAt(ctx, c, msg, nil, nil)
+	var revertErr *RevertError
+	if !errors.As(err, &revertErr) || revertErr.Reason != "paused" {
+		t.Errorf("expected revert error but got: %v", err)
+	}
+}
+
+func TestStateOverride_JSON(t *testing.T) {
+	nonce := uint64(3)
+	slot, value := common.HexToHash("0x01"), common.HexToHash("0x02")
+	o := StateOverride{Balance: big.NewInt(16), Nonce: &nonce, Code: []byte{0x60}, StateDiff: map[common.Hash]common.Hash{slot: value}}
+	b, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"balance":"0x10","nonce":"0x3","code":"0x60","stateDiff":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000002"}}`
+	if string(b) != want {
+		t.Errorf("expected %s but got %s", want, b)
+	}
+	var got StateOverride
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, o) {
+		t.Errorf("expected %+v but got %+v", o, got)
+	}
+	if b, err := json.Marshal(StateOverride{}); err != nil || string(b) != "{}" {
+		t.Errorf("expected empty override but got %s: %v", b, err)
+	}
+}
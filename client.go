@@ -3,121 +3,938 @@ package web3
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gochain/gochain/v3"
 	"github.com/gochain/gochain/v3/common"
 	"github.com/gochain/gochain/v3/common/hexutil"
 	"github.com/gochain/gochain/v3/core/types"
 	"github.com/gochain/gochain/v3/rpc"
 )
 
-// Client is an interface for the web3 RPC API.
+// Client is an interface for the web3 RPC API. Address arguments are checked like ValidateAddress before any request,
+// so malformed input fails with ErrInvalidAddress instead of being read as the zero address.
 type Client interface {
 	// GetBalance returns the balance for an address at the given block number (nil for latest).
 	GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error)
+	// GetPendingBalance returns the balance for an address including pending transactions.
+	GetPendingBalance(ctx context.Context, address string) (*big.Int, error)
 	// GetCode returns the code for an address at the given block number (nil for latest).
 	GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error)
+	// GetStorageAt returns the 32 byte value of a contract's storage slot at the given block number (nil for
+	// latest). Use StorageSlot for slots by index.
+	GetStorageAt(ctx context.Context, address string, slot common.Hash, blockNumber *big.Int) ([]byte, error)
+	// GetLatestBlockNumber returns the number of the latest block, without fetching the block.
+	GetLatestBlockNumber(ctx context.Context) (uint64, error)
 	// GetBlockByNumber returns block details by number (nil for latest), optionally including full txs.
 	GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error)
-	// GetBlockByHash returns block details for the given hash, optionally include full transaction details.
+	// GetBlockByHash returns block details for the given hash, optionally include full transaction details. A
+	// *NotFoundError is returned if the node doesn't have the block.
 	GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*Block, error)
-	// GetTransactionByHash returns transaction details for a hash.
+	// GetHeaderByNumber returns the header of the block with number (nil for latest). There is no header only RPC,
+	// so it is fetched like GetBlockByNumber without full transactions, and the transaction hashes are dropped.
+	GetHeaderByNumber(ctx context.Context, number *big.Int) (*Header, error)
+	// GetHeaderByHash is like GetHeaderByNumber, but for the block with hash.
+	GetHeaderByHash(ctx context.Context, hash string) (*Header, error)
+	// GetTransactionByHash returns transaction details for a hash. BlockNumber is nil while the transaction is
+	// pending, and a *NotFoundError is returned if the node doesn't know it. See GetTransactionStatus.
 	GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error)
+	// GetBlockTransactionCount returns the number of transactions in the block with the given hash.
+	GetBlockTransactionCount(ctx context.Context, blockHash string) (uint64, error)
+	// GetTransactionInBlock returns the transaction at index in the block with the given hash.
+	GetTransactionInBlock(ctx context.Context, blockHash string, index uint64) (*Transaction, error)
 	// GetSnapshot returns the latest clique snapshot.
 	GetSnapshot(ctx context.Context) (*Snapshot, error)
-	// GetID returns unique identifying information for the network.
+	// GetSnapshotAt returns the clique snapshot at the given block number (nil for latest). A *NotFoundError is
+	// returned for blocks the node doesn't have, or which predate clique.
+	GetSnapshotAt(ctx context.Context, blockNumber *big.Int) (*Snapshot, error)
+	// GetID returns unique identifying information for the network. If only some of the details could be
+	// looked up, the partial ID is returned along with a MultiError describing the failures.
 	GetID(ctx context.Context) (*ID, error)
 	// GetTransactionReceipt returns the receipt for a transaction hash.
 	GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error)
 	// GetChainID returns the chain id for the network.
 	GetChainID(ctx context.Context) (*big.Int, error)
+	// ChainID is like GetChainID, but the chain id is only requested until it succeeds once, and then cached.
+	// GetID also fills the cache.
+	ChainID(ctx context.Context) (*big.Int, error)
+	// ResetChainID clears the cached chain id, ie: if the node behind the url was replaced.
+	ResetChainID()
+	// GetTransactionSender recovers the sender of a signed transaction. The chain id, needed for EIP-155
+	// transactions, is requested once and cached.
+	GetTransactionSender(ctx context.Context, tx *types.Transaction) (common.Address, error)
 	// GetNetworkID returns the network id.
 	GetNetworkID(ctx context.Context) (*big.Int, error)
 	// GetGasPrice returns a suggested gas price.
 	GetGasPrice(ctx context.Context) (*big.Int, error)
+	// GetGasTipCap returns a suggested priority fee for EIP-1559 transactions, on networks which support them.
+	GetGasTipCap(ctx context.Context) (*big.Int, error)
 	// GetPendingTransactionCount returns the transaction count including pending txs.
 	// This value is also the next legal nonce.
 	GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error)
+	// GetPendingNonce returns the next nonce for an address including pending transactions.
+	GetPendingNonce(ctx context.Context, address string) (uint64, error)
 	// SendRawTransaction sends the signed raw transaction bytes.
 	SendRawTransaction(ctx context.Context, tx []byte) error
 	// Call executes a call without submitting a transaction.
 	Call(ctx context.Context, msg CallMsg) ([]byte, error)
+	// EstimateGas returns an estimate of the gas needed to execute msg. If execution would revert, the
+	// error is a *RevertError.
+	EstimateGas(ctx context.Context, msg CallMsg) (uint64, error)
+	// FilterLogs returns the logs matching q.
+	FilterLogs(ctx context.Context, q gochain.FilterQuery) ([]types.Log, error)
+	// SubscribeNewHead subscribes to new block headers. It requires a websocket or IPC connection.
+	// The returned channel is closed once the subscription fails, is unsubscribed, or ctx is done.
+	SubscribeNewHead(ctx context.Context) (<-chan *types.Header, gochain.Subscription, error)
+	// SubscribePendingTransactions subscribes to the hashes of transactions entering the node's transaction pool.
+	// It requires a websocket or IPC connection, like SubscribeNewHead. Busy pools can produce hashes faster
+	// than they are read, and the subscription fails with ErrSubscriptionOverflow once too many are queued. Use
+	// WatchPendingTransactions to drop hashes instead.
+	SubscribePendingTransactions(ctx context.Context) (<-chan common.Hash, gochain.Subscription, error)
+	// RawCall calls a JSON-RPC method which isn't otherwise exposed, unmarshaling the result into result.
+	// The caller is responsible for result matching the shape of the method's return value.
+	RawCall(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	// BatchCall sends reqs in a single batch request, to save round trips, or in several if there are more than
+	// ClientOptions.MaxBatchSize. Each element's Error is set if it failed, and the returned error is only for a
+	// batch as a whole.
+	BatchCall(ctx context.Context, reqs []rpc.BatchElem) error
+	// URL returns the url the client was dialed with, or "" if it wraps an existing rpc.Client.
+	URL() string
+	// Transport returns the transport the client was dialed with, TransportHTTP, TransportWebsocket or
+	// TransportIPC, or "" if it wraps an existing rpc.Client.
+	Transport() string
+	// SupportsSubscriptions reports whether the client can subscribe to notifications, like SubscribeNewHead,
+	// which requires a websocket or IPC connection. Clients wrapping an existing rpc.Client are assumed to.
+	SupportsSubscriptions() bool
+	// Stats returns the rate limiting counters, which are zero without ClientOptions.RateLimit.
+	Stats() ClientStats
+	// Close releases the underlying connection. It is safe to call more than once, and any
+	// calls made afterwards fail with ErrClientClosed.
 	Close()
 }
 
-// Dial returns a new client backed by dialing url (supported schemes "http", "https", "ws" and "wss").
+// ErrClientClosed is returned by calls made on a Client after Close.
+var ErrClientClosed = errors.New("client closed")
+
+// ErrSubscriptionUnsupported is returned, wrapped, when subscribing over a connection without support for
+// notifications, like HTTP.
+var ErrSubscriptionUnsupported = rpc.ErrNotificationsUnsupported
+
+// ErrSubscriptionOverflow is the subscription error when notifications arrive faster than they are read, and the
+// queue of unread notifications is full.
+var ErrSubscriptionOverflow = rpc.ErrSubscriptionQueueOverflow
+
+// MultiError is a list of errors from related calls which failed independently.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// RevertError is returned when a call would revert, with the reason if the node reported one.
+type RevertError struct {
+	Reason string
+	err    error
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.err
+}
+
+// toRevertError returns a *RevertError if err is an rpc error reporting a reverted execution, otherwise err.
+func toRevertError(err error) error {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+	const prefix = "execution reverted"
+	msg := rpcErr.Error()
+	if !strings.HasPrefix(msg, prefix) {
+		return err
+	}
+	reason := strings.TrimPrefix(strings.TrimPrefix(msg, prefix), ":")
+	return &RevertError{Reason: strings.TrimSpace(reason), err: err}
+}
+
+// NotFoundError is returned when the node has no Kind, ie: "transaction", with ID. It matches NotFoundErr with
+// errors.Is.
+type NotFoundError struct {
+	Kind string
+	ID   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Kind, e.ID)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == NotFoundErr
+}
+
+// RPCError is returned when the node responds to Method with an error. The node's rpc.Error is available with
+// errors.As.
+type RPCError struct {
+	Method string
+	Err    error
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Method, e.Err)
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+// toRPCError wraps err in an *RPCError if it is an error response from the node, otherwise returns it as is.
+func toRPCError(method string, err error) error {
+	var rpcErr rpc.Error
+	if err == nil || !errors.As(err, &rpcErr) {
+		return err
+	}
+	return &RPCError{Method: method, Err: err}
+}
+
+// rpcErrorContains reports whether err is an error response from the node with a message containing substr, ie:
+// "nonce too low".
+func rpcErrorContains(err error, substr string) bool {
+	var rpcErr *RPCError
+	return errors.As(err, &rpcErr) && strings.Contains(rpcErr.Err.Error(), substr)
+}
+
+// ClientOptions configures a client.
+type ClientOptions struct {
+	// DefaultTimeout, if set, is applied to calls made with a context without a deadline. Contexts with a
+	// deadline are used as is. Subscriptions are not affected.
+	DefaultTimeout time.Duration
+	// MaxRetries is the number of times a call is retried after failing with a transient network error or a
+	// rate limit response. Other errors, like reverts or invalid nonces, are returned immediately.
+	// Transactions are never sent more than once.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, which doubles for each following retry, with jitter.
+	// Defaults to 500 milliseconds. Calls give up early when their context's deadline is sooner than the next
+	// retry.
+	RetryBackoff time.Duration
+	// MaxRetryDelay caps the delay between retries. Defaults to 30 seconds.
+	MaxRetryDelay time.Duration
+	// RetryOn, if set, reports whether a call which failed with err should be retried, instead of the default of
+	// transient network errors and rate limit responses.
+	RetryOn func(err error) bool
+
+	// HTTPClient, Header and basic auth only apply to HTTP connections, and dialing other transports with them
+	// set fails.
+
+	// HTTPClient, if set, is used to make requests instead of http.DefaultClient, ie: for client certificates.
+	HTTPClient *http.Client
+	// Header is added to every request, including batch requests, ie: for API keys.
+	Header http.Header
+	// Username and Password, if Username is set, are sent with every request using basic auth.
+	Username, Password string
+
+	// RateLimit, if set, limits requests to this many per second, with bursts of up to RateBurst requests.
+	// Each element of a batch counts as a request. Calls wait for the limit, or until their context is done.
+	RateLimit float64
+	RateBurst int
+
+	// MaxBatchSize, if set, splits batch requests with more elements into several, for nodes which limit the
+	// size of a batch.
+	MaxBatchSize int
+
+	// Logger, if set, is told about retries, partial results from GetID, and failover endpoints becoming
+	// unhealthy or being restored. Nothing is logged otherwise.
+	Logger Logger
+}
+
+// Logger receives diagnostic messages from a client, ie: a *log.Logger, or an adapter for a structured logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// logf logs to l, if set.
+func logf(l Logger, format string, v ...interface{}) {
+	if l != nil {
+		l.Printf(format, v...)
+	}
+}
+
+// httpOptions reports whether any of the HTTP only options are set.
+func (o ClientOptions) httpOptions() bool {
+	return o.HTTPClient != nil || len(o.Header) > 0 || o.Username != ""
+}
+
+// httpClient returns a copy of the configured HTTP client which adds the configured header and basic auth.
+func (o ClientOptions) httpClient() *http.Client {
+	hc := http.Client{}
+	if o.HTTPClient != nil {
+		hc = *o.HTTPClient
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc.Transport = &headerTransport{base: base, header: o.Header, username: o.Username, password: o.Password}
+	return &hc
+}
+
+// headerTransport adds a header and basic auth to each request.
+type headerTransport struct {
+	base               http.RoundTripper
+	header             http.Header
+	username, password string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.header {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Transports reported by Client.Transport.
+const (
+	TransportHTTP      = "http"
+	TransportWebsocket = "ws"
+	TransportIPC       = "ipc"
+)
+
+// Dial returns a new client backed by dialing url (supported schemes "http", "https", "ws" and "wss"), or an IPC
+// socket when url is a file path.
 func Dial(url string) (Client, error) {
-	r, err := rpc.Dial(url)
+	return DialWithOptions(url, ClientOptions{})
+}
+
+// DialWithOptions is like Dial, but configured by opts.
+func DialWithOptions(url string, opts ClientOptions) (Client, error) {
+	transport := transportOf(url)
+	var r *rpc.Client
+	var err error
+	if opts.httpOptions() {
+		if transport != TransportHTTP {
+			return nil, fmt.Errorf("failed to dial %q: HTTP client, header and basic auth options require an HTTP url", url)
+		}
+		r, err = rpc.DialHTTPWithClient(url, opts.httpClient())
+	} else {
+		r, err = rpc.Dial(url)
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to dial %q: %w", url, err)
 	}
-	return NewClient(r), nil
+	return newClient(r, url, opts), nil
+}
+
+// transportOf returns the transport rpc.Dial uses for rawurl, or "" for other transports, like stdio.
+func transportOf(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return TransportHTTP
+	case "ws", "wss":
+		return TransportWebsocket
+	case "":
+		return TransportIPC
+	}
+	return ""
 }
 
 // NewClient returns a new client backed by an existing rpc.Client.
 func NewClient(r *rpc.Client) Client {
-	return &client{r: r}
+	return NewClientWithOptions(r, ClientOptions{})
+}
+
+// NewClientWithOptions is like NewClient, but configured by opts.
+func NewClientWithOptions(r *rpc.Client, opts ClientOptions) Client {
+	return newClient(r, "", opts)
+}
+
+func newClient(r *rpc.Client, url string, opts ClientOptions) *client {
+	c := &client{r: r, url: url, opts: opts}
+	if url != "" {
+		c.transport = transportOf(url)
+	}
+	if opts.RateLimit > 0 {
+		c.limiter = newLimiter(opts.RateLimit, opts.RateBurst)
+	}
+	return c
 }
 
 type client struct {
-	r *rpc.Client
+	r         *rpc.Client
+	url       string
+	transport string
+	opts      ClientOptions
+	failover  *failover // nil unless created by NewFailoverClient
+	limiter   *limiter  // nil without a rate limit
+
+	mu      sync.Mutex
+	closed  bool
+	chainID *big.Int // cached by ChainID
+}
+
+func (c *client) URL() string {
+	return c.url
+}
+
+func (c *client) Transport() string {
+	return c.transport
+}
+
+func (c *client) SupportsSubscriptions() bool {
+	return c.url == "" || c.transport == TransportWebsocket || c.transport == TransportIPC
 }
 
 func (c *client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if c.failover != nil {
+		c.failover.close()
+		return
+	}
 	c.r.Close()
 }
 
+func (c *client) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// call performs a JSON-RPC call on the underlying client, unless it has been closed.
+func (c *client) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if c.isClosed() {
+		return ErrClientClosed
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	err := c.retry(ctx, method, func() error {
+		if err := c.wait(ctx, 1); err != nil {
+			return err
+		}
+		return c.do(ctx, method, func(r *rpc.Client) error {
+			return r.CallContext(ctx, result, method, args...)
+		})
+	})
+	return toRPCError(method, err)
+}
+
+// wait waits for the rate limit to allow n requests, if there is one.
+func (c *client) wait(ctx context.Context, n int) error {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.wait(ctx, n)
+}
+
+func (c *client) Stats() ClientStats {
+	if c.limiter == nil {
+		return ClientStats{}
+	}
+	return c.limiter.Stats()
+}
+
+// do calls fn with the underlying rpc client, or each endpoint in turn for a failover client.
+func (c *client) do(ctx context.Context, method string, fn func(*rpc.Client) error) error {
+	if c.failover != nil {
+		return c.failover.do(ctx, method, fn)
+	}
+	return fn(c.r)
+}
+
+// withTimeout returns ctx with the default timeout applied, unless there is none or ctx already has a deadline.
+func (c *client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.opts.DefaultTimeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.opts.DefaultTimeout)
+}
+
+// retry calls fn until it succeeds, fails with an error which isn't worth retrying, or runs out of retries.
+// Calls to method which send transactions are not retried.
+func (c *client) retry(ctx context.Context, method string, fn func() error) error {
+	if c.opts.MaxRetries == 0 || isSend(method) {
+		return fn()
+	}
+	backoff := c.opts.RetryBackoff
+	if backoff == 0 {
+		backoff = 500 * time.Millisecond
+	}
+	maxDelay := c.opts.MaxRetryDelay
+	if maxDelay == 0 {
+		maxDelay = 30 * time.Second
+	}
+	retryOn := c.opts.RetryOn
+	if retryOn == nil {
+		retryOn = func(err error) bool { return isTransient(err) || isRateLimited(err) }
+	}
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil || i >= c.opts.MaxRetries || ctx.Err() != nil || !retryOn(err) {
+			return err
+		}
+		if backoff > maxDelay {
+			backoff = maxDelay
+		}
+		// Between half and all of the backoff, so that clients which failed together don't retry together.
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		logf(c.opts.Logger, "web3: retrying %s in %s after attempt %d failed: %v", method, delay, i+1, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
+// isRateLimited reports whether err is a rate limit response from the node.
+func isRateLimited(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	// Not by the "limit exceeded" code, -32005, since some nodes also use it for oversized log queries.
+	msg := strings.ToLower(rpcErr.Error())
+	return strings.Contains(msg, "rate limit") || strings.Contains(msg, "rate exceeded") ||
+		strings.Contains(msg, "too many requests")
+}
+
+func (c *client) RawCall(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return c.call(ctx, result, method, args...)
+}
+
+func (c *client) BatchCall(ctx context.Context, reqs []rpc.BatchElem) error {
+	return c.batchCall(ctx, reqs)
+}
+
+// batchCall performs a JSON-RPC batch call on the underlying client, unless it has been closed, in batches of at
+// most opts.MaxBatchSize elements.
+func (c *client) batchCall(ctx context.Context, b []rpc.BatchElem) error {
+	if c.isClosed() {
+		return ErrClientClosed
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	for len(b) > 0 {
+		n := len(b)
+		if max := c.opts.MaxBatchSize; max > 0 && n > max {
+			n = max
+		}
+		batch := b[:n]
+		method := batchMethod(batch)
+		err := c.retry(ctx, method, func() error {
+			if err := c.wait(ctx, len(batch)); err != nil {
+				return err
+			}
+			return c.do(ctx, method, func(r *rpc.Client) error {
+				return r.BatchCallContext(ctx, batch)
+			})
+		})
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// batchMethod returns the method a batch is retried and failed over as: the first send it contains, since the batch
+// as a whole is then not safe to repeat, or "batch".
+func batchMethod(b []rpc.BatchElem) string {
+	for _, e := range b {
+		if isSend(e.Method) {
+			return e.Method
+		}
+	}
+	return "batch"
+}
+
+// GetBalances returns the balances of addresses at blockNumber (nil for latest), in the same order, using a single
+// batch request. Balances which could not be fetched are nil, and the returned MultiError lists their errors.
+func GetBalances(ctx context.Context, client Client, addresses []string, blockNumber *big.Int) ([]*big.Int, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	results := make([]hexutil.Big, len(addresses))
+	reqs := make([]rpc.BatchElem, len(addresses))
+	for i, address := range addresses {
+		if err := ValidateAddress(address); err != nil {
+			return nil, err
+		}
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getBalance",
+			Args:   []interface{}{common.HexToAddress(address), toBlockNumArg(blockNumber)},
+			Result: &results[i],
+		}
+	}
+	if err := client.BatchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	balances := make([]*big.Int, len(addresses))
+	var errs MultiError
+	for i := range reqs {
+		if err := reqs[i].Error; err != nil {
+			errs = append(errs, fmt.Errorf("cannot get balance of %s: %w", addresses[i], err))
+			continue
+		}
+		balances[i] = results[i].ToInt()
+	}
+	if len(errs) > 0 {
+		return balances, errs
+	}
+	return balances, nil
+}
+
+// GetTransactionReceipts returns the receipts of the transactions with hashes, in the same order, using a single
+// batch request. Receipts which could not be fetched, including those not found, are nil, and the returned
+// MultiError lists their errors.
+func GetTransactionReceipts(ctx context.Context, client Client, hashes []common.Hash) ([]*Receipt, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	receipts := make([]*Receipt, len(hashes))
+	reqs := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+	if err := client.BatchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	var errs MultiError
+	for i := range reqs {
+		err := reqs[i].Error
+		if err == nil && receipts[i] == nil {
+			err = &NotFoundError{Kind: "receipt", ID: hashes[i].Hex()}
+		}
+		if err != nil {
+			receipts[i] = nil
+			errs = append(errs, fmt.Errorf("cannot get receipt %s: %w", hashes[i].Hex(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return receipts, errs
+	}
+	return receipts, nil
+}
+
 func (c *client) Call(ctx context.Context, msg CallMsg) ([]byte, error) {
 	var result hexutil.Bytes
-	err := c.r.CallContext(ctx, &result, "eth_call", toCallArg(msg), "latest")
+	err := c.call(ctx, &result, "eth_call", toCallArg(msg), "latest")
 	if err != nil {
 		return nil, err
 	}
 	return result, err
 }
 
+func (c *client) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	var result hexutil.Uint64
+	err := c.call(ctx, &result, "eth_estimateGas", toCallArg(msg))
+	if err != nil {
+		return 0, toRevertError(err)
+	}
+	return uint64(result), nil
+}
+
+func (c *client) FilterLogs(ctx context.Context, q gochain.FilterQuery) ([]types.Log, error) {
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	var result []types.Log
+	if err := c.call(ctx, &result, "eth_getLogs", arg); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *client) SubscribeNewHead(ctx context.Context) (<-chan *types.Header, gochain.Subscription, error) {
+	in := make(chan *types.Header)
+	sub, err := c.subscribe(ctx, in, "newHeads")
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make(chan *types.Header)
+	go sub.forward(ctx, func(done <-chan struct{}) bool {
+		select {
+		case h := <-in:
+			select {
+			case out <- h:
+				return true
+			case <-done:
+			}
+		case <-done:
+		}
+		return false
+	}, func() { close(out) })
+	return out, sub, nil
+}
+
+func (c *client) SubscribePendingTransactions(ctx context.Context) (<-chan common.Hash, gochain.Subscription, error) {
+	in := make(chan common.Hash)
+	sub, err := c.subscribe(ctx, in, "newPendingTransactions")
+	if err != nil {
+		return nil, nil, err
+	}
+	out := make(chan common.Hash)
+	go sub.forward(ctx, func(done <-chan struct{}) bool {
+		select {
+		case h := <-in:
+			select {
+			case out <- h:
+				return true
+			case <-done:
+			}
+		case <-done:
+		}
+		return false
+	}, func() { close(out) })
+	return out, sub, nil
+}
+
+// subscribe creates an eth subscription delivering notifications to channel.
+func (c *client) subscribe(ctx context.Context, channel interface{}, name string) (*clientSubscription, error) {
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+	if c.transport == TransportHTTP {
+		return nil, fmt.Errorf("cannot subscribe to %s over HTTP, dial a websocket or IPC endpoint instead of %q: %w", name, c.url, ErrSubscriptionUnsupported)
+	}
+	r := c.r
+	if c.failover != nil {
+		r = c.failover.subscriber()
+	}
+	sub, err := r.EthSubscribe(ctx, channel, name)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return nil, fmt.Errorf("cannot subscribe to %s over %q, a websocket or IPC connection is required: %w", name, c.url, err)
+	} else if err != nil {
+		return nil, err
+	}
+	return &clientSubscription{sub: sub, err: make(chan error, 1), done: make(chan struct{})}, nil
+}
+
+// clientSubscription relays notifications from an rpc subscription, so that the forwarding goroutine can observe
+// the subscription error without taking it from the caller.
+type clientSubscription struct {
+	sub  *rpc.ClientSubscription
+	err  chan error
+	done chan struct{}
+}
+
+func (s *clientSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *clientSubscription) Unsubscribe() {
+	s.sub.Unsubscribe()
+}
+
+// forward calls relay to pass on each notification until the subscription ends or ctx is done, then calls
+// closeOut and closes the error channel. relay must give up and return false once its done channel is closed.
+func (s *clientSubscription) forward(ctx context.Context, relay func(done <-chan struct{}) bool, closeOut func()) {
+	defer close(s.err)
+	defer closeOut()
+	var err error
+	go func() {
+		select {
+		case err = <-s.sub.Err():
+		case <-ctx.Done():
+			s.sub.Unsubscribe()
+			err = ctx.Err()
+		}
+		close(s.done)
+	}()
+	for relay(s.done) {
+	}
+	<-s.done
+	if err != nil {
+		s.err <- err
+	}
+}
+
 func (c *client) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
 	var result hexutil.Big
-	err := c.r.CallContext(ctx, &result, "eth_getBalance", common.HexToAddress(address), toBlockNumArg(blockNumber))
+	err = c.call(ctx, &result, "eth_getBalance", addr, toBlockNumArg(blockNumber))
 	return (*big.Int)(&result), err
 }
 
+func (c *client) GetPendingBalance(ctx context.Context, address string) (*big.Int, error) {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	var result hexutil.Big
+	if err := c.call(ctx, &result, "eth_getBalance", addr, "pending"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&result), nil
+}
+
 func (c *client) GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error) {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
 	var result hexutil.Bytes
-	err := c.r.CallContext(ctx, &result, "eth_getCode", common.HexToAddress(address), toBlockNumArg(blockNumber))
+	err = c.call(ctx, &result, "eth_getCode", addr, toBlockNumArg(blockNumber))
 	return result, err
 }
 
+func (c *client) GetStorageAt(ctx context.Context, address string, slot common.Hash, blockNumber *big.Int) ([]byte, error) {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	var result hexutil.Bytes
+	if err := c.call(ctx, &result, "eth_getStorageAt", addr, slot, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StorageSlot returns the storage slot with the given index, for GetStorageAt, and a nil index is slot 0.
+// Variables which fit in a single slot are stored at their declaration index, with smaller ones packed together.
+func StorageSlot(index *big.Int) common.Hash {
+	if index == nil {
+		return common.Hash{}
+	}
+	return common.BigToHash(index)
+}
+
+func (c *client) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	var result hexutil.Uint64
+	err := c.call(ctx, &result, "eth_blockNumber")
+	return uint64(result), err
+}
+
 func (c *client) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
 	return c.getBlock(ctx, "eth_getBlockByNumber", toBlockNumArg(number), includeTxs)
 }
 
-func (c *client) GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*Block, error) {
-	return c.getBlock(ctx, "eth_getBlockByHash", hash, includeTxs)
+func (c *client) GetBlockByHash(ctx context.Context, blockHash string, includeTxs bool) (*Block, error) {
+	hash, err := parseHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return c.getBlock(ctx, "eth_getBlockByHash", hash.Hex(), includeTxs)
+}
+
+func (c *client) GetHeaderByNumber(ctx context.Context, number *big.Int) (*Header, error) {
+	b, err := c.GetBlockByNumber(ctx, number, false)
+	if err != nil {
+		return nil, err
+	}
+	return b.Header(), nil
+}
+
+func (c *client) GetHeaderByHash(ctx context.Context, hash string) (*Header, error) {
+	b, err := c.GetBlockByHash(ctx, hash, false)
+	if err != nil {
+		return nil, err
+	}
+	return b.Header(), nil
 }
 
 func (c *client) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
 	var tx *Transaction
-	err := c.r.CallContext(ctx, &tx, "eth_getTransactionByHash", hash.String())
+	err := c.call(ctx, &tx, "eth_getTransactionByHash", hash.String())
 	if err != nil {
 		return nil, err
 	} else if tx == nil {
-		return nil, NotFoundErr
+		return nil, &NotFoundError{Kind: "transaction", ID: hash.Hex()}
 	} else if tx.R == nil {
 		return nil, fmt.Errorf("server returned transaction without signature")
 	}
 	return tx, nil
 }
 
-func (c *client) GetSnapshot(ctx context.Context) (*Snapshot, error) {
-	var s Snapshot
-	err := c.r.CallContext(ctx, &s, "clique_getSnapshot", "latest")
+func (c *client) GetBlockTransactionCount(ctx context.Context, blockHash string) (uint64, error) {
+	hash, err := parseHash(blockHash)
+	if err != nil {
+		return 0, err
+	}
+	var count *hexutil.Uint64
+	if err := c.call(ctx, &count, "eth_getBlockTransactionCountByHash", hash); err != nil {
+		return 0, err
+	} else if count == nil {
+		return 0, &NotFoundError{Kind: "block", ID: hash.Hex()}
+	}
+	return uint64(*count), nil
+}
+
+func (c *client) GetTransactionInBlock(ctx context.Context, blockHash string, index uint64) (*Transaction, error) {
+	hash, err := parseHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	var tx *Transaction
+	err = c.call(ctx, &tx, "eth_getTransactionByBlockHashAndIndex", hash, hexutil.Uint64(index))
 	if err != nil {
 		return nil, err
+	} else if tx == nil {
+		return nil, &NotFoundError{Kind: "transaction", ID: fmt.Sprintf("%d in block %s", index, hash.Hex())}
+	} else if tx.R == nil {
+		return nil, fmt.Errorf("server returned transaction without signature")
 	}
-	return &s, nil
+	return tx, nil
+}
+
+func (c *client) GetSnapshot(ctx context.Context) (*Snapshot, error) {
+	return c.GetSnapshotAt(ctx, nil)
+}
+
+func (c *client) GetSnapshotAt(ctx context.Context, blockNumber *big.Int) (*Snapshot, error) {
+	var s *Snapshot
+	err := c.call(ctx, &s, "clique_getSnapshot", toBlockNumArg(blockNumber))
+	var rpcErr rpc.Error
+	if err != nil && !(errors.As(err, &rpcErr) && rpcErr.Error() == "unknown block") {
+		return nil, err
+	} else if s == nil {
+		id := "latest"
+		if blockNumber != nil {
+			id = blockNumber.String()
+		}
+		return nil, &NotFoundError{Kind: "snapshot at block", ID: id}
+	}
+	return s, nil
 }
 
 func (c *client) GetID(ctx context.Context) (*ID, error) {
@@ -129,25 +946,57 @@ func (c *client) GetID(ctx context.Context) (*ID, error) {
 		{Method: "net_version", Result: &netIDStr},
 		{Method: "eth_chainId", Result: chainID},
 	}
-	if err := c.r.BatchCallContext(ctx, batch); err != nil {
+	if err := c.batchCall(ctx, batch); err != nil {
 		return nil, err
 	}
-	for _, e := range batch {
-		if e.Error != nil {
-			log.Printf("Method %q failed: %v\n", e.Method, e.Error)
-		}
+	var id ID
+	var errs MultiError
+	if err := batch[0].Error; err != nil {
+		errs = append(errs, fmt.Errorf("failed to get genesis block: %w", err))
+	} else {
+		id.GenesisHash = block.Hash
 	}
-	netID := new(big.Int)
-	if _, ok := netID.SetString(netIDStr, 10); !ok {
-		return nil, fmt.Errorf("invalid net_version result %q", netIDStr)
+	if err := batch[1].Error; err != nil {
+		errs = append(errs, fmt.Errorf("failed to get network id: %w", err))
+	} else if netID, ok := new(big.Int).SetString(netIDStr, 10); !ok {
+		errs = append(errs, fmt.Errorf("invalid net_version result %q", netIDStr))
+	} else {
+		id.NetworkID = netID
+	}
+	if err := batch[2].Error; err != nil {
+		errs = append(errs, fmt.Errorf("failed to get chain id: %w", err))
+	} else {
+		id.ChainID = (*big.Int)(chainID)
+		c.setChainID(id.ChainID)
+	}
+	switch len(errs) {
+	case 0:
+		return &id, nil
+	case len(batch):
+		return nil, errs
+	default:
+		logf(c.opts.Logger, "web3: returning partial id: %v", errs)
+		return &id, errs
 	}
-	return &ID{NetworkID: netID, ChainID: (*big.Int)(chainID), GenesisHash: block.Hash}, nil
+}
+
+// GetIDStrict is like Client.GetID, but fails with the first error instead of returning a partial ID.
+func GetIDStrict(ctx context.Context, client Client) (*ID, error) {
+	id, err := client.GetID(ctx)
+	var errs MultiError
+	if errors.As(err, &errs) && len(errs) > 0 {
+		return nil, errs[0]
+	}
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
 }
 
 func (c *client) GetNetworkID(ctx context.Context) (*big.Int, error) {
 	version := new(big.Int)
 	var ver string
-	if err := c.r.CallContext(ctx, &ver, "net_version"); err != nil {
+	if err := c.call(ctx, &ver, "net_version"); err != nil {
 		return nil, err
 	}
 	if _, ok := version.SetString(ver, 10); !ok {
@@ -158,16 +1007,58 @@ func (c *client) GetNetworkID(ctx context.Context) (*big.Int, error) {
 
 func (c *client) GetChainID(ctx context.Context) (*big.Int, error) {
 	var result hexutil.Big
-	err := c.r.CallContext(ctx, &result, "eth_chainId")
+	err := c.call(ctx, &result, "eth_chainId")
 	return (*big.Int)(&result), err
 }
 
+func (c *client) ChainID(ctx context.Context) (*big.Int, error) {
+	c.mu.Lock()
+	chainID := c.chainID
+	c.mu.Unlock()
+	if chainID != nil {
+		return new(big.Int).Set(chainID), nil
+	}
+	chainID, err := c.GetChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.setChainID(chainID)
+	return chainID, nil
+}
+
+func (c *client) ResetChainID() {
+	c.setChainID(nil)
+}
+
+// setChainID caches a copy of chainID, or clears the cache if it is nil.
+func (c *client) setChainID(chainID *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if chainID == nil {
+		c.chainID = nil
+		return
+	}
+	c.chainID = new(big.Int).Set(chainID)
+}
+
+func (c *client) GetTransactionSender(ctx context.Context, tx *types.Transaction) (common.Address, error) {
+	if !tx.Protected() {
+		// Signed before EIP-155, without a chain id.
+		return types.Sender(types.HomesteadSigner{}, tx)
+	}
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("cannot get chain id: %w", err)
+	}
+	return types.Sender(types.NewEIP155Signer(chainID), tx)
+}
+
 func (c *client) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
 	var r *Receipt
-	err := c.r.CallContext(ctx, &r, "eth_getTransactionReceipt", hash)
+	err := c.call(ctx, &r, "eth_getTransactionReceipt", hash)
 	if err == nil {
 		if r == nil {
-			return nil, NotFoundErr
+			return nil, &NotFoundError{Kind: "receipt", ID: hash.Hex()}
 		}
 	}
 	return r, err
@@ -175,7 +1066,15 @@ func (c *client) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*
 
 func (c *client) GetGasPrice(ctx context.Context) (*big.Int, error) {
 	var hex hexutil.Big
-	if err := c.r.CallContext(ctx, &hex, "eth_gasPrice"); err != nil {
+	if err := c.call(ctx, &hex, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&hex), nil
+}
+
+func (c *client) GetGasTipCap(ctx context.Context) (*big.Int, error) {
+	var hex hexutil.Big
+	if err := c.call(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
 		return nil, err
 	}
 	return (*big.Int)(&hex), nil
@@ -185,23 +1084,31 @@ func (c *client) GetPendingTransactionCount(ctx context.Context, account common.
 	return c.getTransactionCount(ctx, account, "pending")
 }
 
+func (c *client) GetPendingNonce(ctx context.Context, address string) (uint64, error) {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return 0, err
+	}
+	return c.getTransactionCount(ctx, addr, "pending")
+}
+
 func (c *client) getTransactionCount(ctx context.Context, account common.Address, blockNumArg string) (uint64, error) {
 	var result hexutil.Uint64
-	err := c.r.CallContext(ctx, &result, "eth_getTransactionCount", account, blockNumArg)
+	err := c.call(ctx, &result, "eth_getTransactionCount", account, blockNumArg)
 	return uint64(result), err
 }
 
 func (c *client) SendRawTransaction(ctx context.Context, tx []byte) error {
-	return c.r.CallContext(ctx, nil, "eth_sendRawTransaction", common.ToHex(tx))
+	return c.call(ctx, nil, "eth_sendRawTransaction", common.ToHex(tx))
 }
 
 func (c *client) getBlock(ctx context.Context, method string, hashOrNum string, includeTxs bool) (*Block, error) {
 	var raw json.RawMessage
-	err := c.r.CallContext(ctx, &raw, method, hashOrNum, includeTxs)
+	err := c.call(ctx, &raw, method, hashOrNum, includeTxs)
 	if err != nil {
 		return nil, err
 	} else if len(raw) == 0 {
-		return nil, NotFoundErr
+		return nil, &NotFoundError{Kind: "block", ID: hashOrNum}
 	}
 	var block Block
 	if err := json.Unmarshal(raw, &block); err != nil {
@@ -232,7 +1139,7 @@ func (c *client) getBlock(ctx context.Context, method string, hashOrNum string,
 				Result: &uncles[i],
 			}
 		}
-		if err := c.r.BatchCallContext(ctx, reqs); err != nil {
+		if err := c.batchCall(ctx, reqs); err != nil {
 			return nil, err
 		}
 		for i := range reqs {
@@ -247,6 +1154,18 @@ func (c *client) getBlock(ctx context.Context, method string, hashOrNum string,
 	return &block, nil
 }
 
+// parseHash parses a 0x prefixed 32 byte hex hash.
+func parseHash(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid hash %q: %w", s, err)
+	}
+	if len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid hash %q: must be %d bytes", s, common.HashLength)
+	}
+	return common.BytesToHash(b), nil
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -254,6 +1173,30 @@ func toBlockNumArg(number *big.Int) string {
 	return hexutil.EncodeBig(number)
 }
 
+func toFilterArg(q gochain.FilterQuery) (interface{}, error) {
+	arg := map[string]interface{}{
+		"address": q.Addresses,
+		"topics":  q.Topics,
+	}
+	if q.BlockHash != nil {
+		if q.FromBlock != nil || q.ToBlock != nil {
+			return nil, errors.New("cannot specify both BlockHash and FromBlock/ToBlock")
+		}
+		arg["blockHash"] = *q.BlockHash
+		return arg, nil
+	}
+	if q.FromBlock != nil && q.ToBlock != nil && q.FromBlock.Cmp(q.ToBlock) > 0 {
+		return nil, fmt.Errorf("invalid block range: fromBlock %s is after toBlock %s", q.FromBlock, q.ToBlock)
+	}
+	if q.FromBlock == nil {
+		arg["fromBlock"] = "0x0"
+	} else {
+		arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+	}
+	arg["toBlock"] = toBlockNumArg(q.ToBlock)
+	return arg, nil
+}
+
 func toCallArg(msg CallMsg) interface{} {
 	arg := map[string]interface{}{
 		"from": msg.From,
@@ -1,12 +1,1500 @@
 package web3
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3"
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/gochain/gochain/v3/rlp"
+	"github.com/gochain/gochain/v3/rpc"
 )
 
-func ExampleRPCClient_GetBlockByNumber() {
+// newTestClient returns a Client backed by an in-process server with the given services registered by namespace.
+func newTestClient(t *testing.T, services map[string]interface{}) Client {
+	t.Helper()
+	srv := rpc.NewServer()
+	for name, svc := range services {
+		if err := srv.RegisterName(name, svc); err != nil {
+			t.Fatalf("failed to register %q service: %v", name, err)
+		}
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	t.Cleanup(c.Close)
+	return c
+}
+
+// FakeEthService implements a subset of the eth namespace backed by its fields. It is for testing the client
+// itself, and the functions whose JSON-RPC requests matter, ie: block tags, batches or state overrides. Everything
+// else is tested in package web3_test against web3test.FakeClient.
+type FakeEthService struct {
+	mu      sync.Mutex
+	Balance *big.Int
+	// Balances overrides Balance by address, and a nil entry makes GetBalance fail.
+	Balances map[common.Address]*big.Int
+	Price    *big.Int
+	// TipCap is returned by MaxPriorityFeePerGas, which fails if it is nil.
+	TipCap *big.Int
+	Nonce  uint64
+	Sent   []*types.Transaction
+	// Tags records the block argument of each GetBalance, GetTransactionCount and Call request.
+	Tags []string
+	// ChainID is returned by ChainId, which fails if it is nil. ChainIDCalls counts the requests.
+	ChainID      *big.Int
+	ChainIDCalls int
+
+	// CallFunc handles eth_call, if set.
+	CallFunc func(msg map[string]interface{}) ([]byte, error)
+
+	// Gas is returned by EstimateGas, unless EstimateErr is set.
+	Gas         uint64
+	EstimateErr error
+	Estimated   []map[string]interface{}
+
+	// Blocks are served by number, with Head as the latest, which is also returned by BlockNumber. FullTxs records
+	// the full transactions argument of each block request.
+	Blocks  map[uint64]*Block
+	Head    uint64
+	FullTxs []bool
+
+	Receipts     map[common.Hash]*Receipt
+	receiptCalls int
+
+	// Code is returned by GetCode.
+	Code map[common.Address][]byte
+	// Storage is returned by GetStorageAt, with missing slots as zero.
+	Storage map[common.Address]map[common.Hash]common.Hash
+
+	// Logs are returned by GetLogs, which records its filter arguments in Filters.
+	Logs    []types.Log
+	Filters []map[string]interface{}
+	// Pending are notified to NewPendingTransactions subscribers in a loop, and served by GetTransactionByHash.
+	Pending []*Transaction
+	// MaxLogRange, if set, makes GetLogs reject block ranges spanning more blocks, and only return the Logs
+	// within the requested range.
+	MaxLogRange uint64
+}
+
+func (s *FakeEthService) GetLogs(args map[string]interface{}) ([]types.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Filters = append(s.Filters, args)
+	if s.MaxLogRange == 0 {
+		return s.Logs, nil
+	}
+	from, err := hexutil.DecodeUint64(args["fromBlock"].(string))
+	if err != nil {
+		return nil, err
+	}
+	to, err := hexutil.DecodeUint64(args["toBlock"].(string))
+	if err != nil {
+		return nil, err
+	}
+	if to-from+1 > s.MaxLogRange {
+		return nil, fmt.Errorf("query returned more than 10000 results")
+	}
+	logs := []types.Log{}
+	for _, l := range s.Logs {
+		if l.BlockNumber >= from && l.BlockNumber <= to {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+// NewHeads notifies the subscriber of a new header every few milliseconds until it unsubscribes.
+// Headers sent before the subscription is activated are dropped, so numbering may not start at 1.
+func (s *FakeEthService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for n := int64(1); ; n++ {
+			select {
+			case <-ticker.C:
+				h := &types.Header{Number: big.NewInt(n), Difficulty: big.NewInt(1), Time: big.NewInt(n)}
+				if err := notifier.Notify(sub.ID, h); err != nil {
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// NewPendingTransactions notifies the subscriber of the hashes of Pending every few milliseconds, repeating
+// them until it unsubscribes.
+func (s *FakeEthService) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-ticker.C:
+				if err := notifier.Notify(sub.ID, s.Pending[i%len(s.Pending)].Hash); err != nil {
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func (s *FakeEthService) GetTransactionByHash(hash common.Hash) *Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tx := range s.Pending {
+		if tx.Hash == hash {
+			return tx
+		}
+	}
+	return nil
+}
+
+func (s *FakeEthService) GetTransactionReceipt(hash common.Hash) *Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receiptCalls++
+	return s.Receipts[hash]
+}
+
+func (s *FakeEthService) GetCode(address common.Address, block string) hexutil.Bytes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Code[address]
+}
+
+func (s *FakeEthService) GetStorageAt(address common.Address, slot common.Hash, block string) hexutil.Bytes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value := s.Storage[address][slot]
+	return value[:]
+}
+
+func (s *FakeEthService) GetBalance(address common.Address, block string) (*hexutil.Big, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Tags = append(s.Tags, block)
+	if bal, ok := s.Balances[address]; ok {
+		if bal == nil {
+			return nil, fmt.Errorf("no balance for %s", address.Hex())
+		}
+		return (*hexutil.Big)(bal), nil
+	}
+	if s.Balance == nil {
+		return (*hexutil.Big)(big.NewInt(0)), nil
+	}
+	return (*hexutil.Big)(s.Balance), nil
+}
+
+func (s *FakeEthService) GasPrice() *hexutil.Big {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Price == nil {
+		return (*hexutil.Big)(big.NewInt(0))
+	}
+	return (*hexutil.Big)(s.Price)
+}
+
+func (s *FakeEthService) MaxPriorityFeePerGas() (*hexutil.Big, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.TipCap == nil {
+		return nil, errors.New("the method eth_maxPriorityFeePerGas does not exist/is not available")
+	}
+	return (*hexutil.Big)(s.TipCap), nil
+}
+
+func (s *FakeEthService) Call(msg map[string]interface{}, block string) (hexutil.Bytes, error) {
+	s.mu.Lock()
+	s.Tags = append(s.Tags, block)
+	s.mu.Unlock()
+	if s.CallFunc == nil {
+		return nil, nil
+	}
+	return s.CallFunc(msg)
+}
+
+func (s *FakeEthService) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.Head)
+}
+
+func (s *FakeEthService) GetBlockByNumber(number string, full bool) (*Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FullTxs = append(s.FullTxs, full)
+	n := s.Head
+	if number != "latest" {
+		var err error
+		if n, err = hexutil.DecodeUint64(number); err != nil {
+			return nil, err
+		}
+	}
+	return s.Blocks[n], nil
+}
+
+func (s *FakeEthService) blockByHash(hash common.Hash) *Block {
+	for _, b := range s.Blocks {
+		if b.Hash == hash {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *FakeEthService) GetBlockByHash(hash common.Hash, full bool) *Block {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FullTxs = append(s.FullTxs, full)
+	return s.blockByHash(hash)
+}
+
+func (s *FakeEthService) GetBlockTransactionCountByHash(hash common.Hash) *hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.blockByHash(hash)
+	if b == nil {
+		return nil
+	}
+	n := hexutil.Uint64(b.TxCount())
+	return &n
+}
+
+func (s *FakeEthService) GetTransactionByBlockHashAndIndex(hash common.Hash, index hexutil.Uint64) *Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.blockByHash(hash)
+	if b == nil || int(index) >= len(b.TxDetails) {
+		return nil
+	}
+	return b.TxDetails[index]
+}
+
+func (s *FakeEthService) ChainId() (*hexutil.Big, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ChainIDCalls++
+	if s.ChainID == nil {
+		return nil, errors.New("the method eth_chainId does not exist/is not available")
+	}
+	return (*hexutil.Big)(s.ChainID), nil
+}
+
+func (s *FakeEthService) EstimateGas(args map[string]interface{}) (hexutil.Uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Estimated = append(s.Estimated, args)
+	if s.EstimateErr != nil {
+		return 0, s.EstimateErr
+	}
+	return hexutil.Uint64(s.Gas), nil
+}
+
+func (s *FakeEthService) GetTransactionCount(address common.Address, block string) hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Tags = append(s.Tags, block)
+	return hexutil.Uint64(s.Nonce)
+}
+
+func (s *FakeEthService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return common.Hash{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Sent = append(s.Sent, tx)
+	return tx.Hash(), nil
+}
+
+func TestDial_error(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		url  string
+	}{
+		{name: "unreachable", url: filepath.Join(t.TempDir(), "missing.ipc")},
+		{name: "malformed", url: "http://[::1"},
+		{name: "unknown-scheme", url: "ftp://localhost:8545"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Dial(tt.url)
+			if err == nil {
+				c.Close()
+				t.Fatalf("expected error dialing %q", tt.url)
+			}
+			if errors.Unwrap(err) == nil {
+				t.Errorf("expected wrapped dial error, got: %v", err)
+			}
+		})
+	}
+}
+
+// FakeNetService implements the net namespace.
+type FakeNetService struct {
+	NetworkID string
+}
+
+func (s *FakeNetService) Version() string {
+	return s.NetworkID
+}
+
+// testBlock returns a valid empty block with number n.
+func testBlock(n uint64) *Block {
+	return &Block{
+		ParentHash: common.BigToHash(new(big.Int).SetUint64(n - 1)),
+		Sha3Uncles: types.EmptyUncleHash,
+		TxsRoot:    types.EmptyRootHash,
+		LogsBloom:  new(types.Bloom),
+		Difficulty: big.NewInt(1),
+		Number:     new(big.Int).SetUint64(n),
+		Timestamp:  time.Unix(int64(n), 0).UTC(),
+		Hash:       common.BigToHash(new(big.Int).SetUint64(n + 1000)),
+		TxHashes:   []common.Hash{},
+	}
+}
+
+func TestClient_GetID(t *testing.T) {
+	ctx := context.Background()
+	genesis := testBlock(0)
+	for _, tt := range []struct {
+		name     string
+		services map[string]interface{}
+		wantID   *ID
+		wantErrs int
+	}{
+		{
+			name: "success",
+			services: map[string]interface{}{
+				"eth": &FakeEthService{ChainID: big.NewInt(60), Blocks: map[uint64]*Block{0: genesis}},
+				"net": &FakeNetService{NetworkID: "60"},
+			},
+			wantID: &ID{NetworkID: big.NewInt(60), ChainID: big.NewInt(60), GenesisHash: genesis.Hash},
+		},
+		{
+			name: "partial",
+			services: map[string]interface{}{
+				"eth": &FakeEthService{Blocks: map[uint64]*Block{0: genesis}},
+				"net": &FakeNetService{NetworkID: "sixty"},
+			},
+			wantID:   &ID{GenesisHash: genesis.Hash},
+			wantErrs: 2,
+		},
+		{
+			name:     "failure",
+			services: map[string]interface{}{"net": &FakeNetService{NetworkID: "x"}},
+			wantErrs: 3,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.services)
+			id, err := c.GetID(ctx)
+			if tt.wantErrs == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if errs, ok := err.(MultiError); !ok {
+				t.Fatalf("expected MultiError but got: %v", err)
+			} else if len(errs) != tt.wantErrs {
+				t.Errorf("expected %d errors but got: %v", tt.wantErrs, err)
+			}
+			if !reflect.DeepEqual(id, tt.wantID) {
+				t.Errorf("expected id %+v but got %+v", tt.wantID, id)
+			}
+		})
+	}
+}
+
+func TestGetIDStrict(t *testing.T) {
+	ctx := context.Background()
+	genesis := testBlock(0)
+	c := newTestClient(t, map[string]interface{}{
+		"eth": &FakeEthService{ChainID: big.NewInt(60), Blocks: map[uint64]*Block{0: genesis}},
+		"net": &FakeNetService{NetworkID: "60"},
+	})
+	id, err := GetIDStrict(ctx, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (&ID{NetworkID: big.NewInt(60), ChainID: big.NewInt(60), GenesisHash: genesis.Hash}); !reflect.DeepEqual(id, want) {
+		t.Errorf("expected id %+v but got %+v", want, id)
+	}
+
+	// The chain id fails, and the network id is 0 rather than missing.
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", &FakeEthService{Blocks: map[uint64]*Block{0: genesis}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.RegisterName("net", &FakeNetService{NetworkID: "0"}); err != nil {
+		t.Fatal(err)
+	}
+	logger := &testLogger{}
+	c = NewClientWithOptions(rpc.DialInProc(srv), ClientOptions{Logger: logger})
+	if id, err := c.GetID(ctx); err == nil || id == nil || id.NetworkID == nil || id.NetworkID.Sign() != 0 || id.ChainID != nil {
+		t.Errorf("expected network id 0 and no chain id but got %+v: %v", id, err)
+	}
+	if msgs := logger.messages(); len(msgs) != 1 || !strings.Contains(msgs[0], "partial id") || !strings.Contains(msgs[0], "chain id") {
+		t.Errorf("expected the partial id to be logged but got %q", msgs)
+	}
+	id, err = GetIDStrict(ctx, c)
+	if err == nil || !strings.Contains(err.Error(), "chain id") {
+		t.Errorf("expected chain id error but got: %v", err)
+	}
+	if _, ok := err.(MultiError); ok {
+		t.Errorf("expected the first error rather than a MultiError")
+	}
+	if id != nil {
+		t.Errorf("expected no id but got %+v", id)
+	}
+
+	c.Close()
+	if _, err := GetIDStrict(ctx, c); err != ErrClientClosed {
+		t.Errorf("expected %v after close, got: %v", ErrClientClosed, err)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{"eth": &FakeEthService{}})
+	ctx := context.Background()
+	if _, err := c.GetBalance(ctx, common.Address{}.Hex(), nil); err != nil {
+		t.Fatalf("unexpected error before close: %v", err)
+	}
+	c.Close()
+	c.Close()
+	if _, err := c.GetBalance(ctx, common.Address{}.Hex(), nil); err != ErrClientClosed {
+		t.Errorf("expected %v after close, got: %v", ErrClientClosed, err)
+	}
+	if _, err := c.GetID(ctx); err != ErrClientClosed {
+		t.Errorf("expected %v after close, got: %v", ErrClientClosed, err)
+	}
+}
+
+func TestClient_SubscribeNewHead(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{"eth": &FakeEthService{}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	subCtx, subCancel := context.WithCancel(ctx)
+	defer subCancel()
+
+	heads, sub, err := c.SubscribeNewHead(subCtx)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	var last *big.Int
+	for i := 0; i < 3; i++ {
+		select {
+		case h, ok := <-heads:
+			if !ok {
+				t.Fatalf("channel closed early: %v", <-sub.Err())
+			}
+			if last != nil && h.Number.Cmp(new(big.Int).Add(last, big.NewInt(1))) != 0 {
+				t.Errorf("expected header after %s but got %s", last, h.Number)
+			}
+			last = h.Number
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for headers")
+		}
+	}
+
+	subCancel()
+	for {
+		select {
+		case _, ok := <-heads:
+			if ok {
+				continue
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for channel to close")
+		}
+		break
+	}
+	if err := <-sub.Err(); err != context.Canceled {
+		t.Errorf("expected %v but got: %v", context.Canceled, err)
+	}
+}
+
+func TestClient_SubscribeNewHead_http(t *testing.T) {
+	c, err := Dial("http://127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+	_, _, err = c.SubscribeNewHead(context.Background())
+	if !errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		t.Errorf("expected %v but got: %v", rpc.ErrNotificationsUnsupported, err)
+	}
+}
+
+func TestDial_transports(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", &FakeEthService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	ipcPath := filepath.Join(t.TempDir(), "web3.ipc")
+	l, err := net.Listen("unix", ipcPath)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", ipcPath, err)
+	}
+	defer l.Close()
+	go srv.ServeListener(l)
+	ws := httptest.NewServer(srv.WebsocketHandler([]string{"*"}))
+	defer ws.Close()
+	httpSrv := httptest.NewServer(srv)
+	defer httpSrv.Close()
+
+	for _, tt := range []struct {
+		url       string
+		transport string
+	}{
+		{url: ipcPath, transport: TransportIPC},
+		{url: "ws" + strings.TrimPrefix(ws.URL, "http"), transport: TransportWebsocket},
+		{url: httpSrv.URL, transport: TransportHTTP},
+	} {
+		t.Run(tt.transport, func(t *testing.T) {
+			c, err := Dial(tt.url)
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+			if got := c.Transport(); got != tt.transport {
+				t.Errorf("expected transport %q but got %q", tt.transport, got)
+			}
+			if got, want := c.SupportsSubscriptions(), tt.transport != TransportHTTP; got != want {
+				t.Errorf("expected SupportsSubscriptions %t but got %t", want, got)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			heads, _, err := c.SubscribeNewHead(ctx)
+			if tt.transport == TransportHTTP {
+				if !errors.Is(err, ErrSubscriptionUnsupported) {
+					t.Errorf("expected %v but got: %v", ErrSubscriptionUnsupported, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to subscribe: %v", err)
+			}
+			select {
+			case h := <-heads:
+				if h == nil {
+					t.Error("expected a header")
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for header")
+			}
+		})
+	}
+}
+
+func Test_transportOf(t *testing.T) {
+	for url, want := range map[string]string{
+		"http://localhost:8545":           TransportHTTP,
+		"https://rpc.gochain.io":          TransportHTTP,
+		"HTTPS://rpc.gochain.io":          TransportHTTP,
+		"ws://localhost:8546":             TransportWebsocket,
+		"wss://mainnet.infura.io/ws/v3/x": TransportWebsocket,
+		"/var/lib/gochain/gochain.ipc":    TransportIPC,
+		"gochain.ipc":                     TransportIPC,
+		"stdio://":                        "",
+	} {
+		if got := transportOf(url); got != want {
+			t.Errorf("%s: expected transport %q but got %q", url, want, got)
+		}
+	}
+}
+
+func TestDialWithOptions_http(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("test", EchoService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	var mu sync.Mutex
+	var reqs []*http.Request
+	var batches int
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		reqs = append(reqs, r)
+		if bytes.HasPrefix(body, []byte("[")) {
+			batches++
+		}
+		mu.Unlock()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		srv.ServeHTTP(w, r)
+	}))
+	defer httpSrv.Close()
+
+	var certs int
+	hc := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		certs++
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	c, err := DialWithOptions(httpSrv.URL, ClientOptions{
+		HTTPClient: hc,
+		Header:     http.Header{"X-Api-Key": {"secret"}},
+		Username:   "user",
+		Password:   "pass",
+	})
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	if err := c.RawCall(ctx, nil, "test_echo", "hello", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// GetID is a batch request, and fails because the eth namespace is missing.
+	c.GetID(ctx)
+
+	if len(reqs) != 2 || batches != 1 {
+		t.Fatalf("expected a single and a batch request but got %d requests with %d batches", len(reqs), batches)
+	}
+	for _, r := range reqs {
+		if got := r.Header.Get("X-Api-Key"); got != "secret" {
+			t.Errorf("expected api key header but got %q", got)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			t.Errorf("expected basic auth but got %q %q %t", user, pass, ok)
+		}
+	}
+	if certs != 2 {
+		t.Errorf("expected custom HTTP client to make 2 requests but got %d", certs)
+	}
+
+	if _, err := DialWithOptions("ws://127.0.0.1:0", ClientOptions{Username: "user"}); err == nil {
+		t.Error("expected error for basic auth over websocket")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// EchoService implements a custom namespace.
+type EchoService struct{}
+
+func (EchoService) Echo(s string, n int) map[string]interface{} {
+	return map[string]interface{}{"s": s, "n": n}
+}
+
+// Sleep blocks for ms milliseconds.
+func (EchoService) Sleep(ms int) {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+}
+
+func TestClient_DefaultTimeout(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("test", EchoService{}); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClientWithOptions(rpc.DialInProc(srv), ClientOptions{DefaultTimeout: 20 * time.Millisecond})
+	defer c.Close()
+
+	if err := c.RawCall(context.Background(), nil, "test_sleep", 1000); err != context.DeadlineExceeded {
+		t.Errorf("expected %v without a deadline but got: %v", context.DeadlineExceeded, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.RawCall(ctx, nil, "test_sleep", 100); err != nil {
+		t.Errorf("expected explicit deadline to be preserved but got: %v", err)
+	}
+}
+
+// testLogger is a Logger which captures messages, like a *log.Logger would.
+type testLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+var _ Logger = (*log.Logger)(nil)
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, v...))
+}
+
+func (l *testLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.msgs...)
+}
+
+func TestClient_Retry(t *testing.T) {
+	const ok = `{"jsonrpc":"2.0","id":1,"result":"0x2a"}`
+	for _, tt := range []struct {
+		name      string
+		responses []string // status and body, the last is repeated
+		wantErr   bool
+		wantReqs  int
+	}{
+		{name: "unavailable", responses: []string{"503", "503", ok}, wantReqs: 3},
+		{name: "rate-limited", responses: []string{"429", `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"daily request count exceeded, request rate limited"}}`, ok}, wantReqs: 3},
+		{name: "exhausted", responses: []string{"503"}, wantErr: true, wantReqs: 4},
+		{name: "nonce-too-low", responses: []string{`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`, ok}, wantErr: true, wantReqs: 1},
+		{name: "bad-request", responses: []string{"400", ok}, wantErr: true, wantReqs: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var reqs int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := tt.responses[len(tt.responses)-1]
+				if reqs < len(tt.responses) {
+					resp = tt.responses[reqs]
+				}
+				reqs++
+				if code, err := strconv.Atoi(resp); err == nil {
+					w.WriteHeader(code)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, resp)
+			}))
+			defer srv.Close()
+			c, err := DialWithOptions(srv.URL, ClientOptions{MaxRetries: 3, RetryBackoff: time.Millisecond})
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+
+			var got hexutil.Uint64
+			err = c.RawCall(context.Background(), &got, "eth_blockNumber")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if got != 42 {
+				t.Errorf("expected 42 but got %d", got)
+			}
+			if reqs != tt.wantReqs {
+				t.Errorf("expected %d requests but got %d", tt.wantReqs, reqs)
+			}
+		})
+	}
+
+	unavailable := func(t *testing.T, opts ClientOptions) (Client, *int) {
+		var reqs int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqs++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		t.Cleanup(srv.Close)
+		c, err := DialWithOptions(srv.URL, opts)
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		t.Cleanup(c.Close)
+		return c, &reqs
+	}
+
+	t.Run("send", func(t *testing.T) {
+		c, reqs := unavailable(t, ClientOptions{MaxRetries: 3, RetryBackoff: time.Millisecond})
+		if err := c.SendRawTransaction(context.Background(), []byte{0xc0}); err == nil {
+			t.Error("expected error")
+		}
+		if *reqs != 1 {
+			t.Errorf("expected transaction to be sent once but got %d requests", *reqs)
+		}
+	})
+
+	t.Run("batch send", func(t *testing.T) {
+		c, reqs := unavailable(t, ClientOptions{MaxRetries: 3, RetryBackoff: time.Millisecond})
+		err := c.BatchCall(context.Background(), []rpc.BatchElem{
+			{Method: "eth_blockNumber"},
+			{Method: "eth_sendRawTransaction", Args: []interface{}{"0xc0"}},
+		})
+		if err == nil {
+			t.Error("expected error")
+		}
+		if *reqs != 1 {
+			t.Errorf("expected batch with a transaction to be sent once but got %d requests", *reqs)
+		}
+	})
+
+	t.Run("retry-on", func(t *testing.T) {
+		c, reqs := unavailable(t, ClientOptions{MaxRetries: 3, RetryBackoff: time.Millisecond, RetryOn: func(err error) bool {
+			return !strings.HasPrefix(err.Error(), "503")
+		}})
+		if err := c.RawCall(context.Background(), nil, "eth_blockNumber"); err == nil {
+			t.Error("expected error")
+		}
+		if *reqs != 1 {
+			t.Errorf("expected RetryOn to prevent retries but got %d requests", *reqs)
+		}
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		logger := &testLogger{}
+		c, _ := unavailable(t, ClientOptions{MaxRetries: 2, RetryBackoff: time.Millisecond, Logger: logger})
+		if err := c.RawCall(context.Background(), nil, "eth_blockNumber"); err == nil {
+			t.Error("expected error")
+		}
+		msgs := logger.messages()
+		if len(msgs) != 2 {
+			t.Fatalf("expected 2 retries logged but got %q", msgs)
+		}
+		for i, msg := range msgs {
+			if want := fmt.Sprintf("after attempt %d failed: 503", i+1); !strings.HasPrefix(msg, "web3: retrying eth_blockNumber in ") || !strings.Contains(msg, want) {
+				t.Errorf("expected retry message containing %q but got %q", want, msg)
+			}
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		c, reqs := unavailable(t, ClientOptions{MaxRetries: 3, RetryBackoff: time.Hour})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		if err := c.RawCall(ctx, nil, "eth_blockNumber"); err == nil || err == context.DeadlineExceeded {
+			t.Errorf("expected the last error without waiting for the deadline but got: %v", err)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("expected to give up early but took %s", d)
+		}
+		if *reqs != 1 {
+			t.Errorf("expected 1 request but got %d", *reqs)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		c, _ := unavailable(t, ClientOptions{MaxRetries: 3, RetryBackoff: time.Hour})
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		if err := c.RawCall(ctx, nil, "eth_blockNumber"); err != context.Canceled {
+			t.Errorf("expected %v while waiting to retry but got: %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestClient_RawCall(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{"test": EchoService{}})
+	var result struct {
+		S string
+		N int
+	}
+	if err := c.RawCall(context.Background(), &result, "test_echo", "hello", 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.S != "hello" || result.N != 7 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	err := c.RawCall(context.Background(), nil, "test_missing")
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Method != "test_missing" {
+		t.Errorf("expected *RPCError for missing method but got: %v", err)
+	}
+	c.Close()
+	if err := c.RawCall(context.Background(), &result, "test_echo", "hello", 7); err != ErrClientClosed {
+		t.Errorf("expected %v after close, got: %v", ErrClientClosed, err)
+	}
+}
+
+func TestClient_BatchCall(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("test", EchoService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	var mu sync.Mutex
+	var reqs int
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqs++
+		mu.Unlock()
+		srv.ServeHTTP(w, r)
+	}))
+	defer httpSrv.Close()
+
+	for _, tt := range []struct {
+		maxBatchSize int
+		wantReqs     int
+	}{{0, 1}, {2, 3}, {5, 1}} {
+		t.Run(fmt.Sprintf("max %d", tt.maxBatchSize), func(t *testing.T) {
+			c, err := DialWithOptions(httpSrv.URL, ClientOptions{MaxBatchSize: tt.maxBatchSize})
+			if err != nil {
+				t.Fatalf("failed to dial: %v", err)
+			}
+			defer c.Close()
+			mu.Lock()
+			reqs = 0
+			mu.Unlock()
+			results := make([]struct {
+				S string
+				N int
+			}, 5)
+			batch := make([]rpc.BatchElem, len(results))
+			for i := range batch {
+				batch[i] = rpc.BatchElem{Method: "test_echo", Args: []interface{}{"hello", i}, Result: &results[i]}
+			}
+			batch[3].Method = "test_missing"
+			if err := c.BatchCall(context.Background(), batch); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, r := range results {
+				if i == 3 {
+					if batch[i].Error == nil {
+						t.Errorf("expected error for missing method")
+					}
+					continue
+				}
+				if batch[i].Error != nil || r.S != "hello" || r.N != i {
+					t.Errorf("%d: unexpected result %+v: %v", i, r, batch[i].Error)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if reqs != tt.wantReqs {
+				t.Errorf("expected %d requests but got %d", tt.wantReqs, reqs)
+			}
+		})
+	}
+}
+
+func TestGetBalances(t *testing.T) {
+	addrs := []string{
+		"0x0000000000000000000000000000000000000001",
+		"0x0000000000000000000000000000000000000002",
+		"0x0000000000000000000000000000000000000003",
+	}
+	eth := &FakeEthService{Balances: map[common.Address]*big.Int{
+		common.HexToAddress(addrs[0]): big.NewInt(10),
+		common.HexToAddress(addrs[1]): nil,
+		common.HexToAddress(addrs[2]): big.NewInt(30),
+	}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	balances, err := GetBalances(ctx, c, []string{addrs[2], addrs[0]}, big.NewInt(5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(balances) != 2 || balances[0].Int64() != 30 || balances[1].Int64() != 10 {
+		t.Errorf("expected balances [30 10] but got %v", balances)
+	}
+	if want := []string{"0x5", "0x5"}; !reflect.DeepEqual(eth.Tags, want) {
+		t.Errorf("expected block tags %v but got %v", want, eth.Tags)
+	}
+
+	balances, err = GetBalances(ctx, c, addrs, nil)
+	var errs MultiError
+	if !errors.As(err, &errs) || len(errs) != 1 || !strings.Contains(errs[0].Error(), addrs[1]) {
+		t.Fatalf("expected a single error for %s but got: %v", addrs[1], err)
+	}
+	if len(balances) != 3 || balances[0].Int64() != 10 || balances[1] != nil || balances[2].Int64() != 30 {
+		t.Errorf("expected balances [10 <nil> 30] but got %v", balances)
+	}
+
+	if _, err := GetBalances(ctx, c, []string{"0x01"}, nil); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
+
+func TestGetTransactionReceipts(t *testing.T) {
+	hashes := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03")}
+	eth := &FakeEthService{Receipts: map[common.Hash]*Receipt{
+		hashes[0]: {TxHash: hashes[0], BlockNumber: 1, Logs: []*types.Log{}},
+		hashes[2]: {TxHash: hashes[2], BlockNumber: 3, Logs: []*types.Log{}},
+	}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	receipts, err := GetTransactionReceipts(ctx, c, []common.Hash{hashes[2], hashes[0]})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(receipts) != 2 || receipts[0].TxHash != hashes[2] || receipts[1].TxHash != hashes[0] {
+		t.Errorf("expected receipts for %s and %s but got %v", hashes[2].Hex(), hashes[0].Hex(), receipts)
+	}
+
+	receipts, err = GetTransactionReceipts(ctx, c, hashes)
+	var errs MultiError
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(errs[0], NotFoundErr) {
+		t.Fatalf("expected a single not found error but got: %v", err)
+	}
+	if len(receipts) != 3 || receipts[0] == nil || receipts[1] != nil || receipts[2] == nil {
+		t.Errorf("expected receipts [%s <nil> %s] but got %v", hashes[0].Hex(), hashes[2].Hex(), receipts)
+	}
+
+	if receipts, err := GetTransactionReceipts(ctx, c, nil); err != nil || receipts != nil {
+		t.Errorf("expected no receipts and no error but got %v: %v", receipts, err)
+	}
+}
+
+func TestClient_GetPending(t *testing.T) {
+	eth := &FakeEthService{Balance: big.NewInt(42), Nonce: 7}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+	const address = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+
+	bal, err := c.GetPendingBalance(ctx, address)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if bal.Cmp(eth.Balance) != 0 {
+		t.Errorf("expected balance %s but got %s", eth.Balance, bal)
+	}
+	nonce, err := c.GetPendingNonce(ctx, address)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if nonce != eth.Nonce {
+		t.Errorf("expected nonce %d but got %d", eth.Nonce, nonce)
+	}
+	if want := []string{"pending", "pending"}; !reflect.DeepEqual(eth.Tags, want) {
+		t.Errorf("expected block args %v but got %v", want, eth.Tags)
+	}
+
+	if _, err := c.GetPendingBalance(ctx, "0x01"); err == nil {
+		t.Error("expected error for invalid address")
+	}
+	if _, err := c.GetPendingNonce(ctx, "not an address"); err == nil {
+		t.Error("expected error for invalid address")
+	}
+	if len(eth.Tags) != 2 {
+		t.Errorf("expected invalid addresses to be rejected before the request, got %d requests", len(eth.Tags))
+	}
+}
+
+func TestClient_invalidAddress(t *testing.T) {
+	eth := &FakeEthService{Balance: big.NewInt(42)}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+	calls := map[string]func(address string) error{
+		"GetBalance":        func(a string) error { _, err := c.GetBalance(ctx, a, nil); return err },
+		"GetPendingBalance": func(a string) error { _, err := c.GetPendingBalance(ctx, a); return err },
+		"GetCode":           func(a string) error { _, err := c.GetCode(ctx, a, nil); return err },
+		"GetStorageAt":      func(a string) error { _, err := c.GetStorageAt(ctx, a, common.Hash{}, nil); return err },
+		"GetPendingNonce":   func(a string) error { _, err := c.GetPendingNonce(ctx, a); return err },
+	}
+	for name, call := range calls {
+		for _, bad := range []string{"", "0x", "0x0", "0x01", "not an address", common.Address{}.Hex() + "00",
+			"0xa25b5e2d2d63dad7fa940e239925f29320f5103g"} {
+			before := c.Stats().Requests
+			if err := call(bad); !errors.Is(err, ErrInvalidAddress) {
+				t.Errorf("%s(%q): expected %v but got: %v", name, bad, ErrInvalidAddress, err)
+			}
+			if c.Stats().Requests != before {
+				t.Errorf("%s(%q): expected no request for an invalid address", name, bad)
+			}
+		}
+		// The zero address is fine when written out in full, with or without 0x.
+		for _, good := range []string{common.Address{}.Hex(), common.Address{}.Hex()[2:], "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"} {
+			if err := call(good); err != nil {
+				t.Errorf("%s(%q): unexpected error: %v", name, good, err)
+			}
+		}
+	}
+}
+
+func TestClient_GasPrices(t *testing.T) {
+	ctx := context.Background()
+	eth := &FakeEthService{Price: Gwei(5), TipCap: Gwei(2)}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	if price, err := c.GetGasPrice(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if price.Cmp(eth.Price) != 0 {
+		t.Errorf("expected gas price %s but got %s", eth.Price, price)
+	}
+	if tip, err := c.GetGasTipCap(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if tip.Cmp(eth.TipCap) != 0 {
+		t.Errorf("expected tip cap %s but got %s", eth.TipCap, tip)
+	}
+
+	c = newTestClient(t, map[string]interface{}{"eth": &FakeEthService{}})
+	if _, err := c.GetGasTipCap(ctx); err == nil {
+		t.Error("expected error from network without EIP-1559")
+	}
+}
+
+func TestClient_EstimateGas(t *testing.T) {
+	ctx := context.Background()
+	to := common.HexToAddress("0x01")
+	eth := &FakeEthService{Gas: 21000}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	gas, err := c.EstimateGas(ctx, CallMsg{To: &to, Value: big.NewInt(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if gas != 21000 {
+		t.Errorf("expected 21000 but got %d", gas)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		err        error
+		wantRevert bool
+		wantReason string
+	}{
+		{"reason", errors.New("execution reverted: insufficient balance"), true, "insufficient balance"},
+		{"no-reason", errors.New("execution reverted"), true, ""},
+		{"other", errors.New("gas required exceeds allowance"), false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := &FakeEthService{EstimateErr: tt.err}
+			c := newTestClient(t, map[string]interface{}{"eth": eth})
+			_, err := c.EstimateGas(ctx, CallMsg{To: &to})
+			var revertErr *RevertError
+			if got := errors.As(err, &revertErr); got != tt.wantRevert {
+				t.Fatalf("expected revert error %t but got: %v", tt.wantRevert, err)
+			}
+			if tt.wantRevert && revertErr.Reason != tt.wantReason {
+				t.Errorf("expected reason %q but got %q", tt.wantReason, revertErr.Reason)
+			}
+		})
+	}
+}
+
+func TestClient_FilterLogs(t *testing.T) {
+	eth := &FakeEthService{}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+	hash := common.HexToHash("0x01")
+	for _, tt := range []struct {
+		name string
+		q    gochain.FilterQuery
+		want string
+	}{
+		{"reversed-range", gochain.FilterQuery{FromBlock: big.NewInt(10), ToBlock: big.NewInt(9)}, "fromBlock 10 is after toBlock 9"},
+		{"hash-and-range", gochain.FilterQuery{BlockHash: &hash, FromBlock: big.NewInt(1)}, "cannot specify both"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.FilterLogs(ctx, tt.q)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q but got: %v", tt.want, err)
+			}
+		})
+	}
+	if len(eth.Filters) != 0 {
+		t.Errorf("expected no requests for invalid queries but got %d", len(eth.Filters))
+	}
+}
+
+func TestClient_GetLatestBlockNumber(t *testing.T) {
+	eth := &FakeEthService{Head: 1234}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	n, err := c.GetLatestBlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if n != 1234 {
+		t.Errorf("expected 1234 but got %d", n)
+	}
+}
+
+func TestClient_GetStorageAt(t *testing.T) {
+	contract := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	owner := common.HexToHash("0xfe3b557e8fb62b89f4916b721be55ceb828dbd73")
+	eth := &FakeEthService{Storage: map[common.Address]map[common.Hash]common.Hash{
+		contract: {StorageSlot(big.NewInt(1)): owner},
+	}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	got, err := c.GetStorageAt(ctx, contract.Hex(), StorageSlot(big.NewInt(1)), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if common.BytesToHash(got) != owner {
+		t.Errorf("expected slot 1 to be %s but got %x", owner.Hex(), got)
+	}
+	got, err = c.GetStorageAt(ctx, contract.Hex(), StorageSlot(big.NewInt(2)), big.NewInt(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 32 || common.BytesToHash(got) != (common.Hash{}) {
+		t.Errorf("expected empty slot 2 to be 32 zero bytes but got %x", got)
+	}
+	if _, err := c.GetStorageAt(ctx, "0x123", common.Hash{}, nil); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
+
+func TestStorageSlot(t *testing.T) {
+	want := common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000107")
+	if got := StorageSlot(big.NewInt(263)); got != want {
+		t.Errorf("expected %s but got %s", want.Hex(), got.Hex())
+	}
+	if got := StorageSlot(nil); got != (common.Hash{}) {
+		t.Errorf("expected slot 0 for a nil index but got %s", got.Hex())
+	}
+}
+
+func TestClient_GetBlockByHash(t *testing.T) {
+	parent, child := testBlock(1), testBlock(2)
+	child.ParentHash = parent.Hash
+	eth := &FakeEthService{Blocks: map[uint64]*Block{1: parent, 2: child}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	// Follow the parent hash, as when handling a reorg.
+	got, err := c.GetBlockByHash(ctx, child.ParentHash.Hex(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got.Hash != parent.Hash || got.Number.Int64() != 1 {
+		t.Errorf("expected block 1 %s but got %s %s", parent.Hash.Hex(), got.Number, got.Hash.Hex())
+	}
+
+	missing := common.HexToHash("0x01").Hex()
+	_, err = c.GetBlockByHash(ctx, missing, false)
+	if !errors.Is(err, NotFoundErr) {
+		t.Errorf("expected %v for missing block but got: %v", NotFoundErr, err)
+	} else if want := "block " + missing + " not found"; err.Error() != want {
+		t.Errorf("expected %q but got %q", want, err)
+	}
+	for _, bad := range []string{"0x01", "not a hash", parent.Hash.Hex()[2:]} {
+		if _, err := c.GetBlockByHash(ctx, bad, false); err == nil {
+			t.Errorf("expected error for invalid hash %q", bad)
+		}
+	}
+}
+
+func TestClient_GetHeader(t *testing.T) {
+	block := testBlock(7)
+	block.GasLimit = 8000000
+	block.BaseFee = Gwei(2)
+	block.TxsRoot = common.HexToHash("0x0a")
+	block.TxHashes = []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}
+	eth := &FakeEthService{Blocks: map[uint64]*Block{7: block}, Head: 7}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	check := func(h *Header, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.Hash != block.Hash || h.Number.Uint64() != 7 || h.GasLimit != 8000000 || h.BaseFee.Cmp(Gwei(2)) != 0 ||
+			!h.Timestamp.Equal(block.Timestamp) || h.TxsRoot != block.TxsRoot {
+			t.Errorf("expected header of block 7 but got %+v", h)
+		}
+	}
+	check(c.GetHeaderByNumber(ctx, nil))
+	check(c.GetHeaderByNumber(ctx, big.NewInt(7)))
+	check(c.GetHeaderByHash(ctx, block.Hash.Hex()))
+	if want := []bool{false, false, false}; !reflect.DeepEqual(eth.FullTxs, want) {
+		t.Errorf("expected requests without full transactions %v but got %v", want, eth.FullTxs)
+	}
+
+	if _, err := c.GetHeaderByNumber(ctx, big.NewInt(8)); !errors.Is(err, NotFoundErr) {
+		t.Errorf("expected %v for missing block but got: %v", NotFoundErr, err)
+	}
+	if _, err := c.GetHeaderByHash(ctx, common.HexToHash("0x01").Hex()); !errors.Is(err, NotFoundErr) {
+		t.Errorf("expected %v for missing block but got: %v", NotFoundErr, err)
+	}
+}
+
+func TestClient_GetTransactionInBlock(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := testBlock(1)
+	for i := uint64(0); i < 3; i++ {
+		tx, err := types.SignTx(types.NewTransaction(i, common.Address{}, big.NewInt(1), 21000, Gwei(1), nil), types.HomesteadSigner{}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.TxDetails = append(block.TxDetails, convertTx(tx, crypto.PubkeyToAddress(key.PublicKey)))
+	}
+	block.TxHashes = nil
+	eth := &FakeEthService{Blocks: map[uint64]*Block{1: block}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+	hash := block.Hash.Hex()
+
+	count, err := c.GetBlockTransactionCount(ctx, hash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if count != 3 {
+		t.Errorf("expected 3 transactions but got %d", count)
+	}
+	tx, err := c.GetTransactionInBlock(ctx, hash, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if tx.Hash != block.TxDetails[2].Hash || tx.Nonce != 2 {
+		t.Errorf("expected tx %s but got %s", block.TxDetails[2].Hash.Hex(), tx.Hash.Hex())
+	}
+
+	if _, err := c.GetTransactionInBlock(ctx, hash, 3); !errors.Is(err, NotFoundErr) {
+		t.Errorf("expected %v for index out of range but got: %v", NotFoundErr, err)
+	}
+	missing := common.HexToHash("0x01").Hex()
+	if _, err := c.GetBlockTransactionCount(ctx, missing); !errors.Is(err, NotFoundErr) {
+		t.Errorf("expected %v for missing block but got: %v", NotFoundErr, err)
+	}
+	for _, bad := range []string{"0x01", "not a hash", block.Hash.Hex()[2:]} {
+		if _, err := c.GetBlockTransactionCount(ctx, bad); err == nil {
+			t.Errorf("expected error for invalid hash %q", bad)
+		}
+		if _, err := c.GetTransactionInBlock(ctx, bad, 0); err == nil {
+			t.Errorf("expected error for invalid hash %q", bad)
+		}
+	}
+}
+
+func TestClient_ChainID(t *testing.T) {
+	eth := &FakeEthService{ChainID: big.NewInt(5)}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		id, err := c.ChainID(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		} else if id.Int64() != 5 {
+			t.Errorf("expected chain id 5 but got %s", id)
+		}
+		id.SetInt64(100) // must not change the cache
+	}
+	if eth.ChainIDCalls != 1 {
+		t.Errorf("expected chain id to be requested once but got %d requests", eth.ChainIDCalls)
+	}
+
+	c.ResetChainID()
+	eth.mu.Lock()
+	eth.ChainID = big.NewInt(6)
+	eth.mu.Unlock()
+	if id, err := c.ChainID(ctx); err != nil || id.Int64() != 6 {
+		t.Errorf("expected chain id 6 after reset but got %s: %v", id, err)
+	}
+	if eth.ChainIDCalls != 2 {
+		t.Errorf("expected chain id to be requested again after reset but got %d requests", eth.ChainIDCalls)
+	}
+
+	// Filled by GetID, which fails partially without the net namespace.
+	eth = &FakeEthService{ChainID: big.NewInt(7)}
+	c = newTestClient(t, map[string]interface{}{"eth": eth})
+	c.GetID(ctx)
+	if id, err := c.ChainID(ctx); err != nil || id.Int64() != 7 {
+		t.Errorf("expected chain id 7 from GetID but got %s: %v", id, err)
+	}
+	if eth.ChainIDCalls != 1 {
+		t.Errorf("expected chain id from GetID to be cached but got %d requests", eth.ChainIDCalls)
+	}
+
+	// Failures are not cached.
+	eth = &FakeEthService{}
+	c = newTestClient(t, map[string]interface{}{"eth": eth})
+	for i := 0; i < 2; i++ {
+		if _, err := c.ChainID(ctx); err == nil {
+			t.Error("expected error")
+		}
+	}
+	if eth.ChainIDCalls != 2 {
+		t.Errorf("expected failed requests to be repeated but got %d requests", eth.ChainIDCalls)
+	}
+}
+
+func TestClient_GetTransactionSender(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	newTx := func(signer types.Signer) *types.Transaction {
+		tx, err := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, Gwei(1), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	eth := &FakeEthService{ChainID: big.NewInt(5)}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	for name, tx := range map[string]*types.Transaction{
+		"legacy":       newTx(types.HomesteadSigner{}),
+		"eip155":       newTx(types.NewEIP155Signer(big.NewInt(5))),
+		"eip155-again": newTx(types.NewEIP155Signer(big.NewInt(5))),
+	} {
+		got, err := c.GetTransactionSender(ctx, tx)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		} else if got != from {
+			t.Errorf("%s: expected sender %s but got %s", name, from.Hex(), got.Hex())
+		}
+	}
+	if eth.ChainIDCalls != 1 {
+		t.Errorf("expected chain id to be requested once but got %d requests", eth.ChainIDCalls)
+	}
+	if _, err := c.GetTransactionSender(ctx, newTx(types.NewEIP155Signer(big.NewInt(1)))); err == nil {
+		t.Error("expected error for transaction signed for another chain")
+	}
+
+	eth = &FakeEthService{}
+	c = newTestClient(t, map[string]interface{}{"eth": eth})
+	if _, err := c.GetTransactionSender(ctx, newTx(types.NewEIP155Signer(big.NewInt(5)))); err == nil {
+		t.Error("expected error without chain id")
+	}
+	if got, err := c.GetTransactionSender(ctx, newTx(types.HomesteadSigner{})); err != nil || got != from {
+		t.Errorf("expected legacy sender without chain id, got %s: %v", got.Hex(), err)
+	}
+}
+
+func TestClient_GetTransactionReceipt(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	receipt := &Receipt{TxHash: hash, Status: 1, BlockNumber: 1, Logs: []*types.Log{}}
+	eth := &FakeEthService{Receipts: map[common.Hash]*Receipt{hash: receipt}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	got, err := c.GetTransactionReceipt(ctx, hash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.TxHash != hash || got.Status != 1 {
+		t.Errorf("unexpected receipt: %+v", got)
+	}
+	_, err = c.GetTransactionReceipt(ctx, common.HexToHash("0x02"))
+	var notFound *NotFoundError
+	if !errors.Is(err, NotFoundErr) || !errors.As(err, &notFound) || notFound.Kind != "receipt" {
+		t.Errorf("expected receipt %v but got: %v", NotFoundErr, err)
+	}
+	if eth.receiptCalls != 2 {
+		t.Errorf("expected 1 request per call but got %d", eth.receiptCalls)
+	}
+}
+
+func ExampleClient_GetBlockByNumber() {
 	for _, network := range []string{mainnetURL, testnetURL} {
 		exampleRPCClient_GetBlockByNumber(network)
 	}
@@ -0,0 +1,85 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/gochain/gochain/v3/common"
+)
+
+// RecentSigner is an authorized signer and the number of the last block it signed.
+type RecentSigner struct {
+	Signer common.Address
+	Block  uint64
+}
+
+// SignerList returns the authorized signers, sorted by address.
+func (s *Snapshot) SignerList() []common.Address {
+	signers := make([]common.Address, 0, len(s.Signers))
+	for addr := range s.Signers {
+		signers = append(signers, addr)
+	}
+	sortAddresses(signers)
+	return signers
+}
+
+// VoterList returns the voters, sorted by address.
+func (s *Snapshot) VoterList() []common.Address {
+	voters := make([]common.Address, 0, len(s.Voters))
+	for addr := range s.Voters {
+		voters = append(voters, addr)
+	}
+	sortAddresses(voters)
+	return voters
+}
+
+// RecentSigners returns the authorized signers with the last block each signed, most recent first. Signers of the
+// same block are sorted by address.
+func (s *Snapshot) RecentSigners() []RecentSigner {
+	recents := make([]RecentSigner, 0, len(s.Signers))
+	for addr, block := range s.Signers {
+		recents = append(recents, RecentSigner{Signer: addr, Block: block})
+	}
+	sort.Slice(recents, func(i, j int) bool {
+		if recents[i].Block != recents[j].Block {
+			return recents[i].Block > recents[j].Block
+		}
+		return bytes.Compare(recents[i].Signer[:], recents[j].Signer[:]) < 0
+	})
+	return recents
+}
+
+func sortAddresses(addrs []common.Address) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+}
+
+// GetSigners returns the authorized signers from the latest clique snapshot, sorted by address.
+func GetSigners(ctx context.Context, client Client) ([]common.Address, error) {
+	s, err := client.GetSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignerList(), nil
+}
+
+// GetVoters returns the voters from the latest clique snapshot, sorted by address.
+func GetVoters(ctx context.Context, client Client) ([]common.Address, error) {
+	s, err := client.GetSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.VoterList(), nil
+}
+
+// GetRecents returns the authorized signers from the latest clique snapshot with the last block each signed, most
+// recent first.
+func GetRecents(ctx context.Context, client Client) ([]RecentSigner, error) {
+	s, err := client.GetSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.RecentSigners(), nil
+}
@@ -0,0 +1,112 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+)
+
+type FakeCliqueService struct {
+	// Snapshot is returned for the latest block.
+	Snapshot *Snapshot
+	// Snapshots are returned by block number. Other blocks are unknown.
+	Snapshots map[uint64]*Snapshot
+}
+
+func (s *FakeCliqueService) GetSnapshot(block string) (*Snapshot, error) {
+	if block == "latest" {
+		return s.Snapshot, nil
+	}
+	n, err := hexutil.DecodeUint64(block)
+	if err != nil {
+		return nil, err
+	}
+	if sn, ok := s.Snapshots[n]; ok {
+		return sn, nil
+	}
+	return nil, errors.New("unknown block")
+}
+
+func TestClient_GetSnapshotAt(t *testing.T) {
+	signer := common.HexToAddress("0x0a")
+	latest := &Snapshot{Number: 200, Signers: map[common.Address]uint64{signer: 200}}
+	past := &Snapshot{Number: 100, Signers: map[common.Address]uint64{signer: 99}}
+	client := newTestClient(t, map[string]interface{}{"clique": &FakeCliqueService{
+		Snapshot:  latest,
+		Snapshots: map[uint64]*Snapshot{100: past},
+	}})
+	ctx := context.Background()
+
+	got, err := client.GetSnapshotAt(ctx, big.NewInt(100))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Number != 100 || got.Signers[signer] != 99 {
+		t.Errorf("expected snapshot at 100 but got %+v", got)
+	}
+	got, err = client.GetSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Number != 200 {
+		t.Errorf("expected latest snapshot at 200 but got %d", got.Number)
+	}
+
+	_, err = client.GetSnapshotAt(ctx, big.NewInt(5))
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || !errors.Is(err, NotFoundErr) {
+		t.Errorf("expected *NotFoundError for a block before clique but got: %v", err)
+	} else if want := "snapshot at block 5 not found"; err.Error() != want {
+		t.Errorf("expected %q but got %q", want, err)
+	}
+}
+
+func TestGetSigners(t *testing.T) {
+	a := common.HexToAddress("0x0a")
+	b := common.HexToAddress("0x0b")
+	c := common.HexToAddress("0x0c")
+	d := common.HexToAddress("0x0d")
+	snapshot := &Snapshot{
+		Number:  100,
+		Signers: map[common.Address]uint64{d: 97, b: 99, a: 98, c: 99},
+		Voters:  map[common.Address]struct{}{c: {}, a: {}, d: {}},
+	}
+	client := newTestClient(t, map[string]interface{}{"clique": &FakeCliqueService{Snapshot: snapshot}})
+	ctx := context.Background()
+
+	// Repeated to catch any dependence on map order.
+	for i := 0; i < 10; i++ {
+		signers, err := GetSigners(ctx, client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := []common.Address{a, b, c, d}; !reflect.DeepEqual(signers, want) {
+			t.Fatalf("expected signers %v but got %v", want, signers)
+		}
+		voters, err := GetVoters(ctx, client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := []common.Address{a, c, d}; !reflect.DeepEqual(voters, want) {
+			t.Fatalf("expected voters %v but got %v", want, voters)
+		}
+		recents, err := GetRecents(ctx, client)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []RecentSigner{{b, 99}, {c, 99}, {a, 98}, {d, 97}}
+		if !reflect.DeepEqual(recents, want) {
+			t.Fatalf("expected recents %v but got %v", want, recents)
+		}
+	}
+
+	empty := &Snapshot{}
+	if got := empty.SignerList(); got == nil || len(got) != 0 {
+		t.Errorf("expected empty signer list but got %v", got)
+	}
+}
@@ -108,7 +108,8 @@ func callContract(ctx context.Context, rpcURL, privateKey, contractAddress, cont
 		return
 	}
 	fmt.Println("Waiting for receipt...")
-	ctx, _ = context.WithTimeout(ctx, 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		fatalExit(fmt.Errorf("getting receipt: %v", err))
@@ -100,7 +100,8 @@ func CreateDID(ctx context.Context, rpcURL, privateKey, id, registryAddress stri
 		log.Fatalf("Cannot register DID identifier: %v", err)
 	}
 
-	ctx, _ = context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the receipt: %v", err)
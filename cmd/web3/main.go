@@ -20,8 +20,8 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/gochain/gochain/crypto"
 	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
 
 	"github.com/gochain/gochain/v3/accounts/abi"
 	"github.com/gochain/gochain/v3/accounts/keystore"
@@ -368,7 +368,7 @@ func main() {
 						},
 						cli.Uint64Flag{
 							Name:  "gas-limit",
-							Value: 2000000,
+							Usage: "Gas limit for the deployment, 0 to estimate",
 						},
 					},
 				},
@@ -1306,24 +1306,13 @@ func GetSnapshot(ctx context.Context, rpcURL string) {
 	fmt.Println("Latest Number:", s.Number)
 	fmt.Println("Latest Hash:", s.Hash.String())
 	fmt.Println("Signers:")
-	type signer struct {
-		addr common.Address
-		num  uint64
-	}
-	signers := make([]signer, 0, len(s.Signers))
-	for addr, num := range s.Signers {
-		signers = append(signers, signer{addr, num})
-	}
-	sort.Slice(signers, func(i, j int) bool {
-		return signers[j].num < signers[i].num
-	})
-	for _, si := range signers {
+	for _, si := range s.RecentSigners() {
 		//TODO mark signers which have fallen behind
-		fmt.Println("", si.addr.String(), "signed block", si.num, "-", s.Number-si.num, "blocks ago")
+		fmt.Println("", si.Signer.String(), "signed block", si.Block, "-", s.Number-si.Block, "blocks ago")
 	}
 
 	fmt.Println("Voters:")
-	for addr := range s.Voters {
+	for _, addr := range s.VoterList() {
 		fmt.Println("", addr.String())
 	}
 
@@ -1354,8 +1343,10 @@ func GetID(ctx context.Context, rpcURL string) {
 	}
 	defer client.Close()
 	id, err := client.GetID(ctx)
-	if err != nil {
+	if id == nil {
 		fatalExit(fmt.Errorf("Cannot get id info from the network: %v", err))
+	} else if err != nil {
+		log.Printf("Some id info is missing: %v\n", err)
 	}
 	if verbose {
 		log.Println("Snapshot details:")
@@ -1495,7 +1486,8 @@ func DeploySol(ctx context.Context, network web3.Network,
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot deploy the contract: %v", err))
 	}
-	waitCtx, _ := context.WithTimeout(ctx, 60*time.Second)
+	waitCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(waitCtx, client, tx.Hash)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot get the receipt: %v", err))
@@ -1526,7 +1518,8 @@ func DeploySol(ctx context.Context, network web3.Network,
 	if err != nil {
 		log.Fatalf("Cannot deploy the upgradeable proxy contract: %v", err)
 	}
-	waitCtx, _ = context.WithTimeout(ctx, 60*time.Second)
+	waitCtx, cancel = context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
 	proxyReceipt, err := web3.WaitForReceipt(waitCtx, client, proxyTx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the upgradeable proxy receipt: %v", err)
@@ -1632,7 +1625,8 @@ func UpgradeContract(ctx context.Context, rpcURL, privateKey, contractAddress, n
 	if err != nil {
 		log.Fatalf("Cannot upgrade the contract: %v", err)
 	}
-	ctx, _ = context.WithTimeout(ctx, 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the receipt: %v", err)
@@ -1679,7 +1673,8 @@ func PauseContract(ctx context.Context, rpcURL, privateKey, contractAddress stri
 	if err != nil {
 		log.Fatalf("Cannot pause the contract: %v", err)
 	}
-	ctx, _ = context.WithTimeout(ctx, 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the receipt: %v", err)
@@ -1701,7 +1696,8 @@ func ResumeContract(ctx context.Context, rpcURL, privateKey, contractAddress str
 	if err != nil {
 		log.Fatalf("Cannot resume the contract: %v", err)
 	}
-	ctx, _ = context.WithTimeout(ctx, 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the receipt: %v", err)
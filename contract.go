@@ -0,0 +1,40 @@
+package web3
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/gochain/gochain/v3/accounts/abi"
+)
+
+// BoundContract is a deployed contract with a known ABI.
+type BoundContract struct {
+	client  Client
+	address string
+	abi     abi.ABI
+}
+
+// NewBoundContract returns a BoundContract for the contract at address, using client for all calls.
+func NewBoundContract(client Client, address string, myabi abi.ABI) *BoundContract {
+	return &BoundContract{client: client, address: address, abi: myabi}
+}
+
+// Address returns the address of the contract.
+func (c *BoundContract) Address() string {
+	return c.address
+}
+
+// ABI returns the ABI of the contract.
+func (c *BoundContract) ABI() abi.ABI {
+	return c.abi
+}
+
+// Call executes a read-only method call without submitting a transaction, and returns the unpacked outputs.
+func (c *BoundContract) Call(ctx context.Context, method string, params ...interface{}) ([]interface{}, error) {
+	return CallConstantFunction(ctx, c.client, c.abi, c.address, method, params...)
+}
+
+// Send submits a transaction, signed by privateKeyHex, to execute a state-changing method call.
+func (c *BoundContract) Send(ctx context.Context, privateKeyHex, method string, amount *big.Int, gasLimit uint64, params ...interface{}) (*Transaction, error) {
+	return CallTransactFunction(ctx, c.client, c.abi, c.address, privateKeyHex, method, amount, gasLimit, params...)
+}
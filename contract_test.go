@@ -0,0 +1,58 @@
+package web3_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+func TestBoundContract(t *testing.T) {
+	const token = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	const holder = "0x2fe70f1df222c85ad6dd24a3376eb5ac32136978"
+	fake := web3test.NewFakeClient()
+	fake.SetChainID(big.NewInt(60))
+	fake.SetCallFunc(func(msg web3.CallMsg) ([]byte, error) {
+		input, err := erc20ABI.Pack("balanceOf", common.HexToAddress(holder))
+		if err != nil {
+			return nil, err
+		}
+		if msg.To == nil || *msg.To != common.HexToAddress(token) || !bytes.Equal(msg.Data, input) {
+			t.Errorf("unexpected call: %+v", msg)
+		}
+		return erc20ABI.Methods["balanceOf"].Outputs.Pack(big.NewInt(42))
+	})
+	c := web3.NewBoundContract(fake, token, erc20ABI)
+	ctx := context.Background()
+
+	res, err := c.Call(ctx, "balanceOf", holder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 1 || res[0].(*big.Int).Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("expected balance 42 but got %v", res)
+	}
+	if _, err := c.Call(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected method not found error but got: %v", err)
+	}
+
+	tx, err := c.Send(ctx, devKey, "transfer", big.NewInt(0), 100000, holder, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := fake.Sent()
+	if len(sent) != 1 || sent[0].Hash() != tx.Hash {
+		t.Fatalf("expected transaction %s to be sent", tx.Hash.Hex())
+	}
+	if want, _ := erc20ABI.Pack("transfer", common.HexToAddress(holder), big.NewInt(1)); !bytes.Equal(sent[0].Data(), want) {
+		t.Errorf("unexpected transaction data %x", sent[0].Data())
+	}
+	if _, err := c.Send(ctx, devKey, "missing", big.NewInt(0), 100000); err == nil {
+		t.Error("expected method not found error")
+	}
+}
@@ -0,0 +1,214 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/gochain/gochain/v3/accounts/abi"
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/web3/assets"
+)
+
+var (
+	// ErrNotContract is returned when there is no contract code at an address.
+	ErrNotContract = errors.New("no contract code at address")
+	// ErrNotERC20 is returned when a contract returns no data for an ERC20 method.
+	ErrNotERC20 = errors.New("contract does not implement ERC20")
+)
+
+var erc20ABI = func() abi.ABI {
+	myabi, err := abi.JSON(strings.NewReader(assets.ERC20ABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid ERC20 ABI: %v", err))
+	}
+	return myabi
+}()
+
+// TokenInfo is the metadata of an ERC20 token.
+type TokenInfo struct {
+	Name        string   `json:"name"`
+	Symbol      string   `json:"symbol"`
+	Decimals    uint8    `json:"decimals"`
+	TotalSupply *big.Int `json:"total_supply"`
+}
+
+// GetTokenInfo returns the metadata of the ERC20 token at tokenAddr, looked up concurrently.
+func GetTokenInfo(ctx context.Context, client Client, tokenAddr string) (*TokenInfo, error) {
+	var info TokenInfo
+	errs := make([]error, 4)
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		info.Name, errs[0] = tokenString(ctx, client, tokenAddr, "name")
+	}()
+	go func() {
+		defer wg.Done()
+		info.Symbol, errs[1] = tokenString(ctx, client, tokenAddr, "symbol")
+	}()
+	go func() {
+		defer wg.Done()
+		info.Decimals, errs[2] = TokenDecimals(ctx, client, tokenAddr)
+	}()
+	go func() {
+		defer wg.Done()
+		info.TotalSupply, errs[3] = TokenTotalSupply(ctx, client, tokenAddr)
+	}()
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &info, nil
+}
+
+// TokenTotalSupply returns the total supply in base units of the ERC20 token at tokenAddr.
+func TokenTotalSupply(ctx context.Context, client Client, tokenAddr string) (*big.Int, error) {
+	out, err := tokenCall(ctx, client, tokenAddr, "totalSupply")
+	if err != nil {
+		return nil, err
+	}
+	return out.(*big.Int), nil
+}
+
+// TokenBalance returns the balance of holder in base units of the ERC20 token at tokenAddr.
+func TokenBalance(ctx context.Context, client Client, tokenAddr, holder string) (*big.Int, error) {
+	out, err := tokenCall(ctx, client, tokenAddr, "balanceOf", holder)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*big.Int), nil
+}
+
+// TokenAllowance returns the amount in base units of the ERC20 token at tokenAddr that spender may
+// transfer on behalf of owner.
+func TokenAllowance(ctx context.Context, client Client, tokenAddr, owner, spender string) (*big.Int, error) {
+	out, err := tokenCall(ctx, client, tokenAddr, "allowance", owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*big.Int), nil
+}
+
+// TokenDecimals returns the number of decimals of the ERC20 token at tokenAddr.
+func TokenDecimals(ctx context.Context, client Client, tokenAddr string) (uint8, error) {
+	out, err := tokenCall(ctx, client, tokenAddr, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	return out.(uint8), nil
+}
+
+// ParseTokenAmount converts a decimal amount, like "1.5", to base units of the ERC20 token at tokenAddr
+// using the token's decimals. Amounts with more decimals than the token has are rejected, like ParseDecimals.
+func ParseTokenAmount(ctx context.Context, client Client, tokenAddr, amount string) (*big.Int, error) {
+	decimals, err := TokenDecimals(ctx, client, tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDecimals(amount, decimals)
+}
+
+// TokenTransfer sends amount base units of the ERC20 token at tokenAddr from the account of privateKeyHex to to.
+func TokenTransfer(ctx context.Context, client Client, privateKeyHex, tokenAddr, to string, amount *big.Int, opts TransactOptions) (*Transaction, error) {
+	return tokenTransact(ctx, client, privateKeyHex, tokenAddr, "transfer", opts, to, amount)
+}
+
+// TokenApprove allows spender to transfer up to amount base units of the ERC20 token at tokenAddr on behalf
+// of the account of privateKeyHex.
+func TokenApprove(ctx context.Context, client Client, privateKeyHex, tokenAddr, spender string, amount *big.Int, opts TransactOptions) (*Transaction, error) {
+	return tokenTransact(ctx, client, privateKeyHex, tokenAddr, "approve", opts, spender, amount)
+}
+
+// tokenCall calls an ERC20 method with a single output.
+func tokenCall(ctx context.Context, client Client, tokenAddr, method string, params ...interface{}) (interface{}, error) {
+	return contractCall(ctx, client, erc20ABI, ErrNotERC20, tokenAddr, method, params...)
+}
+
+// tokenString calls an ERC20 method returning a string, which some older tokens return as bytes32 instead.
+func tokenString(ctx context.Context, client Client, tokenAddr, method string) (string, error) {
+	res, err := contractCallData(ctx, client, erc20ABI, ErrNotERC20, tokenAddr, method)
+	if err != nil {
+		return "", err
+	}
+	// An ABI encoded string is at least 64 bytes, for the offset and length.
+	if len(res) == 32 {
+		return string(bytes.TrimRight(res, "\x00")), nil
+	}
+	vals, err := erc20ABI.Methods[method].Outputs.UnpackValues(res)
+	if err != nil {
+		return "", fmt.Errorf("failed to unpack values from %s: %w", hexutil.Encode(res), err)
+	}
+	return vals[0].(string), nil
+}
+
+// contractCall calls a method of a standard interface with a single output. See contractCallData.
+func contractCall(ctx context.Context, client Client, myabi abi.ABI, errUnsupported error, address, method string, params ...interface{}) (interface{}, error) {
+	res, err := contractCallData(ctx, client, myabi, errUnsupported, address, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := myabi.Methods[method].Outputs.UnpackValues(res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack values from %s: %w", hexutil.Encode(res), err)
+	}
+	return vals[0], nil
+}
+
+// contractCallData calls a method of a standard interface and returns the raw result. Calls which return no
+// data fail with ErrNotContract if there is no code at address, and errUnsupported otherwise.
+func contractCallData(ctx context.Context, client Client, myabi abi.ABI, errUnsupported error, address, method string, params ...interface{}) ([]byte, error) {
+	if err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	goParams, err := ConvertArguments(myabi.Methods[method].Inputs, params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments for method %q: %w", method, err)
+	}
+	input, err := myabi.Pack(method, goParams...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack values: %w", err)
+	}
+	to := common.HexToAddress(address)
+	res, err := client.Call(ctx, CallMsg{Data: input, To: &to})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		if err := checkContract(ctx, client, address); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %s returned no data: %w", address, method, errUnsupported)
+	}
+	return res, nil
+}
+
+func tokenTransact(ctx context.Context, client Client, privateKeyHex, tokenAddr, method string, opts TransactOptions, params ...interface{}) (*Transaction, error) {
+	if err := ValidateAddress(tokenAddr); err != nil {
+		return nil, err
+	}
+	// Calls to addresses without code succeed without doing anything, so check first.
+	if err := checkContract(ctx, client, tokenAddr); err != nil {
+		return nil, err
+	}
+	return SendContractTransaction(ctx, client, privateKeyHex, assets.ERC20ABI, tokenAddr, method, nil, opts, params...)
+}
+
+// checkContract returns ErrNotContract if there is no code at address.
+func checkContract(ctx context.Context, client Client, address string) error {
+	code, err := client.GetCode(ctx, address, nil)
+	if err != nil {
+		return fmt.Errorf("cannot get code: %w", err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("%s: %w", address, ErrNotContract)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package web3_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v3/accounts/abi"
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/assets"
+	"github.com/gochain/web3/web3test"
+)
+
+var erc20ABI = mustParseABI(assets.ERC20ABI)
+
+func mustParseABI(s string) abi.ABI {
+	myabi, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return myabi
+}
+
+// fakeToken returns a call handler implementing the read-only ERC20 methods for the given balances.
+// The symbol is returned as bytes32.
+func fakeToken(decimals uint8, balances map[common.Address]*big.Int) func(web3.CallMsg) ([]byte, error) {
+	return func(msg web3.CallMsg) ([]byte, error) {
+		fn, err := erc20ABI.MethodById(msg.Data)
+		if err != nil {
+			return nil, err
+		}
+		args, err := fn.Inputs.UnpackValues(msg.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		switch fn.Name {
+		case "name":
+			return fn.Outputs.Pack("Test Token")
+		case "symbol":
+			// Return the symbol as bytes32, like some older tokens.
+			var sym [32]byte
+			copy(sym[:], "TKN")
+			return sym[:], nil
+		case "totalSupply":
+			return fn.Outputs.Pack(big.NewInt(1000000000))
+		case "decimals":
+			return fn.Outputs.Pack(decimals)
+		case "balanceOf":
+			bal := balances[args[0].(common.Address)]
+			if bal == nil {
+				bal = big.NewInt(0)
+			}
+			return fn.Outputs.Pack(bal)
+		case "allowance":
+			return fn.Outputs.Pack(big.NewInt(5))
+		}
+		return nil, fmt.Errorf("unsupported method %s", fn.Name)
+	}
+}
+
+// noData handles calls like a node does for accounts without code, or contracts without a matching method.
+func noData(web3.CallMsg) ([]byte, error) { return nil, nil }
+
+func TestTokenCalls(t *testing.T) {
+	const token, holder = "0x0000000000000000000000000000000000000001", "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetCode(common.HexToAddress(token), []byte{0x60, 0x80})
+	c.SetCallFunc(fakeToken(6, map[common.Address]*big.Int{common.HexToAddress(holder): big.NewInt(1500000)}))
+
+	bal, err := web3.TokenBalance(ctx, c, token, holder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if bal.Int64() != 1500000 {
+		t.Errorf("expected balance 1500000 but got %s", bal)
+	}
+	allowance, err := web3.TokenAllowance(ctx, c, token, holder, token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if allowance.Int64() != 5 {
+		t.Errorf("expected allowance 5 but got %s", allowance)
+	}
+	amount, err := web3.ParseTokenAmount(ctx, c, token, "1.5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if amount.Int64() != 1500000 {
+		t.Errorf("expected 1.5 with 6 decimals to be 1500000 but got %s", amount)
+	}
+	if _, err := web3.TokenBalance(ctx, c, token, "0x01"); err == nil {
+		t.Error("expected error for invalid holder address")
+	}
+}
+
+func TestGetTokenInfo(t *testing.T) {
+	const token = "0x0000000000000000000000000000000000000001"
+	c := web3test.NewFakeClient()
+	c.SetCode(common.HexToAddress(token), []byte{0x60, 0x80})
+	c.SetCallFunc(fakeToken(6, nil))
+	info, err := web3.GetTokenInfo(context.Background(), c, token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"name":"Test Token","symbol":"TKN","decimals":6,"total_supply":1000000000}`
+	if string(b) != want {
+		t.Errorf("expected %s but got %s", want, b)
+	}
+
+	c = web3test.NewFakeClient()
+	c.SetCallFunc(noData)
+	if _, err := web3.GetTokenInfo(context.Background(), c, token); !errors.Is(err, web3.ErrNotContract) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotContract, err)
+	}
+}
+
+func TestTokenCalls_notERC20(t *testing.T) {
+	const token, other = "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetCode(common.HexToAddress(token), []byte{0x60, 0x80})
+	c.SetCallFunc(noData)
+
+	if _, err := web3.TokenDecimals(ctx, c, token); !errors.Is(err, web3.ErrNotERC20) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotERC20, err)
+	}
+	if _, err := web3.TokenBalance(ctx, c, other, other); !errors.Is(err, web3.ErrNotContract) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotContract, err)
+	}
+	if _, err := web3.TokenTransfer(ctx, c, devKey, other, token, big.NewInt(1), web3.TransactOptions{}); !errors.Is(err, web3.ErrNotContract) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotContract, err)
+	}
+	if sent := c.Sent(); len(sent) != 0 {
+		t.Errorf("expected no transactions to be sent, got %d", len(sent))
+	}
+}
+
+func TestTokenTransact(t *testing.T) {
+	const token, to = "0x0000000000000000000000000000000000000001", "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetCode(common.HexToAddress(token), []byte{0x60, 0x80})
+	c.SetChainID(big.NewInt(60))
+	opts := web3.TransactOptions{GasLimit: 60000}
+
+	if _, err := web3.TokenTransfer(ctx, c, devKey, token, to, big.NewInt(100), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := web3.TokenApprove(ctx, c, devKey, token, to, big.NewInt(200), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := c.Sent()
+	for i, want := range []struct {
+		method string
+		amount int64
+	}{{"transfer", 100}, {"approve", 200}} {
+		tx := sent[i]
+		if *tx.To() != common.HexToAddress(token) || tx.Value().Sign() != 0 {
+			t.Errorf("expected zero value tx to token but got to %s with value %s", tx.To().Hex(), tx.Value())
+		}
+		fn, err := erc20ABI.MethodById(tx.Data())
+		if err != nil {
+			t.Fatal(err)
+		}
+		args, err := fn.Inputs.UnpackValues(tx.Data()[4:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fn.Name != want.method || args[0] != common.HexToAddress(to) || args[1].(*big.Int).Int64() != want.amount {
+			t.Errorf("expected %s(%s, %d) but got %s%v", want.method, to, want.amount, fn.Name, args)
+		}
+	}
+	if _, err := web3.TokenTransfer(ctx, c, devKey, token, strings.Repeat("z", 40), big.NewInt(1), opts); err == nil {
+		t.Error("expected error for invalid recipient address")
+	}
+}
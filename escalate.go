@@ -0,0 +1,192 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+)
+
+// ErrGasPriceCap is returned, wrapped, by SendAndConfirm when the transaction still isn't mined, and it can't be
+// replaced again without exceeding EscalationPolicy.MaxGasPrice.
+var ErrGasPriceCap = errors.New("gas price cap reached")
+
+// ErrNonceConsumed is returned, wrapped, by SendAndConfirm when the node reports the nonce as used, but none of the
+// broadcasts is mined, so another transaction must have taken the nonce.
+var ErrNonceConsumed = errors.New("nonce consumed by another transaction")
+
+// EscalationPolicy configures how SendAndConfirm raises the gas price of a transaction which isn't mined.
+type EscalationPolicy struct {
+	// MaxGasPrice is the most to pay per gas. It is required.
+	MaxGasPrice *big.Int
+	// BumpPercent raises the gas price by a percentage each time, 0 for MinReplacementBump.
+	BumpPercent int
+	// BumpStep, if set, raises the gas price by a fixed amount of wei each time instead of BumpPercent, but still by
+	// at least MinReplacementBump, which nodes require of replacements.
+	BumpStep *big.Int
+	// Interval is how long to wait for each broadcast to be mined before replacing it, 0 for 1 minute.
+	Interval time.Duration
+	// PollInterval is how often receipts are checked, 0 for 2 seconds, or Interval if shorter.
+	PollInterval time.Duration
+	// ChainID is used to sign replacements, nil to look it up.
+	ChainID *big.Int
+}
+
+// ConfirmResult describes the broadcasts made by SendAndConfirm, and which was mined.
+type ConfirmResult struct {
+	// Hashes and GasPrices are those of every transaction broadcast, in order. A broadcast which failed
+	// ambiguously, ie: with a network error, is included since it may have reached the node.
+	Hashes    []common.Hash
+	GasPrices []*big.Int
+	// Receipt is the receipt of the mined transaction, and Attempt its index in Hashes, or -1 if none was mined.
+	Receipt *Receipt
+	Attempt int
+	// GasPrice is the gas price of the mined transaction, and Fee the total it paid, for the gas it used.
+	GasPrice *big.Int
+	Fee      *big.Int
+}
+
+// SendAndConfirm signs tx with s, broadcasts it, and waits until it is mined. Each time it isn't mined within
+// policy.Interval, it is replaced at the same nonce with a higher gas price, until policy.MaxGasPrice is reached.
+// Receipts are checked for every broadcast, since any of them may be mined. tx is unsigned, ie: from
+// BuildTransaction, and its gas price is the first one tried.
+//
+// The result is returned along with any error once something was broadcast, so its Hashes can still be watched.
+// The error wraps ErrGasPriceCap if the cap was reached, or ErrNonceConsumed if no broadcast had a receipt within
+// policy.Interval of the node reporting the nonce as used. It is ctx's error if it was cancelled, or a
+// *TxRevertedError if the mined transaction reverted.
+func SendAndConfirm(ctx context.Context, client Client, s Signer, tx *types.Transaction, policy EscalationPolicy) (*ConfirmResult, error) {
+	if policy.MaxGasPrice == nil {
+		return nil, errors.New("max gas price required")
+	} else if tx.GasPrice().Cmp(policy.MaxGasPrice) > 0 {
+		return nil, fmt.Errorf("gas price %s exceeds max gas price %s", tx.GasPrice(), policy.MaxGasPrice)
+	}
+	if policy.BumpStep == nil && policy.BumpPercent != 0 && policy.BumpPercent < MinReplacementBump {
+		return nil, fmt.Errorf("%w: gas price bump %d%% is below the minimum %d%%", ErrReplacementUnderpriced, policy.BumpPercent, MinReplacementBump)
+	}
+	interval := policy.Interval
+	if interval == 0 {
+		interval = time.Minute
+	}
+	poll := policy.PollInterval
+	if poll == 0 {
+		poll = 2 * time.Second
+	}
+	if poll > interval {
+		poll = interval
+	}
+	chainID, err := signingChainID(ctx, client, policy.ChainID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ConfirmResult{Attempt: -1}
+	gasPrice := tx.GasPrice()
+	for {
+		signed, err := s.SignTx(withGasPrice(tx, gasPrice), chainID)
+		if err != nil {
+			return res, fmt.Errorf("cannot sign transaction: %w", err)
+		}
+		broadcast, nonceUsed := false, false
+		err = SendTransaction(ctx, client, signed)
+		switch {
+		case err == nil, isTransient(err), rpcErrorContains(err, "known transaction"), rpcErrorContains(err, "already known"):
+			res.Hashes = append(res.Hashes, signed.Hash())
+			res.GasPrices = append(res.GasPrices, gasPrice)
+			broadcast = true
+		case rpcErrorContains(err, "underpriced"):
+			// Another transaction has the nonce, so try again higher, without waiting since nothing new was sent.
+		case rpcErrorContains(err, "nonce too low") && len(res.Hashes) > 0:
+			// An earlier broadcast was likely mined, so wait for its receipt.
+			nonceUsed = true
+		default:
+			return res, fmt.Errorf("cannot send transaction: %w", err)
+		}
+
+		if broadcast || nonceUsed {
+			receipt, i, err := waitForAnyReceipt(ctx, client, res.Hashes, interval, poll)
+			if err != nil {
+				return res, err
+			}
+			if receipt == nil && nonceUsed {
+				return res, fmt.Errorf("%w: none of %d broadcasts was mined", ErrNonceConsumed, len(res.Hashes))
+			}
+			if receipt != nil {
+				res.Receipt, res.Attempt, res.GasPrice = receipt, i, res.GasPrices[i]
+				res.Fee = new(big.Int).Mul(res.GasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+				if receipt.Status != types.ReceiptStatusSuccessful {
+					return res, &TxRevertedError{Receipt: receipt}
+				}
+				return res, nil
+			}
+		}
+
+		next := policy.nextGasPrice(gasPrice)
+		if next.Cmp(policy.MaxGasPrice) > 0 {
+			next = policy.MaxGasPrice
+		}
+		if next.Cmp(bumpGasPrice(gasPrice, MinReplacementBump)) < 0 {
+			return res, fmt.Errorf("%w: not mined at gas price %s, with max %s", ErrGasPriceCap, gasPrice, policy.MaxGasPrice)
+		}
+		gasPrice = next
+	}
+}
+
+// nextGasPrice returns the gas price to replace a transaction with gasPrice.
+func (p EscalationPolicy) nextGasPrice(gasPrice *big.Int) *big.Int {
+	min := bumpGasPrice(gasPrice, MinReplacementBump)
+	if p.BumpStep == nil {
+		if p.BumpPercent == 0 {
+			return min
+		}
+		return bumpGasPrice(gasPrice, p.BumpPercent)
+	}
+	next := new(big.Int).Add(gasPrice, p.BumpStep)
+	if next.Cmp(min) < 0 {
+		return min
+	}
+	return next
+}
+
+// withGasPrice returns a copy of the unsigned tx with gasPrice.
+func withGasPrice(tx *types.Transaction, gasPrice *big.Int) *types.Transaction {
+	if tx.To() == nil {
+		return types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+	}
+	return types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+}
+
+// waitForAnyReceipt polls for a receipt of any of hashes every poll interval, for up to wait. It returns the receipt
+// and the index of its hash, or a nil receipt if none was found in time.
+func waitForAnyReceipt(ctx context.Context, client Client, hashes []common.Hash, wait, poll time.Duration) (*Receipt, int, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		last := false
+		select {
+		case <-ctx.Done():
+			return nil, -1, ctx.Err()
+		case <-timer.C:
+			last = true
+		case <-ticker.C:
+		}
+		for i, hash := range hashes {
+			receipt, err := client.GetTransactionReceipt(ctx, hash)
+			if err == nil {
+				return receipt, i, nil
+			}
+			if !errors.Is(err, NotFoundErr) && !isTransient(err) {
+				return nil, -1, err
+			}
+		}
+		if last {
+			return nil, -1, nil
+		}
+	}
+}
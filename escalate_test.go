@@ -0,0 +1,213 @@
+package web3_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+// scriptedSendClient calls onSend for each transaction sent, then fails it with the error at its index in errs, if
+// any, or sends it.
+type scriptedSendClient struct {
+	web3.Client
+	errs   []error
+	onSend func(n int, tx *types.Transaction)
+
+	mu    sync.Mutex
+	sends int
+}
+
+func (c *scriptedSendClient) SendRawTransaction(ctx context.Context, raw []byte) error {
+	c.mu.Lock()
+	n := c.sends
+	c.sends++
+	c.mu.Unlock()
+	tx, err := web3.DecodeTransaction(raw)
+	if err != nil {
+		return err
+	}
+	if c.onSend != nil {
+		c.onSend(n, tx)
+	}
+	if n < len(c.errs) && c.errs[n] != nil {
+		return c.errs[n]
+	}
+	return c.Client.SendRawTransaction(ctx, raw)
+}
+
+func TestSendAndConfirm_ambiguous(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := web3.NewLocalSigner(key)
+	tx := types.NewTransaction(3, common.HexToAddress("0x01"), big.NewInt(1), 21000, web3.Gwei(1), nil)
+	policy := web3.EscalationPolicy{MaxGasPrice: web3.Gwei(10), Interval: 20 * time.Millisecond, PollInterval: time.Millisecond}
+	rpcErr := func(msg string) error {
+		return &web3.RPCError{Method: "eth_sendRawTransaction", Err: errors.New(msg)}
+	}
+
+	for _, tt := range []struct {
+		name string
+		errs []error
+		// mine is the send after which the transaction sent by mineSend is mined.
+		mine, mineSend int
+		status         uint64
+		wantAttempt    int
+		wantHashes     int
+		wantGasPrice   *big.Int
+		wantErr        error
+	}{
+		{name: "sent", mine: 0, mineSend: 0, wantAttempt: 0, wantHashes: 1, wantGasPrice: web3.Gwei(1)},
+		{name: "escalated", mine: 2, mineSend: 2, wantAttempt: 2, wantHashes: 3, wantGasPrice: big.NewInt(1.21e9)},
+		{name: "network error", errs: []error{io.ErrUnexpectedEOF}, mine: 0, mineSend: 0, wantAttempt: 0, wantHashes: 1, wantGasPrice: web3.Gwei(1)},
+		{name: "already known", errs: []error{nil, rpcErr("already known")}, mine: 1, mineSend: 1, wantAttempt: 1, wantHashes: 2, wantGasPrice: big.NewInt(1.1e9)},
+		// An earlier broadcast is mined while replacing it.
+		{name: "nonce too low", errs: []error{nil, nil, rpcErr("nonce too low")}, mine: 2, mineSend: 0, wantAttempt: 0, wantHashes: 2, wantGasPrice: web3.Gwei(1)},
+		// Another transaction had the nonce, so it was replaced.
+		{name: "underpriced", errs: []error{rpcErr("replacement transaction underpriced")}, mine: 1, mineSend: 1, wantAttempt: 0, wantHashes: 1, wantGasPrice: big.NewInt(1.1e9)},
+		{name: "reverted", mine: 1, mineSend: 1, status: types.ReceiptStatusFailed, wantAttempt: 1, wantHashes: 2, wantGasPrice: big.NewInt(1.1e9), wantErr: web3.ErrTxReverted},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := web3test.NewFakeClient()
+			fake.SetChainID(big.NewInt(60))
+			var sent []*types.Transaction
+			c := &scriptedSendClient{
+				Client: fake,
+				errs:   tt.errs,
+				onSend: func(n int, tx *types.Transaction) {
+					sent = append(sent, tx)
+					if n != tt.mine {
+						return
+					}
+					status := tt.status
+					if status == 0 && tt.wantErr == nil {
+						status = types.ReceiptStatusSuccessful
+					}
+					hash := sent[tt.mineSend].Hash()
+					fake.AddReceipt(&web3.Receipt{TxHash: hash, Status: status, GasUsed: 21000})
+				},
+			}
+
+			res, err := web3.SendAndConfirm(context.Background(), c, s, tx, policy)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v but got: %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Attempt != tt.wantAttempt || len(res.Hashes) != tt.wantHashes {
+				t.Fatalf("expected attempt %d of %d but got %d of %v", tt.wantAttempt, tt.wantHashes, res.Attempt, res.Hashes)
+			}
+			if res.Receipt.TxHash != res.Hashes[res.Attempt] || res.Hashes[res.Attempt] != sent[tt.mineSend].Hash() {
+				t.Errorf("expected receipt of %s but got %s", sent[tt.mineSend].Hash().Hex(), res.Receipt.TxHash.Hex())
+			}
+			if res.GasPrice.Cmp(tt.wantGasPrice) != 0 {
+				t.Errorf("expected gas price %s but got %s", tt.wantGasPrice, res.GasPrice)
+			}
+			if want := new(big.Int).Mul(tt.wantGasPrice, big.NewInt(21000)); res.Fee.Cmp(want) != 0 {
+				t.Errorf("expected fee %s but got %s", want, res.Fee)
+			}
+			for _, tx := range sent {
+				if tx.Nonce() != 3 {
+					t.Errorf("expected every broadcast at nonce 3 but got %d", tx.Nonce())
+				}
+			}
+		})
+	}
+
+	// The nonce was taken by a transaction which isn't one of the broadcasts.
+	fake := web3test.NewFakeClient()
+	fake.SetChainID(big.NewInt(60))
+	c := &scriptedSendClient{
+		Client: fake,
+		errs:   []error{nil, rpcErr("nonce too low")},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := web3.SendAndConfirm(ctx, c, s, tx, policy)
+	if !errors.Is(err, web3.ErrNonceConsumed) || res == nil || len(res.Hashes) != 1 || res.Attempt != -1 {
+		t.Errorf("expected %v after 1 broadcast but got %+v: %v", web3.ErrNonceConsumed, res, err)
+	}
+
+	// An underpriced first send is retried higher right away, rather than after waiting an interval for nothing.
+	fake = web3test.NewFakeClient()
+	fake.SetChainID(big.NewInt(60))
+	var sent []*types.Transaction
+	c = &scriptedSendClient{
+		Client: fake,
+		errs:   []error{rpcErr("replacement transaction underpriced")},
+		onSend: func(n int, tx *types.Transaction) {
+			sent = append(sent, tx)
+			if n == 1 {
+				fake.AddReceipt(&web3.Receipt{TxHash: tx.Hash(), Status: types.ReceiptStatusSuccessful, GasUsed: 21000})
+			}
+		},
+	}
+	slow := policy
+	slow.Interval = time.Hour
+	start := time.Now()
+	res, err = web3.SendAndConfirm(ctx, c, s, tx, slow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sent) != 2 || sent[1].GasPrice().Cmp(big.NewInt(1.1e9)) != 0 || res.Attempt != 0 {
+		t.Errorf("expected the second send at 1.1 gwei to be mined but got %+v", res)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected no wait before retrying but took %s", d)
+	}
+
+	// A definite rejection by the node isn't retried.
+	fake = web3test.NewFakeClient()
+	fake.SetChainID(big.NewInt(60))
+	c = &scriptedSendClient{
+		Client: fake,
+		errs:   []error{rpcErr("insufficient funds for gas * price + value")},
+	}
+	res, err = web3.SendAndConfirm(context.Background(), c, s, tx, policy)
+	if !web3.RPCErrorContains(err, "insufficient funds") || res == nil || len(res.Hashes) != 0 || res.Attempt != -1 {
+		t.Errorf("expected the node's error with no broadcasts but got %+v: %v", res, err)
+	}
+}
+
+func TestEscalationPolicy_nextGasPrice(t *testing.T) {
+	for _, tt := range []struct {
+		policy web3.EscalationPolicy
+		want   int64
+	}{
+		{policy: web3.EscalationPolicy{}, want: 110},
+		{policy: web3.EscalationPolicy{BumpPercent: 25}, want: 125},
+		{policy: web3.EscalationPolicy{BumpStep: big.NewInt(50)}, want: 150},
+		{policy: web3.EscalationPolicy{BumpStep: big.NewInt(5), BumpPercent: 50}, want: 110},
+	} {
+		if got := tt.policy.NextGasPrice(big.NewInt(100)); got.Int64() != tt.want {
+			t.Errorf("%+v: expected %d but got %s", tt.policy, tt.want, got)
+		}
+	}
+
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewTransaction(0, common.HexToAddress("0x01"), big.NewInt(1), 21000, web3.Gwei(2), nil)
+	for _, policy := range []web3.EscalationPolicy{{}, {MaxGasPrice: web3.Gwei(1)}, {MaxGasPrice: web3.Gwei(10), BumpPercent: 5}} {
+		if _, err := web3.SendAndConfirm(ctx, c, web3.NewLocalSigner(key), tx, policy); err == nil {
+			t.Errorf("%+v: expected error", policy)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package web3
+
+import "math/big"
+
+// Exported for the tests in package web3_test.
+var (
+	BumpGasPrice      = bumpGasPrice
+	ConvertTx         = convertTx
+	ERC721ABI         = erc721ABI
+	ERC721InterfaceID = erc721InterfaceID
+	RPCErrorContains  = rpcErrorContains
+)
+
+func (p EscalationPolicy) NextGasPrice(price *big.Int) *big.Int {
+	return p.nextGasPrice(price)
+}
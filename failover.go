@@ -0,0 +1,219 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/rpc"
+)
+
+// FailoverPolicy selects the order in which a failover client tries its endpoints.
+type FailoverPolicy int
+
+const (
+	// FailoverPrimary sends every call to the first healthy endpoint, in the order given.
+	FailoverPrimary FailoverPolicy = iota
+	// FailoverRoundRobin spreads calls across the healthy endpoints.
+	FailoverRoundRobin
+)
+
+// FailoverOptions configures NewFailoverClient.
+type FailoverOptions struct {
+	// ClientOptions apply to each endpoint. Retries are made after every endpoint has been tried.
+	ClientOptions
+	Policy FailoverPolicy
+	// MaxFailures is the number of consecutive failures after which an endpoint is marked unhealthy, and is only
+	// tried once all healthy endpoints have failed. Defaults to 3.
+	MaxFailures int
+	// ProbeInterval is how often unhealthy endpoints are probed in the background, to restore them once they
+	// respond again. Defaults to 30 seconds.
+	ProbeInterval time.Duration
+}
+
+// AmbiguousSendError is returned when sending a transaction, alone or in a batch, fails after the request may have
+// reached the node, ie: the connection was lost before a response. The transaction may or may not have been accepted, so it is
+// not sent to another endpoint. Check for it by hash before sending it again.
+type AmbiguousSendError struct {
+	URL string
+	err error
+}
+
+func (e *AmbiguousSendError) Error() string {
+	return fmt.Sprintf("transaction may have been sent to %s: %v", e.URL, e.err)
+}
+
+func (e *AmbiguousSendError) Unwrap() error {
+	return e.err
+}
+
+// NewFailoverClient returns a client which sends each call to one of urls, and tries the next endpoint when one
+// fails to respond. Node errors, like reverts, are returned without trying other endpoints. The URL and transport
+// reported by the client are those of the first endpoint, and subscriptions are made with the first healthy one.
+func NewFailoverClient(urls []string, opts FailoverOptions) (Client, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no endpoint urls")
+	}
+	if opts.MaxFailures == 0 {
+		opts.MaxFailures = 3
+	}
+	if opts.ProbeInterval == 0 {
+		opts.ProbeInterval = 30 * time.Second
+	}
+	f := &failover{policy: opts.Policy, maxFailures: opts.MaxFailures, logger: opts.Logger, quit: make(chan struct{})}
+	for _, url := range urls {
+		c, err := DialWithOptions(url, opts.ClientOptions)
+		if err != nil {
+			for _, e := range f.endpoints {
+				e.r.Close()
+			}
+			return nil, err
+		}
+		f.endpoints = append(f.endpoints, &endpoint{url: url, r: c.(*client).r, healthy: true})
+	}
+	go f.probe(opts.ProbeInterval)
+	c := newClient(f.endpoints[0].r, urls[0], opts.ClientOptions)
+	c.failover = f
+	return c, nil
+}
+
+// failover tracks the health of a set of endpoints.
+type failover struct {
+	policy      FailoverPolicy
+	maxFailures int
+	logger      Logger
+	endpoints   []*endpoint
+	quit        chan struct{}
+
+	mu   sync.Mutex
+	next int // next endpoint for FailoverRoundRobin
+}
+
+type endpoint struct {
+	url string
+	r   *rpc.Client
+
+	// Guarded by failover.mu.
+	failures int
+	healthy  bool
+}
+
+// order returns the endpoints in the order to try them, healthy ones first.
+func (f *failover) order() []*endpoint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	start := 0
+	if f.policy == FailoverRoundRobin {
+		start = f.next
+		f.next = (f.next + 1) % len(f.endpoints)
+	}
+	var healthy, unhealthy []*endpoint
+	for i := range f.endpoints {
+		e := f.endpoints[(start+i)%len(f.endpoints)]
+		if e.healthy {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// record updates the health of e after a call which failed with err, or succeeded if err is nil.
+func (f *failover) record(e *endpoint, err error) {
+	f.mu.Lock()
+	wasHealthy := e.healthy
+	if err == nil {
+		e.failures = 0
+		e.healthy = true
+	} else {
+		e.failures++
+		if e.failures >= f.maxFailures {
+			e.healthy = false
+		}
+	}
+	healthy, failures := e.healthy, e.failures
+	f.mu.Unlock()
+	if wasHealthy && !healthy {
+		logf(f.logger, "web3: endpoint %s marked unhealthy after %d failures: %v", e.url, failures, err)
+	} else if !wasHealthy && healthy {
+		logf(f.logger, "web3: endpoint %s restored", e.url)
+	}
+}
+
+// do calls fn with each endpoint in turn until one responds. Calls to method which send transactions are only
+// passed on to the next endpoint if the request could not have been sent.
+func (f *failover) do(ctx context.Context, method string, fn func(*rpc.Client) error) error {
+	var err error
+	for _, e := range f.order() {
+		err = fn(e.r)
+		if err == nil || !isTransient(err) {
+			// The endpoint responded, even if with an error.
+			f.record(e, nil)
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		f.record(e, err)
+		if isSend(method) && !isDialErr(err) {
+			return &AmbiguousSendError{URL: e.url, err: err}
+		}
+	}
+	return err
+}
+
+// probe checks unhealthy endpoints every interval until quit is closed.
+func (f *failover) probe(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-f.quit:
+			return
+		}
+		for _, e := range f.endpoints {
+			f.mu.Lock()
+			healthy := e.healthy
+			f.mu.Unlock()
+			if healthy {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			var n hexutil.Uint64
+			if err := e.r.CallContext(ctx, &n, "eth_blockNumber"); err == nil || !isTransient(err) {
+				// Responded, even if with an error.
+				f.record(e, nil)
+			}
+			cancel()
+		}
+	}
+}
+
+// subscriber returns the rpc client to subscribe with.
+func (f *failover) subscriber() *rpc.Client {
+	return f.order()[0].r
+}
+
+func (f *failover) close() {
+	close(f.quit)
+	for _, e := range f.endpoints {
+		e.r.Close()
+	}
+}
+
+// isSend reports whether method sends a transaction, which is not safe to repeat.
+func isSend(method string) bool {
+	return method == "eth_sendRawTransaction" || method == "eth_sendTransaction"
+}
+
+// isDialErr reports whether err is a failure to connect, in which case no request was sent.
+func isDialErr(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,227 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/rpc"
+)
+
+// testEndpoint is an HTTP endpoint serving eth, which counts requests and can be taken down.
+type testEndpoint struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests int
+	down     bool // respond with 503
+	hangUp   bool // close the connection without responding
+}
+
+func newTestEndpoint(t *testing.T, eth *FakeEthService) *testEndpoint {
+	t.Helper()
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", eth); err != nil {
+		t.Fatal(err)
+	}
+	e := &testEndpoint{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		e.requests++
+		down, hangUp := e.down, e.hangUp
+		e.mu.Unlock()
+		switch {
+		case hangUp:
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		case down:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			srv.ServeHTTP(w, r)
+		}
+	}))
+	t.Cleanup(e.Close)
+	t.Cleanup(srv.Stop)
+	return e
+}
+
+func (e *testEndpoint) set(down, hangUp bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.down, e.hangUp = down, hangUp
+}
+
+// take returns and resets the request count.
+func (e *testEndpoint) take() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := e.requests
+	e.requests = 0
+	return n
+}
+
+func TestFailoverClient(t *testing.T) {
+	a := newTestEndpoint(t, &FakeEthService{Balance: big.NewInt(1)})
+	b := newTestEndpoint(t, &FakeEthService{Balance: big.NewInt(2)})
+	logger := &testLogger{}
+	opts := FailoverOptions{MaxFailures: 2, ProbeInterval: 10 * time.Millisecond, ClientOptions: ClientOptions{Logger: logger}}
+	c, err := NewFailoverClient([]string{a.URL, b.URL}, opts)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	const address = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	balance := func() int64 {
+		t.Helper()
+		bal, err := c.GetBalance(ctx, address, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return bal.Int64()
+	}
+
+	if got := balance(); got != 1 {
+		t.Errorf("expected primary balance 1 but got %d", got)
+	}
+	a.set(true, false)
+	for i := 0; i < 3; i++ {
+		if got := balance(); got != 2 {
+			t.Errorf("expected fallback balance 2 but got %d", got)
+		}
+	}
+	// Only tried until it was marked unhealthy, then only probed.
+	if n := a.take(); n < 2 {
+		t.Errorf("expected at least 2 requests to the failed endpoint but got %d", n)
+	}
+
+	// Restored by probing.
+	a.set(false, false)
+	deadline := time.Now().Add(5 * time.Second)
+	for balance() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the primary endpoint to be restored")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	msgs := logger.messages()
+	want := []string{"web3: endpoint " + a.URL + " marked unhealthy after 2 failures: 503 Service Unavailable", "web3: endpoint " + a.URL + " restored"}
+	if len(msgs) != 2 || !strings.HasPrefix(msgs[0], want[0]) || msgs[1] != want[1] {
+		t.Errorf("expected messages %q but got %q", want, msgs)
+	}
+
+	// Killed outright.
+	a.Close()
+	for i := 0; i < 3; i++ {
+		if got := balance(); got != 2 {
+			t.Errorf("expected fallback balance 2 but got %d", got)
+		}
+	}
+}
+
+func TestFailoverClient_roundRobin(t *testing.T) {
+	a := newTestEndpoint(t, &FakeEthService{Balance: big.NewInt(1)})
+	b := newTestEndpoint(t, &FakeEthService{Balance: big.NewInt(2)})
+	c, err := NewFailoverClient([]string{a.URL, b.URL}, FailoverOptions{Policy: FailoverRoundRobin})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+	for i := 0; i < 4; i++ {
+		if _, err := c.GetBalance(context.Background(), "0xa25b5e2d2d63dad7fa940e239925f29320f5103d", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if na, nb := a.take(), b.take(); na != 2 || nb != 2 {
+		t.Errorf("expected 2 requests to each endpoint but got %d and %d", na, nb)
+	}
+}
+
+func TestFailoverClient_send(t *testing.T) {
+	raw := []byte{0xc0}
+	t.Run("ambiguous", func(t *testing.T) {
+		a := newTestEndpoint(t, &FakeEthService{})
+		b := newTestEndpoint(t, &FakeEthService{})
+		c, err := NewFailoverClient([]string{a.URL, b.URL}, FailoverOptions{ClientOptions: ClientOptions{MaxRetries: 2, RetryBackoff: time.Millisecond}})
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		defer c.Close()
+		a.set(false, true)
+		err = c.SendRawTransaction(context.Background(), raw)
+		var ambiguous *AmbiguousSendError
+		if !errors.As(err, &ambiguous) || ambiguous.URL != a.URL {
+			t.Errorf("expected *AmbiguousSendError for %s but got: %v", a.URL, err)
+		}
+		if na, nb := a.take(), b.take(); na != 1 || nb != 0 {
+			t.Errorf("expected a single request to the first endpoint but got %d and %d", na, nb)
+		}
+	})
+	t.Run("batch", func(t *testing.T) {
+		a := newTestEndpoint(t, &FakeEthService{})
+		b := newTestEndpoint(t, &FakeEthService{})
+		c, err := NewFailoverClient([]string{a.URL, b.URL}, FailoverOptions{ClientOptions: ClientOptions{MaxRetries: 2, RetryBackoff: time.Millisecond}})
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		defer c.Close()
+		a.set(false, true)
+		err = c.BatchCall(context.Background(), []rpc.BatchElem{
+			{Method: "eth_blockNumber", Result: new(hexutil.Uint64)},
+			{Method: "eth_sendRawTransaction", Args: []interface{}{hexutil.Bytes(raw)}},
+		})
+		var ambiguous *AmbiguousSendError
+		if !errors.As(err, &ambiguous) || ambiguous.URL != a.URL {
+			t.Errorf("expected *AmbiguousSendError for %s but got: %v", a.URL, err)
+		}
+		if na, nb := a.take(), b.take(); na != 1 || nb != 0 {
+			t.Errorf("expected a single request to the first endpoint but got %d and %d", na, nb)
+		}
+	})
+	t.Run("unreachable", func(t *testing.T) {
+		a := newTestEndpoint(t, &FakeEthService{})
+		eth := &FakeEthService{}
+		b := newTestEndpoint(t, eth)
+		c, err := NewFailoverClient([]string{a.URL, b.URL}, FailoverOptions{})
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+		defer c.Close()
+		a.Close()
+		// Fails to decode, but reached the second endpoint.
+		err = c.SendRawTransaction(context.Background(), raw)
+		var ambiguous *AmbiguousSendError
+		if errors.As(err, &ambiguous) {
+			t.Errorf("expected unambiguous error but got: %v", err)
+		}
+		if b.take() != 1 {
+			t.Error("expected the transaction to be sent to the second endpoint")
+		}
+	})
+}
+
+func TestFailoverClient_nodeError(t *testing.T) {
+	a := newTestEndpoint(t, &FakeEthService{CallFunc: func(map[string]interface{}) ([]byte, error) {
+		return nil, errors.New("execution reverted: nope")
+	}})
+	b := newTestEndpoint(t, &FakeEthService{})
+	c, err := NewFailoverClient([]string{a.URL, b.URL}, FailoverOptions{})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+	to := common.HexToAddress("0x01")
+	if _, err := c.Call(context.Background(), CallMsg{To: &to}); err == nil {
+		t.Error("expected node error")
+	}
+	if b.take() != 0 {
+		t.Error("expected node error to be returned without trying the next endpoint")
+	}
+}
@@ -0,0 +1,109 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// DefaultGasPriceBlocks is the number of recent blocks sampled by GasPriceTiers.
+const DefaultGasPriceBlocks = 20
+
+// GasPrices are gas price suggestions from GasPriceTiers, for how soon a transaction should be mined.
+type GasPrices struct {
+	Slow     *big.Int // 25th percentile
+	Standard *big.Int // 50th percentile
+	Fast     *big.Int // 90th percentile
+}
+
+// GasPriceEstimate returns the percentile (0-100) of the gas prices paid by the transactions in the latest blocks.
+// Blocks without transactions are skipped. If no transactions were found, the node's suggestion from
+// Client.GetGasPrice is returned instead.
+func GasPriceEstimate(ctx context.Context, client Client, blocks int, percentile float64) (*big.Int, error) {
+	if blocks <= 0 {
+		return nil, fmt.Errorf("invalid block count: %d", blocks)
+	}
+	if percentile < 0 || percentile > 100 || math.IsNaN(percentile) {
+		return nil, fmt.Errorf("invalid percentile: %v", percentile)
+	}
+	prices, err := recentGasPrices(ctx, client, blocks)
+	if err != nil {
+		return nil, err
+	}
+	if len(prices) == 0 {
+		return client.GetGasPrice(ctx)
+	}
+	return gasPricePercentile(prices, percentile), nil
+}
+
+// GasPriceTiers returns slow, standard, and fast gas prices, from the 25th, 50th, and 90th percentiles of the gas
+// prices paid in the last DefaultGasPriceBlocks blocks. Like GasPriceEstimate, it falls back to the node's
+// suggestion for every tier if there were no transactions.
+func GasPriceTiers(ctx context.Context, client Client) (*GasPrices, error) {
+	prices, err := recentGasPrices(ctx, client, DefaultGasPriceBlocks)
+	if err != nil {
+		return nil, err
+	}
+	if len(prices) == 0 {
+		price, err := client.GetGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &GasPrices{Slow: price, Standard: price, Fast: price}, nil
+	}
+	return &GasPrices{
+		Slow:     gasPricePercentile(prices, 25),
+		Standard: gasPricePercentile(prices, 50),
+		Fast:     gasPricePercentile(prices, 90),
+	}, nil
+}
+
+// recentGasPrices returns the gas prices of the transactions in the latest blocks, sorted in ascending order.
+func recentGasPrices(ctx context.Context, client Client, blocks int) ([]*big.Int, error) {
+	latest, err := client.GetLatestBlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get latest block number: %w", err)
+	}
+	var prices []*big.Int
+	for i := 0; i < blocks && uint64(i) <= latest; i++ {
+		number := new(big.Int).SetUint64(latest - uint64(i))
+		block, err := client.GetBlockByNumber(ctx, number, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get block %s: %w", number, err)
+		}
+		for _, tx := range block.TxDetails {
+			if tx.GasPrice != nil {
+				prices = append(prices, tx.GasPrice)
+			}
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Cmp(prices[j]) < 0 })
+	return prices, nil
+}
+
+// gasPricePercentile returns the nearest-rank percentile of sorted, which must not be empty.
+func gasPricePercentile(sorted []*big.Int, percentile float64) *big.Int {
+	i := int(math.Ceil(percentile/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return new(big.Int).Set(sorted[i])
+}
+
+// ErrNoBaseFee is returned by GetBaseFee for networks without EIP-1559.
+var ErrNoBaseFee = errors.New("latest block has no base fee")
+
+// GetBaseFee returns the base fee per gas of the latest block, for EIP-1559 pricing.
+func GetBaseFee(ctx context.Context, client Client) (*big.Int, error) {
+	block, err := client.GetBlockByNumber(ctx, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get latest block: %w", err)
+	}
+	if block.BaseFee == nil {
+		return nil, ErrNoBaseFee
+	}
+	return block.BaseFee, nil
+}
@@ -0,0 +1,133 @@
+package web3
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+// gasPriceBlocks returns blocks 0 to len(gwei)-1, with a transaction for each of their gas prices, in gwei.
+func gasPriceBlocks(t *testing.T, gwei ...[]int64) map[uint64]*Block {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := make(map[uint64]*Block)
+	var nonce uint64
+	for n, prices := range gwei {
+		block := testBlock(uint64(n))
+		block.TxHashes = nil
+		block.TxDetails = []*Transaction{}
+		if len(prices) > 0 {
+			block.TxsRoot = common.HexToHash("0x0a")
+		}
+		for _, price := range prices {
+			tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(1), 21000, Gwei(price), nil), types.HomesteadSigner{}, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce++
+			block.TxDetails = append(block.TxDetails, convertTx(tx, crypto.PubkeyToAddress(key.PublicKey)))
+		}
+		blocks[uint64(n)] = block
+	}
+	return blocks
+}
+
+func TestGasPriceEstimate(t *testing.T) {
+	ctx := context.Background()
+	blocks := gasPriceBlocks(t, []int64{100}, []int64{5, 1}, nil, []int64{3, 2, 4}, []int64{})
+	eth := &FakeEthService{Blocks: blocks, Head: 4, Price: Gwei(7)}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+
+	for _, tt := range []struct {
+		blocks     int
+		percentile float64
+		want       int64
+	}{
+		// The latest 4 blocks have gas prices 1 to 5.
+		{blocks: 4, percentile: 0, want: 1},
+		{blocks: 4, percentile: 20, want: 1},
+		{blocks: 4, percentile: 50, want: 3},
+		{blocks: 4, percentile: 90, want: 5},
+		{blocks: 4, percentile: 100, want: 5},
+		// More blocks than the chain has.
+		{blocks: 10, percentile: 100, want: 100},
+		// No transactions, so the node's suggestion.
+		{blocks: 1, percentile: 50, want: 7},
+	} {
+		got, err := GasPriceEstimate(ctx, c, tt.blocks, tt.percentile)
+		if err != nil {
+			t.Fatalf("%d blocks at %v: unexpected error: %v", tt.blocks, tt.percentile, err)
+		}
+		if got.Cmp(Gwei(tt.want)) != 0 {
+			t.Errorf("%d blocks at %v: expected %d gwei but got %s", tt.blocks, tt.percentile, tt.want, got)
+		}
+	}
+	for _, full := range eth.FullTxs {
+		if !full {
+			t.Errorf("expected blocks with full transactions")
+		}
+	}
+
+	for _, tt := range []struct {
+		blocks     int
+		percentile float64
+	}{{0, 50}, {-1, 50}, {4, -1}, {4, 101}} {
+		if _, err := GasPriceEstimate(ctx, c, tt.blocks, tt.percentile); err == nil {
+			t.Errorf("%d blocks at %v: expected error", tt.blocks, tt.percentile)
+		}
+	}
+}
+
+func TestGasPriceTiers(t *testing.T) {
+	ctx := context.Background()
+	var prices []int64
+	for i := int64(1); i <= 20; i++ {
+		prices = append(prices, i)
+	}
+	eth := &FakeEthService{Blocks: gasPriceBlocks(t, prices[:10], nil, prices[10:]), Head: 2}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	tiers, err := GasPriceTiers(ctx, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tiers.Slow.Cmp(Gwei(5)) != 0 || tiers.Standard.Cmp(Gwei(10)) != 0 || tiers.Fast.Cmp(Gwei(18)) != 0 {
+		t.Errorf("expected tiers 5, 10, and 18 gwei but got %s, %s, and %s", tiers.Slow, tiers.Standard, tiers.Fast)
+	}
+
+	eth = &FakeEthService{Blocks: gasPriceBlocks(t, nil), Price: Gwei(3)}
+	c = newTestClient(t, map[string]interface{}{"eth": eth})
+	tiers, err = GasPriceTiers(ctx, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tiers.Slow.Cmp(Gwei(3)) != 0 || tiers.Standard.Cmp(Gwei(3)) != 0 || tiers.Fast.Cmp(Gwei(3)) != 0 {
+		t.Errorf("expected the suggested gas price for every tier but got %+v", tiers)
+	}
+}
+
+func TestGetBaseFee(t *testing.T) {
+	ctx := context.Background()
+	legacy := testBlock(1)
+	eip1559 := testBlock(2)
+	eip1559.BaseFee = Gwei(7)
+	eth := &FakeEthService{Blocks: map[uint64]*Block{1: legacy, 2: eip1559}, Head: 2}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+
+	fee, err := GetBaseFee(ctx, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if fee.Cmp(Gwei(7)) != 0 {
+		t.Errorf("expected base fee %s but got %s", Gwei(7), fee)
+	}
+	eth.Head = 1
+	if _, err := GetBaseFee(ctx, c); err != ErrNoBaseFee {
+		t.Errorf("expected %v but got: %v", ErrNoBaseFee, err)
+	}
+}
@@ -0,0 +1,133 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gochain/gochain/v3"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/rpc"
+)
+
+// HeadOptions configures SubscribeNewHeads.
+type HeadOptions struct {
+	// PollInterval is how often to poll for new blocks when the connection does not support subscriptions.
+	// Defaults to 2 seconds.
+	PollInterval time.Duration
+}
+
+// SubscribeNewHeads is like Client.SubscribeNewHead, but falls back to polling for new blocks when the client is
+// connected over HTTP. When polling, each block is sent once and in order, including blocks produced between
+// polls. The returned channel is closed once the subscription fails, is unsubscribed, or ctx is done.
+func SubscribeNewHeads(ctx context.Context, client Client, opts HeadOptions) (<-chan *types.Header, gochain.Subscription, error) {
+	heads, sub, err := client.SubscribeNewHead(ctx)
+	if !errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return heads, sub, err
+	}
+	interval := opts.PollInterval
+	if interval == 0 {
+		interval = 2 * time.Second
+	}
+	head, err := client.GetBlockByNumber(ctx, nil, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	ps := &pollSubscription{err: make(chan error, 1), quit: make(chan struct{})}
+	out := make(chan *types.Header)
+	go ps.poll(ctx, client, head.Number.Uint64()+1, interval, out)
+	return out, ps, nil
+}
+
+// pollSubscription is a subscription to new heads which polls for new blocks.
+type pollSubscription struct {
+	err      chan error
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+func (s *pollSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *pollSubscription) Unsubscribe() {
+	s.quitOnce.Do(func() { close(s.quit) })
+}
+
+// poll sends the headers of blocks from next onwards to out, checking for new blocks every interval, until it is
+// unsubscribed, a request fails, or ctx is done. Transient failures are retried at the next interval.
+func (s *pollSubscription) poll(ctx context.Context, client Client, next uint64, interval time.Duration, out chan<- *types.Header) {
+	defer close(out)
+	defer close(s.err)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		case <-ctx.Done():
+			s.err <- ctx.Err()
+			return
+		}
+		latest, err := client.GetBlockByNumber(ctx, nil, false)
+		for err == nil && next <= latest.Number.Uint64() {
+			block := latest
+			if next < latest.Number.Uint64() {
+				// Backfill blocks produced since the last poll.
+				block, err = client.GetBlockByNumber(ctx, new(big.Int).SetUint64(next), false)
+				if err != nil {
+					break
+				}
+			}
+			select {
+			case out <- blockHeader(block):
+				next++
+			case <-s.quit:
+				return
+			case <-ctx.Done():
+				s.err <- ctx.Err()
+				return
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				s.err <- ctx.Err()
+				return
+			}
+			if !isTransient(err) {
+				s.err <- err
+				return
+			}
+		}
+	}
+}
+
+// blockHeader returns the header of b.
+func blockHeader(b *Block) *types.Header {
+	h := &types.Header{
+		ParentHash:  b.ParentHash,
+		UncleHash:   b.Sha3Uncles,
+		Coinbase:    b.Miner,
+		Signers:     b.Signers,
+		Voters:      b.Voters,
+		Signer:      b.Signer,
+		Root:        b.StateRoot,
+		TxHash:      b.TxsRoot,
+		ReceiptHash: b.ReceiptsRoot,
+		Difficulty:  b.Difficulty,
+		Number:      b.Number,
+		GasLimit:    b.GasLimit,
+		GasUsed:     b.GasUsed,
+		Time:        big.NewInt(b.Timestamp.Unix()),
+		Extra:       b.ExtraData,
+		MixDigest:   b.MixHash,
+		Nonce:       b.Nonce,
+	}
+	if b.LogsBloom != nil {
+		h.Bloom = *b.LogsBloom
+	}
+	return h
+}
@@ -0,0 +1,104 @@
+package web3_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+// newBlock returns block n, with hashes derived from n.
+func newBlock(n uint64) *web3.Block {
+	return &web3.Block{
+		ParentHash: common.BigToHash(new(big.Int).SetUint64(n - 1)),
+		Number:     new(big.Int).SetUint64(n),
+		Timestamp:  time.Unix(int64(n), 0).UTC(),
+		Hash:       common.BigToHash(new(big.Int).SetUint64(n + 1000)),
+	}
+}
+
+func TestSubscribeNewHeads_polling(t *testing.T) {
+	// Subscriptions fail like they do over HTTP, so it polls.
+	c := web3test.NewFakeClient()
+	for n := uint64(0); n <= 2; n++ {
+		c.AddBlock(newBlock(n))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	subCtx, subCancel := context.WithCancel(ctx)
+	defer subCancel()
+
+	heads, sub, err := web3.SubscribeNewHeads(subCtx, c, web3.HeadOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	// Advance the head unevenly, so that some polls see no new blocks and others skip several.
+	go func() {
+		next := uint64(3)
+		for _, head := range []uint64{2, 3, 3, 7, 8, 12} {
+			time.Sleep(5 * time.Millisecond)
+			for ; next <= head; next++ {
+				c.AddBlock(newBlock(next))
+			}
+		}
+	}()
+	for want := uint64(3); want <= 12; want++ {
+		select {
+		case h, ok := <-heads:
+			if !ok {
+				t.Fatalf("channel closed early: %v", <-sub.Err())
+			}
+			if h.Number.Uint64() != want {
+				t.Fatalf("expected header %d but got %s", want, h.Number)
+			}
+			if parent := newBlock(want).ParentHash; h.ParentHash != parent {
+				t.Errorf("expected parent %s but got %s", parent.Hex(), h.ParentHash.Hex())
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for header %d", want)
+		}
+	}
+
+	subCancel()
+	for {
+		select {
+		case h, ok := <-heads:
+			if ok {
+				t.Errorf("unexpected header after cancel: %s", h.Number)
+				continue
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for channel to close")
+		}
+		break
+	}
+	if err := <-sub.Err(); err != context.Canceled {
+		t.Errorf("expected %v but got: %v", context.Canceled, err)
+	}
+}
+
+func TestSubscribeNewHeads_unsubscribe(t *testing.T) {
+	c := web3test.NewFakeClient()
+	c.AddBlock(newBlock(1))
+	heads, sub, err := web3.SubscribeNewHeads(context.Background(), c, web3.HeadOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	select {
+	case _, ok := <-heads:
+		if ok {
+			t.Error("unexpected header")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for channel to close")
+	}
+	if err, ok := <-sub.Err(); ok {
+		t.Errorf("expected no error after unsubscribe but got: %v", err)
+	}
+}
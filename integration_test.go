@@ -0,0 +1,675 @@
+package web3_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/gochain/gochain/v3/rpc"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+// Well known development key and address.
+const (
+	devKey     = "0x8f2a55949038a9610f50fb23b5883af3b4ecb3c3bb792cbcefbd1542c692be63"
+	devAddress = "0xFE3B557E8Fb62b89F4916B721be55cEb828dBd73"
+)
+
+func dialTestServer(t *testing.T, opts web3.ClientOptions) (*web3test.Server, web3.Client) {
+	t.Helper()
+	srv := web3test.NewServer()
+	t.Cleanup(srv.Close)
+	c, err := web3.DialWithOptions(srv.URL, opts)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return srv, c
+}
+
+func TestDeployContract_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	const bin = "0x6080604052348015600f57600080fd5b50"
+
+	tx, err := web3.DeployContract(ctx, c, devKey, bin, "", 0)
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	if tx.GasLimit != 25200 {
+		t.Errorf("expected estimated gas limit 25200 but got %d", tx.GasLimit)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		srv.Mine()
+	}()
+	receipt, err := web3.WaitForReceiptWithOptions(ctx, c, tx.Hash, web3.ReceiptOptions{PollInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to wait for receipt: %v", err)
+	}
+	want, err := tx.ContractAddress()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want != crypto.CreateAddress(common.HexToAddress(devAddress), 0) {
+		t.Errorf("expected the contract address from the sender and nonce 0 but got %s", want.Hex())
+	}
+	if receipt.Status != 1 || receipt.ContractAddress != want || receipt.BlockNumber != 1 {
+		t.Errorf("expected successful receipt for %s in block 1 but got %+v", want.Hex(), receipt)
+	}
+	if code, err := c.GetCode(ctx, want.Hex(), nil); err != nil || len(code) == 0 {
+		t.Errorf("expected contract code but got %x: %v", code, err)
+	}
+
+	// The next deployment uses the next nonce.
+	tx, err = web3.DeployContract(ctx, c, devKey, bin, "", 100000)
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	if tx.Nonce != 1 {
+		t.Errorf("expected nonce 1 but got %d", tx.Nonce)
+	}
+	srv.Mine()
+	want, err = tx.ContractAddress()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt, err := c.GetTransactionReceipt(ctx, tx.Hash); err != nil || receipt.ContractAddress != want {
+		t.Errorf("expected contract at %s but got %+v: %v", want.Hex(), receipt, err)
+	}
+}
+
+func TestDeployContractWithABI_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	const storeABI = `[{"inputs":[{"name":"count","type":"uint256"},{"name":"label","type":"string"}],"stateMutability":"nonpayable","type":"constructor"}]`
+
+	tx, addr, err := web3.DeployContractWithABI(ctx, c, devKey, storeABI, "0x6080604052348015600f57600080fd5b50", big.NewInt(42), "store")
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	srv.Mine()
+	receipt, err := c.GetTransactionReceipt(ctx, tx.Hash)
+	if err != nil {
+		t.Fatalf("failed to get receipt: %v", err)
+	}
+	if receipt.ContractAddress != addr {
+		t.Errorf("expected contract at predicted address %s but got %s", addr.Hex(), receipt.ContractAddress.Hex())
+	}
+}
+
+func TestDeployContract_serverError(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	srv.SetError("eth_sendRawTransaction", errors.New("insufficient funds for gas * price + value"))
+	_, err := web3.DeployContract(context.Background(), c, devKey, "0x00", "", 100000)
+	var rpcErr *web3.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Method != "eth_sendRawTransaction" {
+		t.Errorf("expected *RPCError from eth_sendRawTransaction but got: %v", err)
+	}
+	if n := srv.Requests("eth_sendRawTransaction"); n != 1 {
+		t.Errorf("expected node error not to be retried, but got %d requests", n)
+	}
+}
+
+func TestWaitForReceipt_serverRetries(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{MaxRetries: 2, RetryBackoff: time.Millisecond})
+	ctx := context.Background()
+	srv.SetBalance(common.HexToAddress(devAddress), web3.Base(1))
+	tx, err := web3.Send(ctx, c, devKey, common.HexToAddress("0x01"), big.NewInt(5))
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	srv.Mine()
+
+	srv.FailNext("eth_getTransactionReceipt", http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	receipt, err := web3.WaitForReceipt(ctx, c, tx.Hash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if receipt.TxHash != tx.Hash {
+		t.Errorf("expected receipt for %s but got %s", tx.Hash.Hex(), receipt.TxHash.Hex())
+	}
+	if n := srv.Requests("eth_getTransactionReceipt"); n != 3 {
+		t.Errorf("expected 2 failures and a retry but got %d requests", n)
+	}
+	if bal, err := c.GetBalance(ctx, common.HexToAddress("0x01").Hex(), nil); err != nil || bal.Int64() != 5 {
+		t.Errorf("expected balance 5 but got %v: %v", bal, err)
+	}
+}
+
+func TestClient_serverLatency(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{DefaultTimeout: 10 * time.Millisecond})
+	srv.SetLatency("eth_blockNumber", time.Second)
+	if _, err := c.GetLatestBlockNumber(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRawCall_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{MaxRetries: 1, RetryBackoff: time.Millisecond, RateLimit: 1000, RateBurst: 10})
+	ctx := context.Background()
+
+	srv.FailNext("web3_clientVersion", http.StatusServiceUnavailable)
+	var version string
+	if err := c.RawCall(ctx, &version, "web3_clientVersion"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != web3test.ClientVersion {
+		t.Errorf("expected client version %q but got %q", web3test.ClientVersion, version)
+	}
+	if n := srv.Requests("web3_clientVersion"); n != 2 {
+		t.Errorf("expected a failure and a retry but got %d requests", n)
+	}
+
+	var netVersion string
+	batch := []rpc.BatchElem{
+		{Method: "web3_clientVersion", Result: &version},
+		{Method: "net_version", Result: &netVersion},
+		{Method: "txpool_status"},
+	}
+	if err := c.BatchCall(ctx, batch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch[0].Error != nil || version != web3test.ClientVersion || batch[1].Error != nil || netVersion != "1" {
+		t.Errorf("unexpected results %q and %q: %v, %v", version, netVersion, batch[0].Error, batch[1].Error)
+	}
+	if batch[2].Error == nil {
+		t.Error("expected error for unsupported method")
+	}
+	if n := c.Stats().Requests; n != 5 {
+		t.Errorf("expected 5 rate limited requests but got %d", n)
+	}
+}
+
+func TestTransferWithAccount_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	acct, err := web3.ParsePrivateKey(devKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	srv.SetBalance(acct.Address(), web3.Base(1))
+	to := common.HexToAddress("0x01")
+	tx, err := web3.TransferWithAccount(ctx, c, acct, to.Hex(), big.NewInt(5), web3.TransferOptions{})
+	if err != nil {
+		t.Fatalf("failed to transfer: %v", err)
+	}
+	if tx.From != acct.Address() {
+		t.Errorf("expected transfer from %s but got %s", acct.Address().Hex(), tx.From.Hex())
+	}
+	srv.Mine()
+	if bal, err := c.GetBalance(ctx, to.Hex(), nil); err != nil || bal.Int64() != 5 {
+		t.Errorf("expected balance 5 but got %v: %v", bal, err)
+	}
+}
+
+func TestNonceManager_server(t *testing.T) {
+	const n = 50
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	srv.SetBalance(common.HexToAddress(devAddress), web3.Base(1))
+	m := web3.NewNonceManager(c)
+	to := common.HexToAddress("0x01").Hex()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := web3.Transfer(ctx, c, devKey, to, big.NewInt(1), web3.TransferOptions{NonceSource: m})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("failed to transfer: %v", err)
+		}
+	}
+
+	block := srv.Mine()
+	if len(block.TxHashes) != n {
+		t.Fatalf("expected %d transactions mined but got %d", n, len(block.TxHashes))
+	}
+	nonces := make(map[uint64]bool)
+	for _, hash := range block.TxHashes {
+		tx, err := c.GetTransactionByHash(ctx, hash)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		nonces[tx.Nonce] = true
+	}
+	for i := uint64(0); i < n; i++ {
+		if !nonces[i] {
+			t.Fatalf("expected distinct nonces 0 to %d but got %v", n-1, nonces)
+		}
+	}
+	if bal, err := c.GetBalance(ctx, to, nil); err != nil || bal.Int64() != n {
+		t.Errorf("expected balance %d but got %v: %v", n, bal, err)
+	}
+}
+
+func TestSpeedUpTransaction_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	srv.SetBalance(common.HexToAddress(devAddress), web3.Base(1))
+	to := common.HexToAddress("0x01").Hex()
+	tx, err := web3.Transfer(ctx, c, devKey, to, big.NewInt(5), web3.TransferOptions{})
+	if err != nil {
+		t.Fatalf("failed to transfer: %v", err)
+	}
+
+	faster, err := web3.SpeedUpTransaction(ctx, c, devKey, tx.Hash, 10)
+	if err != nil {
+		t.Fatalf("failed to speed up: %v", err)
+	}
+	if want := big.NewInt(1.1e9); faster.Nonce != tx.Nonce || faster.GasPrice.Cmp(want) != 0 {
+		t.Errorf("expected nonce %d with gas price %s but got %d with %s", tx.Nonce, want, faster.Nonce, faster.GasPrice)
+	}
+	// The node dropped the original.
+	if _, err := web3.SpeedUpTransaction(ctx, c, devKey, tx.Hash, 10); !errors.Is(err, web3.NotFoundErr) {
+		t.Errorf("expected the replaced transaction to be gone but got: %v", err)
+	}
+	block := srv.Mine()
+	if len(block.TxHashes) != 1 || block.TxHashes[0] != faster.Hash {
+		t.Errorf("expected only %s mined but got %v", faster.Hash.Hex(), block.TxHashes)
+	}
+	if _, err := web3.CancelTransaction(ctx, c, devKey, faster.Hash, 0); !errors.Is(err, web3.ErrAlreadyMined) {
+		t.Errorf("expected %v but got: %v", web3.ErrAlreadyMined, err)
+	}
+}
+
+func TestSendAndConfirm_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	srv.SetBalance(common.HexToAddress(devAddress), web3.Base(1))
+	acct, err := web3.ParsePrivateKey(devKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := web3.BuildTransaction(ctx, c, devAddress, common.HexToAddress("0x01").Hex(), big.NewInt(5), nil, web3.TransactOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policy := web3.EscalationPolicy{
+		MaxGasPrice:  web3.Gwei(2),
+		BumpStep:     big.NewInt(0.2e9),
+		Interval:     50 * time.Millisecond,
+		PollInterval: 5 * time.Millisecond,
+	}
+
+	// Only mine after the third broadcast.
+	go func() {
+		for srv.Requests("eth_sendRawTransaction") < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		srv.Mine()
+	}()
+	res, err := web3.SendAndConfirm(ctx, c, acct, tx, policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Attempt != 2 || len(res.Hashes) != 3 || res.Receipt.TxHash != res.Hashes[2] {
+		t.Fatalf("expected the third of 3 broadcasts mined but got %d of %v", res.Attempt, res.Hashes)
+	}
+	if want := big.NewInt(1.4e9); res.GasPrice.Cmp(want) != 0 {
+		t.Errorf("expected gas price %s but got %s", want, res.GasPrice)
+	}
+	if want := new(big.Int).Mul(big.NewInt(1.4e9), new(big.Int).SetUint64(res.Receipt.GasUsed)); res.Fee.Cmp(want) != 0 {
+		t.Errorf("expected fee %s but got %s", want, res.Fee)
+	}
+
+	// Never mined, so it stops at the cap: 1, 1.2, 1.4, then 1.5 gwei is too small a bump.
+	tx, err = web3.BuildTransaction(ctx, c, devAddress, common.HexToAddress("0x01").Hex(), big.NewInt(5), nil, web3.TransactOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policy.MaxGasPrice = big.NewInt(1.5e9)
+	res, err = web3.SendAndConfirm(ctx, c, acct, tx, policy)
+	if !errors.Is(err, web3.ErrGasPriceCap) {
+		t.Fatalf("expected %v but got: %v", web3.ErrGasPriceCap, err)
+	}
+	if res.Attempt != -1 || len(res.Hashes) != 3 || res.GasPrices[2].Cmp(big.NewInt(1.4e9)) != 0 {
+		t.Errorf("expected 3 broadcasts up to 1.4 gwei but got %v at %v", res.Hashes, res.GasPrices)
+	}
+	// The last one is still pending, and can be watched.
+	srv.Mine()
+	if _, err := c.GetTransactionReceipt(ctx, res.Hashes[2]); err != nil {
+		t.Errorf("expected the last broadcast to be mined: %v", err)
+	}
+}
+
+// seedGasPrices mines a block for each of blocks, with a transfer from devAddress at each of its gas prices, in gwei.
+func seedGasPrices(tb testing.TB, srv *web3test.Server, c web3.Client, blocks ...[]int64) {
+	tb.Helper()
+	ctx := context.Background()
+	srv.SetBalance(common.HexToAddress(devAddress), web3.Base(1))
+	for _, prices := range blocks {
+		for _, price := range prices {
+			_, err := web3.Transfer(ctx, c, devKey, common.HexToAddress("0x01").Hex(), big.NewInt(1), web3.TransferOptions{GasPrice: web3.Gwei(price)})
+			if err != nil {
+				tb.Fatalf("failed to transfer: %v", err)
+			}
+		}
+		srv.Mine()
+	}
+}
+
+func TestGasPriceEstimate_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	srv.SetGasPrice(web3.Gwei(9))
+
+	// Only the genesis block, so the node's suggestion.
+	if got, err := web3.GasPriceEstimate(ctx, c, 10, 50); err != nil || got.Cmp(web3.Gwei(9)) != 0 {
+		t.Errorf("expected the suggested gas price but got %s: %v", got, err)
+	}
+
+	seedGasPrices(t, srv, c, []int64{50}, []int64{4, 2}, nil, []int64{1, 3, 5})
+	if got, err := web3.GasPriceEstimate(ctx, c, 3, 50); err != nil || got.Cmp(web3.Gwei(3)) != 0 {
+		t.Errorf("expected the median of the last 3 blocks but got %s: %v", got, err)
+	}
+	tiers, err := web3.GasPriceTiers(ctx, c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tiers.Slow.Cmp(web3.Gwei(2)) != 0 || tiers.Standard.Cmp(web3.Gwei(3)) != 0 || tiers.Fast.Cmp(web3.Gwei(50)) != 0 {
+		t.Errorf("expected tiers 2, 3, and 50 gwei but got %s, %s, and %s", tiers.Slow, tiers.Standard, tiers.Fast)
+	}
+}
+
+func BenchmarkGasPriceTiers_server(b *testing.B) {
+	srv := web3test.NewServer()
+	defer srv.Close()
+	c, err := web3.Dial(srv.URL)
+	if err != nil {
+		b.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+	var blocks [][]int64
+	for i := int64(1); i <= web3.DefaultGasPriceBlocks; i++ {
+		blocks = append(blocks, []int64{i, i + 1, i + 2})
+	}
+	seedGasPrices(b, srv, c, blocks...)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := web3.GasPriceTiers(ctx, c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCallAt_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	dev, to := common.HexToAddress(devAddress), common.HexToAddress("0x01")
+	// The call returns the balance of its recipient, and a storage slot of it.
+	srv.SetStateCallFunc(func(msg web3.CallMsg, st *web3test.State) ([]byte, error) {
+		slot := st.Storage[*msg.To][common.Hash{}]
+		return append(common.LeftPadBytes(st.Balance(*msg.To).Bytes(), 32), slot[:]...), nil
+	})
+	srv.SetBalance(dev, web3.Base(1))
+	srv.Mine()
+	for i := 0; i < 2; i++ {
+		if _, err := web3.Transfer(ctx, c, devKey, to.Hex(), big.NewInt(100), web3.TransferOptions{}); err != nil {
+			t.Fatalf("failed to transfer: %v", err)
+		}
+		srv.Mine()
+	}
+
+	msg := web3.CallMsg{From: dev, To: &to}
+	call := func(block *big.Int, overrides map[common.Address]web3.StateOverride) (*big.Int, common.Hash) {
+		t.Helper()
+		res, err := web3.CallAt(ctx, c, msg, block, overrides)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return new(big.Int).SetBytes(res[:32]), common.BytesToHash(res[32:])
+	}
+	for _, tt := range []struct {
+		block *big.Int
+		want  int64
+	}{{big.NewInt(1), 0}, {big.NewInt(2), 100}, {big.NewInt(3), 200}, {nil, 200}} {
+		if got, _ := call(tt.block, nil); got.Int64() != tt.want {
+			t.Errorf("block %v: expected balance %d but got %s", tt.block, tt.want, got)
+		}
+		if got, err := c.GetBalance(ctx, to.Hex(), tt.block); err != nil || got.Int64() != tt.want {
+			t.Errorf("block %v: expected balance %d but got %s: %v", tt.block, tt.want, got, err)
+		}
+	}
+	if _, err := web3.CallAt(ctx, c, msg, big.NewInt(4), nil); err == nil {
+		t.Error("expected error for a future block")
+	}
+
+	value := common.HexToHash("0x2a")
+	balance, slot := call(big.NewInt(2), map[common.Address]web3.StateOverride{
+		to: {Balance: big.NewInt(5), StateDiff: map[common.Hash]common.Hash{{}: value}},
+	})
+	if balance.Int64() != 5 || slot != value {
+		t.Errorf("expected overridden balance 5 and slot %s but got %s and %s", value.Hex(), balance, slot.Hex())
+	}
+	// Overrides only apply to their call.
+	if balance, slot := call(big.NewInt(2), nil); balance.Int64() != 100 || slot != (common.Hash{}) {
+		t.Errorf("expected balance 100 and an empty slot but got %s and %s", balance, slot.Hex())
+	}
+}
+
+func TestGetBlockAndHeader_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	srv.Mine()
+
+	genesis, err := c.GetBlockByNumber(ctx, big.NewInt(0), false)
+	if err != nil {
+		t.Fatalf("failed to get genesis block: %v", err)
+	}
+	if genesis.Number.Sign() != 0 || genesis.ParentHash != (common.Hash{}) {
+		t.Errorf("expected genesis block without parent but got %+v", genesis)
+	}
+	byHash, err := c.GetBlockByHash(ctx, genesis.Hash.Hex(), false)
+	if err != nil || byHash.Hash != genesis.Hash {
+		t.Errorf("expected genesis block by hash but got %+v: %v", byHash, err)
+	}
+	for _, get := range []func() (*web3.Header, error){
+		func() (*web3.Header, error) { return c.GetHeaderByNumber(ctx, big.NewInt(0)) },
+		func() (*web3.Header, error) { return c.GetHeaderByHash(ctx, genesis.Hash.Hex()) },
+	} {
+		if h, err := get(); err != nil || h.Hash != genesis.Hash || h.Number.Sign() != 0 {
+			t.Errorf("expected genesis header but got %+v: %v", h, err)
+		}
+	}
+
+	unknown := common.HexToHash("0x01").Hex()
+	if _, err := c.GetBlockByHash(ctx, unknown, false); !errors.Is(err, web3.NotFoundErr) {
+		t.Errorf("expected %v for unknown hash but got: %v", web3.NotFoundErr, err)
+	}
+	if _, err := c.GetHeaderByHash(ctx, unknown); !errors.Is(err, web3.NotFoundErr) {
+		t.Errorf("expected %v for unknown hash but got: %v", web3.NotFoundErr, err)
+	}
+	if _, err := c.GetHeaderByNumber(ctx, big.NewInt(2)); !errors.Is(err, web3.NotFoundErr) {
+		t.Errorf("expected %v for future block but got: %v", web3.NotFoundErr, err)
+	}
+
+	// Malformed hashes never reach the node.
+	requests := c.Stats().Requests
+	for _, bad := range []string{"", "0x01", genesis.Hash.Hex() + "00"} {
+		if _, err := c.GetBlockByHash(ctx, bad, false); err == nil {
+			t.Errorf("expected error for hash %q", bad)
+		}
+		if _, err := c.GetHeaderByHash(ctx, bad); err == nil {
+			t.Errorf("expected error for hash %q", bad)
+		}
+	}
+	if n := c.Stats().Requests; n != requests {
+		t.Errorf("expected no requests for malformed hashes but got %d", n-requests)
+	}
+}
+
+func TestGetBlockRange_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	// Blocks 1 to 30, every third with two transfers.
+	var blocks [][]int64
+	for i := 1; i <= 30; i++ {
+		if i%3 == 0 {
+			blocks = append(blocks, []int64{1, 2})
+		} else {
+			blocks = append(blocks, nil)
+		}
+	}
+	seedGasPrices(t, srv, c, blocks...)
+	// Requests complete out of order.
+	srv.SetJitter("eth_getBlockByNumber", 5*time.Millisecond)
+	srv.SetJitter("eth_getTransactionReceipt", time.Millisecond)
+
+	results, err := web3.GetBlockRange(ctx, c, 2, 30, web3.BlockRangeOptions{Concurrency: 6, Receipts: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := uint64(2)
+	for r := range results {
+		if r.Err != nil {
+			t.Fatalf("unexpected error: %v", r.Err)
+		}
+		if r.Number != want || r.Block.Number.Uint64() != want {
+			t.Fatalf("expected block %d but got %d", want, r.Block.Number)
+		}
+		if len(r.Receipts) != len(r.Block.TxHashes) {
+			t.Fatalf("block %d: expected %d receipts but got %d", want, len(r.Block.TxHashes), len(r.Receipts))
+		}
+		for i, receipt := range r.Receipts {
+			if receipt.TxHash != r.Block.TxHashes[i] || receipt.BlockNumber != want {
+				t.Errorf("block %d: expected receipt of %s but got %+v", want, r.Block.TxHashes[i].Hex(), receipt)
+			}
+		}
+		want++
+	}
+	if want != 31 {
+		t.Errorf("expected blocks up to 30 but got up to %d", want-1)
+	}
+
+	// Past the head, it stops at the first missing block.
+	results, err = web3.GetBlockRange(ctx, c, 25, 40, web3.BlockRangeOptions{Concurrency: 4, Transactions: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var last web3.BlockResult
+	n := 0
+	for r := range results {
+		last = r
+		n++
+	}
+	if n != 7 || last.Number != 31 || !errors.Is(last.Err, web3.NotFoundErr) {
+		t.Errorf("expected blocks 25 to 30, then %v for 31, but got %d results ending with %d: %v", web3.NotFoundErr, n, last.Number, last.Err)
+	}
+
+	// Cancelled part way.
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results, err = web3.GetBlockRange(cctx, c, 0, 30, web3.BlockRangeOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	n = 0
+	for r := range results {
+		if n++; n == 3 {
+			cancel()
+		}
+		last = r
+	}
+	if !errors.Is(last.Err, context.Canceled) || n > 31 {
+		t.Errorf("expected %v after cancelling but got %d results ending with: %v", context.Canceled, n, last.Err)
+	}
+
+	for _, tt := range []struct {
+		from, to uint64
+		opts     web3.BlockRangeOptions
+	}{{from: 5, to: 4}, {from: 0, to: 4, opts: web3.BlockRangeOptions{Concurrency: -1}}} {
+		if _, err := web3.GetBlockRange(ctx, c, tt.from, tt.to, tt.opts); err == nil {
+			t.Errorf("%d to %d with %+v: expected error", tt.from, tt.to, tt.opts)
+		}
+	}
+}
+
+func BenchmarkGetBlockRange_server(b *testing.B) {
+	srv := web3test.NewServer()
+	defer srv.Close()
+	c, err := web3.Dial(srv.URL)
+	if err != nil {
+		b.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+	for i := 0; i < 100; i++ {
+		srv.Mine()
+	}
+	srv.SetLatency("eth_getBlockByNumber", time.Millisecond)
+	ctx := context.Background()
+
+	for _, concurrency := range []int{1, 8, 32} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				results, err := web3.GetBlockRange(ctx, c, 1, 100, web3.BlockRangeOptions{Concurrency: concurrency})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for r := range results {
+					if r.Err != nil {
+						b.Fatal(r.Err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSendRawTransaction_server(t *testing.T) {
+	srv, c := dialTestServer(t, web3.ClientOptions{})
+	ctx := context.Background()
+	srv.SetBalance(common.HexToAddress(devAddress), web3.Base(1))
+	tx, err := web3.BuildTransaction(ctx, c, devAddress, "0x0000000000000000000000000000000000000001", big.NewInt(5), nil, web3.TransactOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	signedHex, err := web3.SignTransactionHex(tx, devKey, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw := common.FromHex(signedHex)
+
+	if _, err := web3.SendRawTransaction(ctx, c, raw[:len(raw)-1]); err == nil {
+		t.Error("expected error for truncated transaction")
+	}
+	if n := srv.Requests("eth_sendRawTransaction"); n != 0 {
+		t.Errorf("expected invalid transaction not to be sent, but got %d requests", n)
+	}
+
+	hash, err := web3.SendRawTransaction(ctx, c, raw)
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	srv.Mine()
+	receipt, err := c.GetTransactionReceipt(ctx, hash)
+	if err != nil || receipt.Status != 1 {
+		t.Errorf("expected successful receipt for %s but got %+v: %v", hash.Hex(), receipt, err)
+	}
+
+	srv.SetError("eth_sendRawTransaction", errors.New("nonce too low"))
+	_, err = web3.SendRawTransaction(ctx, c, raw)
+	var rpcErr *web3.RPCError
+	if !errors.As(err, &rpcErr) || !strings.Contains(err.Error(), "nonce too low") {
+		t.Errorf("expected *RPCError with the node error but got: %v", err)
+	}
+}
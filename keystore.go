@@ -0,0 +1,118 @@
+package web3
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gochain/gochain/v3/accounts/keystore"
+	"github.com/gochain/gochain/v3/common"
+	"github.com/pborman/uuid"
+)
+
+const (
+	// StandardScryptN and StandardScryptP are the scrypt parameters used by geth for keystore files, using 256MB of
+	// memory and about a second of CPU.
+	StandardScryptN = keystore.StandardScryptN
+	StandardScryptP = keystore.StandardScryptP
+	// LightScryptN and LightScryptP use 4MB of memory and a fraction of a second of CPU.
+	LightScryptN = keystore.LightScryptN
+	LightScryptP = keystore.LightScryptP
+)
+
+// ErrWrongPassword is returned by ImportKeystore when the password doesn't decrypt the keystore.
+var ErrWrongPassword = keystore.ErrDecrypt
+
+// ErrInvalidKeystore is returned, wrapped, when a keystore file is malformed or unsupported.
+var ErrInvalidKeystore = errors.New("invalid keystore")
+
+// ImportKeystore decrypts an encrypted keystore file in the Web3 Secret Storage (version 3) format, as written by
+// geth. ErrWrongPassword is returned if the password is wrong, and an error wrapping ErrInvalidKeystore if the file
+// is malformed.
+func ImportKeystore(keyJSON []byte, password string) (*Account, error) {
+	key, _, err := KeyFromKeystore(keyJSON, password)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{key: key}, nil
+}
+
+// KeyFromKeystore is like ImportKeystore, but returns the private key and its address, like KeyFromHex.
+func KeyFromKeystore(keyJSON []byte, password string) (*ecdsa.PrivateKey, common.Address, error) {
+	address, err := checkKeystore(keyJSON)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err == keystore.ErrDecrypt {
+		return nil, common.Address{}, ErrWrongPassword
+	} else if err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if address != nil && *address != key.Address {
+		return nil, common.Address{}, fmt.Errorf("%w: address %s does not match key for %s", ErrInvalidKeystore, address.Hex(), key.Address.Hex())
+	}
+	return key.PrivateKey, key.Address, nil
+}
+
+// ExportKeystore encrypts acct's private key with password in the Web3 Secret Storage (version 3) format. Use
+// StandardScryptN and StandardScryptP unless the file is only for tests.
+func ExportKeystore(acct *Account, password string, scryptN, scryptP int) ([]byte, error) {
+	return keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.NewRandom(),
+		Address:    acct.Address(),
+		PrivateKey: acct.Key(),
+	}, password, scryptN, scryptP)
+}
+
+// checkKeystore checks the fields of a version 3 keystore which keystore.DecryptKey assumes are valid, and returns
+// its address, if set.
+func checkKeystore(keyJSON []byte) (*common.Address, error) {
+	var k struct {
+		Address string `json:"address"`
+		Version int    `json:"version"`
+		Crypto  struct {
+			KDF       string                 `json:"kdf"`
+			KDFParams map[string]interface{} `json:"kdfparams"`
+		} `json:"crypto"`
+	}
+	if err := json.Unmarshal(keyJSON, &k); err != nil {
+		return nil, err
+	}
+	if k.Version != 3 {
+		return nil, fmt.Errorf("unsupported version %d", k.Version)
+	}
+	params := k.Crypto.KDFParams
+	if _, ok := params["salt"].(string); !ok {
+		return nil, errors.New("missing kdf salt")
+	}
+	if dkLen, ok := params["dklen"].(float64); !ok || dkLen < 32 {
+		return nil, fmt.Errorf("invalid kdf dklen: %v", params["dklen"])
+	}
+	var ints []string
+	switch k.Crypto.KDF {
+	case "scrypt":
+		ints = []string{"n", "r", "p"}
+	case "pbkdf2":
+		if _, ok := params["prf"].(string); !ok {
+			return nil, errors.New("missing kdf prf")
+		}
+		ints = []string{"c"}
+	default:
+		return nil, fmt.Errorf("unsupported kdf %q", k.Crypto.KDF)
+	}
+	for _, name := range ints {
+		if v, ok := params[name].(float64); !ok || v < 1 {
+			return nil, fmt.Errorf("invalid kdf %s: %v", name, params[name])
+		}
+	}
+	if k.Address == "" {
+		return nil, nil
+	}
+	address, err := parseAddress(k.Address)
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
@@ -0,0 +1,80 @@
+package web3
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+// gethKeystore was written by geth, with password "foobar".
+const gethKeystore = `{"address":"f466859ead1932d743d622cb74fc058882e8648a","crypto":{"cipher":"aes-128-ctr","ciphertext":"cb664472deacb41a2e995fa7f96fe29ce744471deb8d146a0e43c7898c9ddd4d","cipherparams":{"iv":"dfd9ee70812add5f4b8f89d0811c9158"},"kdf":"scrypt","kdfparams":{"dklen":32,"n":8,"p":16,"r":8,"salt":"0d6769bf016d45c479213990d6a08d938469c4adad8a02ce507b4a4e7b7739f1"},"mac":"bac9af994b15a45dd39669fc66f9aa8a3b9dd8c22cb16e4d8d7ea089d0f1a1a9"},"id":"472e8b3d-afb6-45b5-8111-72c89895099a","version":3}`
+
+func TestImportKeystore(t *testing.T) {
+	acct, err := ImportKeystore([]byte(gethKeystore), "foobar")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := common.HexToAddress("0xf466859ead1932d743d622cb74fc058882e8648a"); acct.Address() != want {
+		t.Errorf("expected address %s but got %s", want.Hex(), acct.Address().Hex())
+	}
+
+	if _, err := ImportKeystore([]byte(gethKeystore), "wrong"); err != ErrWrongPassword {
+		t.Errorf("expected %v but got: %v", ErrWrongPassword, err)
+	}
+
+	for name, keyJSON := range map[string]string{
+		"garbage":          "not json",
+		"version":          `{"version":1}`,
+		"missing-kdf":      `{"version":3,"crypto":{"cipher":"aes-128-ctr"}}`,
+		"missing-n":        `{"version":3,"crypto":{"kdf":"scrypt","kdfparams":{"dklen":32,"p":1,"r":8,"salt":"00"}}}`,
+		"short-dklen":      `{"version":3,"crypto":{"kdf":"scrypt","kdfparams":{"dklen":16,"n":2,"p":1,"r":8,"salt":"00"}}}`,
+		"address-mismatch": strings.Replace(gethKeystore, "f466859e", "0466859e", 1),
+	} {
+		if _, err := ImportKeystore([]byte(keyJSON), "foobar"); !errors.Is(err, ErrInvalidKeystore) {
+			t.Errorf("%s: expected %v but got: %v", name, ErrInvalidKeystore, err)
+		}
+	}
+}
+
+func TestKeyFromKeystore(t *testing.T) {
+	key, addr, err := KeyFromKeystore([]byte(gethKeystore), "foobar")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := common.HexToAddress("0xf466859ead1932d743d622cb74fc058882e8648a"); addr != want {
+		t.Errorf("expected address %s but got %s", want.Hex(), addr.Hex())
+	}
+	if got := crypto.PubkeyToAddress(key.PublicKey); got != addr {
+		t.Errorf("expected key for %s but got %s", addr.Hex(), got.Hex())
+	}
+	if _, _, err := KeyFromKeystore([]byte(gethKeystore), ""); err != ErrWrongPassword {
+		t.Errorf("expected %v but got: %v", ErrWrongPassword, err)
+	}
+	if _, _, err := KeyFromKeystore([]byte(gethKeystore[1:]), "foobar"); !errors.Is(err, ErrInvalidKeystore) {
+		t.Errorf("expected %v but got: %v", ErrInvalidKeystore, err)
+	}
+}
+
+func TestExportKeystore(t *testing.T) {
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyJSON, err := ExportKeystore(acct, "secret", LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := ImportKeystore(keyJSON, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.PrivateKey() != acct.PrivateKey() {
+		t.Errorf("expected key for %s to round trip but got %s", acct.Address().Hex(), got.Address().Hex())
+	}
+	if _, err := ImportKeystore(keyJSON, "Secret"); err != ErrWrongPassword {
+		t.Errorf("expected %v but got: %v", ErrWrongPassword, err)
+	}
+}
@@ -0,0 +1,80 @@
+package web3
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+// ErrInvalidSignature is returned, wrapped, when a message signature is malformed.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// MessageHash returns the EIP-191 hash of message, as signed by personal_sign: the keccak256 of the message with the
+// "\x19Ethereum Signed Message:\n" prefix and its length.
+func MessageHash(message []byte) common.Hash {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return crypto.Keccak256Hash([]byte(prefix), message)
+}
+
+// SignMessage signs message with the private key, like personal_sign and MetaMask. The 65 byte signature is r, s, and
+// v, with v as 27 or 28.
+func SignMessage(privateKeyHex string, message []byte) ([]byte, error) {
+	key, _, err := KeyFromHex(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return signHash(key, MessageHash(message))
+}
+
+// signHash signs hash with key, returning r, s, and v with v as 27 or 28.
+func signHash(key *ecdsa.PrivateKey, hash common.Hash) ([]byte, error) {
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// RecoverSigner returns the address which signed message, from a 65 byte signature as returned by SignMessage or
+// personal_sign. v may be 0 or 1, or 27 or 28.
+func RecoverSigner(message, signature []byte) (common.Address, error) {
+	return recoverHashSigner(MessageHash(message), signature)
+}
+
+// recoverHashSigner returns the address which signed hash, from a 65 byte signature with v as 0 or 1, or 27 or 28.
+func recoverHashSigner(hash common.Hash, signature []byte) (common.Address, error) {
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("%w: expected 65 bytes but got %d", ErrInvalidSignature, len(signature))
+	}
+	sig := make([]byte, 65)
+	copy(sig, signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return common.Address{}, fmt.Errorf("%w: invalid v %d", ErrInvalidSignature, signature[64])
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// VerifySignature reports whether message was signed by address. An error is returned for an invalid address or
+// malformed signature.
+func VerifySignature(address string, message, signature []byte) (bool, error) {
+	addr, err := parseAddress(address)
+	if err != nil {
+		return false, err
+	}
+	signer, err := RecoverSigner(message, signature)
+	if err != nil {
+		return false, err
+	}
+	return signer == addr, nil
+}
@@ -0,0 +1,74 @@
+package web3
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gochain/gochain/v3/accounts"
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+func TestSignMessage(t *testing.T) {
+	// Well known development key and address.
+	const key = "0x8f2a55949038a9610f50fb23b5883af3b4ecb3c3bb792cbcefbd1542c692be63"
+	const addr = "0xFE3B557E8Fb62b89F4916B721be55cEb828dBd73"
+	msg := []byte("Sign in to example.com")
+
+	sig, err := SignMessage(key, msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		t.Fatalf("expected 65 byte signature with v 27 or 28 but got %x", sig)
+	}
+	if got, err := RecoverSigner(msg, sig); err != nil || got != common.HexToAddress(addr) {
+		t.Errorf("expected signer %s but got %s: %v", addr, got.Hex(), err)
+	}
+	// v as 0 or 1.
+	raw := append([]byte(nil), sig...)
+	raw[64] -= 27
+	if ok, err := VerifySignature(addr, msg, raw); err != nil || !ok {
+		t.Errorf("expected signature with v %d to verify: %v", raw[64], err)
+	}
+	if ok, err := VerifySignature(addr, []byte("Sign in to evil.com"), sig); err != nil || ok {
+		t.Errorf("expected signature of another message not to verify: %v", err)
+	}
+
+	if _, err := RecoverSigner(msg, sig[:64]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected %v but got: %v", ErrInvalidSignature, err)
+	}
+	bad := append([]byte(nil), sig...)
+	bad[64] = 29
+	if _, err := RecoverSigner(msg, bad); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected %v but got: %v", ErrInvalidSignature, err)
+	}
+	if _, err := VerifySignature("0x1234", msg, sig); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("expected %v but got: %v", ErrInvalidAddress, err)
+	}
+}
+
+func TestMessageHash(t *testing.T) {
+	// Test vector from geth's accounts.TextHash.
+	want := common.HexToHash("0xa080337ae51c4e064c189e113edd0ba391df9206e2f49db658bb32cf2911730b")
+	if got := MessageHash([]byte("Hello Joe")); got != want {
+		t.Errorf("expected %s but got %s", want.Hex(), got.Hex())
+	}
+
+	// Signatures match geth's personal_sign, which signs accounts.TextHash and adds 27 to v.
+	const key = "0x8f2a55949038a9610f50fb23b5883af3b4ecb3c3bb792cbcefbd1542c692be63"
+	pk, _, err := KeyFromHex(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("Hello Joe")
+	want65, err := crypto.Sign(accounts.TextHash(msg), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want65[64] += 27
+	if got, err := SignMessage(key, msg); err != nil || !bytes.Equal(got, want65) {
+		t.Errorf("expected signature %x but got %x: %v", want65, got, err)
+	}
+}
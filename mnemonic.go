@@ -0,0 +1,161 @@
+package web3
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/math"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultDerivationPath is the BIP-44 path of the first Ethereum account, as used by MetaMask and most wallets.
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+var (
+	// ErrInvalidMnemonic is returned, wrapped, when a mnemonic has the wrong number of words, or an unknown word.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	// ErrMnemonicChecksum is returned when a mnemonic's words are valid, but its checksum is not, ie: a mistyped
+	// or reordered word.
+	ErrMnemonicChecksum = errors.New("invalid mnemonic checksum")
+)
+
+// GenerateMnemonic returns a new random BIP-39 mnemonic with bits of entropy, from crypto/rand. bits must be a
+// multiple of 32 between 128 and 256, ie: 128 for 12 words, or 256 for 24.
+func GenerateMnemonic(bits int) (string, error) {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid mnemonic entropy bits %d: must be a multiple of 32 from 128 to 256", bits)
+	}
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// AccountFromMnemonic derives the account at derivationPath (DefaultDerivationPath if empty) from a BIP-39 mnemonic
+// and optional passphrase, per BIP-32. The same account is derived by MetaMask and other wallets.
+func AccountFromMnemonic(mnemonic, passphrase, derivationPath string) (*Account, error) {
+	if derivationPath == "" {
+		derivationPath = DefaultDerivationPath
+	}
+	path, err := parseDerivationPath(derivationPath)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, err = checkMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(bip39.NewSeed(mnemonic, passphrase))
+	sum := mac.Sum(nil)
+	key, err := crypto.ToECDSA(sum[:32])
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %v", err)
+	}
+	chainCode := sum[32:]
+	for _, index := range path {
+		key, chainCode, err = deriveChild(key, chainCode, index)
+		if err != nil {
+			return nil, fmt.Errorf("cannot derive %s: %v", derivationPath, err)
+		}
+	}
+	return &Account{key: key}, nil
+}
+
+// KeyFromMnemonic is like AccountFromMnemonic without a passphrase, but returns the private key and its address,
+// like KeyFromHex. Use DerivationPath for the path of other accounts.
+func KeyFromMnemonic(mnemonic, path string) (*ecdsa.PrivateKey, common.Address, error) {
+	acct, err := AccountFromMnemonic(mnemonic, "", path)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return acct.Key(), acct.Address(), nil
+}
+
+// DerivationPath returns the BIP-44 path of the Ethereum account with index, as numbered by MetaMask from 0.
+func DerivationPath(index uint32) string {
+	return fmt.Sprintf("m/44'/60'/0'/0/%d", index)
+}
+
+// checkMnemonic returns mnemonic normalized to single spaced lower case words, or an error if it is invalid.
+func checkMnemonic(mnemonic string) (string, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if n := len(words); n < 12 || n > 24 || n%3 != 0 {
+		return "", fmt.Errorf("%w: expected 12, 15, 18, 21 or 24 words but got %d", ErrInvalidMnemonic, n)
+	}
+	for i, w := range words {
+		if _, ok := bip39.GetWordIndex(w); !ok {
+			return "", fmt.Errorf("%w: unknown word %d %q", ErrInvalidMnemonic, i+1, w)
+		}
+	}
+	mnemonic = strings.Join(words, " ")
+	if _, err := bip39.EntropyFromMnemonic(mnemonic); err == bip39.ErrChecksumIncorrect {
+		return "", ErrMnemonicChecksum
+	} else if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	return mnemonic, nil
+}
+
+// hardened is the offset of hardened BIP-32 child indexes, written with a ' suffix in paths.
+const hardened = 1 << 31
+
+// parseDerivationPath parses a BIP-32 path, like "m/44'/60'/0'/0/0", into child indexes.
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m/", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h") || strings.HasSuffix(p, "H") {
+			offset = hardened
+			p = p[:len(p)-1]
+		}
+		i, err := strconv.ParseUint(p, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: bad index %q", path, p)
+		}
+		indexes = append(indexes, uint32(i)+offset)
+	}
+	return indexes, nil
+}
+
+// deriveChild returns the BIP-32 child private key and chain code at index.
+func deriveChild(key *ecdsa.PrivateKey, chainCode []byte, index uint32) (*ecdsa.PrivateKey, []byte, error) {
+	var data []byte
+	if index >= hardened {
+		data = append([]byte{0}, math.PaddedBigBytes(key.D, 32)...)
+	} else {
+		data = crypto.CompressPubkey(&key.PublicKey)
+	}
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	d := il.Add(il, key.D)
+	d.Mod(d, n)
+	child, err := crypto.ToECDSA(math.PaddedBigBytes(d, 32))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid child key at index %d: %v", index, err)
+	}
+	return child, sum[32:], nil
+}
@@ -0,0 +1,118 @@
+package web3
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+func TestAccountFromMnemonic(t *testing.T) {
+	const abandon = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	// The default development mnemonic of hardhat and anvil, whose accounts MetaMask derives too.
+	const junk = "test test test test test test test test test test test junk"
+	for _, tt := range []struct {
+		name, mnemonic, passphrase, path string
+		want                             string
+	}{
+		{name: "default-path", mnemonic: abandon, want: "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+		{name: "metamask-0", mnemonic: junk, path: "m/44'/60'/0'/0/0", want: "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{name: "metamask-1", mnemonic: junk, path: "m/44'/60'/0'/0/1", want: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+		{name: "normalized", mnemonic: "  Test test TEST test test test test test test test test\tjunk\n", want: "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			acct, err := AccountFromMnemonic(tt.mnemonic, tt.passphrase, tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := acct.Address().Hex(); got != tt.want {
+				t.Errorf("expected %s but got %s", tt.want, got)
+			}
+		})
+	}
+
+	withPassphrase, err := AccountFromMnemonic(junk, "secret", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if withPassphrase.Address().Hex() == "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266" {
+		t.Error("expected passphrase to derive a different account")
+	}
+}
+
+func TestKeyFromMnemonic(t *testing.T) {
+	const junk = "test test test test test test test test test test test junk"
+	for i, want := range []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+	} {
+		key, addr, err := KeyFromMnemonic(junk, DerivationPath(uint32(i)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if addr.Hex() != want {
+			t.Errorf("expected account %d to be %s but got %s", i, want, addr.Hex())
+		}
+		if got := crypto.PubkeyToAddress(key.PublicKey); got != addr {
+			t.Errorf("expected key for %s but got %s", addr.Hex(), got.Hex())
+		}
+	}
+	if _, _, err := KeyFromMnemonic(strings.Repeat("abandon ", 12), ""); err != ErrMnemonicChecksum {
+		t.Errorf("expected %v but got: %v", ErrMnemonicChecksum, err)
+	}
+}
+
+func TestAccountFromMnemonic_invalid(t *testing.T) {
+	valid := strings.Repeat("abandon ", 11) + "about"
+	for _, tt := range []struct {
+		name, mnemonic, path string
+		wantErr              error
+	}{
+		{name: "checksum", mnemonic: strings.Repeat("abandon ", 12), wantErr: ErrMnemonicChecksum},
+		{name: "unknown-word", mnemonic: strings.Repeat("abandon ", 11) + "aboot", wantErr: ErrInvalidMnemonic},
+		{name: "length", mnemonic: strings.Repeat("abandon ", 11), wantErr: ErrInvalidMnemonic},
+		{name: "path", mnemonic: valid, path: "44'/60'/0'/0/0"},
+		{name: "path-index", mnemonic: valid, path: "m/44'/x/0"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := AccountFromMnemonic(tt.mnemonic, "", tt.path)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v but got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateMnemonic(t *testing.T) {
+	for bits, words := range map[int]int{128: 12, 160: 15, 256: 24} {
+		m, err := GenerateMnemonic(bits)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n := len(strings.Fields(m)); n != words {
+			t.Errorf("expected %d words for %d bits but got %d", words, bits, n)
+		}
+		if _, err := AccountFromMnemonic(m, "", ""); err != nil {
+			t.Errorf("unexpected error for generated mnemonic: %v", err)
+		}
+	}
+	if _, err := GenerateMnemonic(100); err == nil {
+		t.Error("expected error for 100 bits")
+	}
+}
+
+func TestMnemonicSeed(t *testing.T) {
+	// Test vector from BIP-39.
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	const want = "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if got := hex.EncodeToString(bip39.NewSeed(mnemonic, "TREZOR")); got != want {
+		t.Errorf("expected seed %s but got %s", want, got)
+	}
+}
@@ -1,5 +1,12 @@
 package web3
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
 const (
 	testnetExplorerURL = "https://testnet-explorer.gochain.io/api"
 	mainnetExplorerURL = "https://explorer.gochain.io/api"
@@ -21,13 +28,15 @@ var Networks = map[string]Network{
 		ExplorerURL: mainnetExplorerURL,
 	},
 	"localhost": {
-		Name: "localhost",
-		URL:  "http://localhost:8545",
-		Unit: "GO",
+		Name:         "localhost",
+		URL:          "http://localhost:8545",
+		WebsocketURL: "ws://localhost:8546",
+		Unit:         "GO",
 	},
 	"ethereum": {
-		Name: "ethereum",
-		URL:  "https://mainnet.infura.io/v3/bc5b0e5cfd9b4385befb69a68a9400c3",
+		Name:         "ethereum",
+		URL:          "https://mainnet.infura.io/v3/bc5b0e5cfd9b4385befb69a68a9400c3",
+		WebsocketURL: "wss://mainnet.infura.io/ws/v3/bc5b0e5cfd9b4385befb69a68a9400c3",
 		// URL: "https://cloudflare-eth.com", // these don't worry very well, constant problems
 		// URL: "https://main-rpc.linkpool.io",
 		Unit:        "ETH",
@@ -45,4 +54,65 @@ type Network struct {
 	URL         string
 	ExplorerURL string
 	Unit        string
+
+	// WebsocketURL is the websocket endpoint, required for subscriptions, if the network has a known one.
+	WebsocketURL string
+}
+
+var (
+	registeredMu sync.RWMutex
+	registered   = map[string]string{}
+)
+
+// RegisterNetwork registers url for the network name, overriding the URL of any built-in network with that name.
+func RegisterNetwork(name, url string) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registered[name] = url
+}
+
+// NetworkURL returns the URL for the network name, checking registered networks before the built-in Networks.
+func NetworkURL(name string) (string, bool) {
+	url, err := NetworkURLErr(name)
+	return url, err == nil
+}
+
+// NetworkURLErr is like NetworkURL, but returns an error listing the known networks if name is unknown.
+func NetworkURLErr(name string) (string, error) {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	if url, ok := registered[name]; ok {
+		return url, nil
+	}
+	if n, ok := Networks[name]; ok {
+		return n.URL, nil
+	}
+	known := make([]string, 0, len(Networks)+len(registered))
+	for n := range Networks {
+		known = append(known, n)
+	}
+	for n := range registered {
+		if _, ok := Networks[n]; !ok {
+			known = append(known, n)
+		}
+	}
+	sort.Strings(known)
+	return "", fmt.Errorf("unknown network %q, known networks: %s", name, strings.Join(known, ", "))
+}
+
+// NetworkWebsocketURL returns the websocket URL for the network name, if it has one. A network registered with a
+// ws or wss URL uses it, and otherwise the built-in network's is used. The GoChain mainnet and testnet have no known
+// public websocket endpoint, so they only have one if registered.
+func NetworkWebsocketURL(name string) (string, bool) {
+	registeredMu.RLock()
+	url, ok := registered[name]
+	registeredMu.RUnlock()
+	if ok && (strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://")) {
+		return url, true
+	}
+	n, ok := Networks[name]
+	if !ok || n.WebsocketURL == "" {
+		return "", false
+	}
+	return n.WebsocketURL, true
 }
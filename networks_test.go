@@ -0,0 +1,73 @@
+package web3
+
+import "testing"
+
+func TestRegisterNetwork(t *testing.T) {
+	t.Cleanup(func() {
+		registeredMu.Lock()
+		delete(registered, "consortium")
+		delete(registered, "gochain")
+		registeredMu.Unlock()
+	})
+
+	if _, ok := NetworkURL("consortium"); ok {
+		t.Fatal("expected unregistered network to be unknown")
+	}
+	if url, ok := NetworkURL("gochain"); !ok || url != mainnetURL {
+		t.Errorf("expected built-in url %q but got %q", mainnetURL, url)
+	}
+
+	RegisterNetwork("consortium", "http://10.0.0.1:8545")
+	RegisterNetwork("gochain", "http://10.0.0.2:8545")
+	if url, ok := NetworkURL("consortium"); !ok || url != "http://10.0.0.1:8545" {
+		t.Errorf("expected registered url but got %q", url)
+	}
+	if url, ok := NetworkURL("gochain"); !ok || url != "http://10.0.0.2:8545" {
+		t.Errorf("expected override url but got %q", url)
+	}
+	if url, ok := NetworkURL("testnet"); !ok || url != testnetURL {
+		t.Errorf("expected built-in url %q but got %q", testnetURL, url)
+	}
+}
+
+func TestNetworkURLErr(t *testing.T) {
+	if url, err := NetworkURLErr("testnet"); err != nil || url != testnetURL {
+		t.Errorf("expected %q but got %q: %v", testnetURL, url, err)
+	}
+	_, err := NetworkURLErr("bogus")
+	const want = `unknown network "bogus", known networks: ethereum, gochain, localhost, ropsten, testnet`
+	if err == nil || err.Error() != want {
+		t.Errorf("expected error %q but got: %v", want, err)
+	}
+}
+
+func TestNetworkWebsocketURL(t *testing.T) {
+	if url, ok := NetworkWebsocketURL("localhost"); !ok || url != "ws://localhost:8546" {
+		t.Errorf("expected localhost websocket url but got %q", url)
+	}
+	if url, ok := NetworkWebsocketURL("ropsten"); ok {
+		t.Errorf("expected no websocket url but got %q", url)
+	}
+	if _, ok := NetworkWebsocketURL("bogus"); ok {
+		t.Error("expected unknown network to have no websocket url")
+	}
+
+	t.Cleanup(func() {
+		registeredMu.Lock()
+		delete(registered, "gochain")
+		delete(registered, "localhost")
+		registeredMu.Unlock()
+	})
+	RegisterNetwork("gochain", "wss://10.0.0.2:8546")
+	RegisterNetwork("localhost", "http://127.0.0.1:9545")
+	if url, ok := NetworkWebsocketURL("gochain"); !ok || url != "wss://10.0.0.2:8546" {
+		t.Errorf("expected registered websocket url but got %q", url)
+	}
+	if url, ok := NetworkWebsocketURL("localhost"); !ok || url != "ws://localhost:8546" {
+		t.Errorf("expected built-in websocket url for a network registered over http but got %q", url)
+	}
+	RegisterNetwork("gochain", "https://10.0.0.2:8545")
+	if url, ok := NetworkWebsocketURL("gochain"); ok || url != "" {
+		t.Errorf("expected no websocket url for a network registered over http but got %q", url)
+	}
+}
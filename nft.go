@@ -0,0 +1,97 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gochain/gochain/v3/accounts/abi"
+	"github.com/gochain/gochain/v3/common"
+)
+
+// ErrNotERC721 is returned when a contract does not report support for the ERC721 interface.
+var ErrNotERC721 = errors.New("contract does not implement ERC721")
+
+// erc721InterfaceID is the ERC165 interface id of ERC721.
+var erc721InterfaceID = [4]byte{0x80, 0xac, 0x58, 0xcd}
+
+// erc721StdABI is the subset of the final ERC721 standard used here. The bundled assets.ERC721ABI is from an
+// earlier draft, without ERC165 or safeTransferFrom.
+const erc721StdABI = `[
+{"constant":true,"inputs":[{"name":"interfaceId","type":"bytes4"}],"name":"supportsInterface","outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"},
+{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"constant":true,"inputs":[{"name":"tokenId","type":"uint256"}],"name":"ownerOf","outputs":[{"name":"","type":"address"}],"stateMutability":"view","type":"function"},
+{"constant":true,"inputs":[{"name":"tokenId","type":"uint256"}],"name":"tokenURI","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+{"constant":false,"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"}
+]`
+
+var erc721ABI = func() abi.ABI {
+	myabi, err := abi.JSON(strings.NewReader(erc721StdABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid ERC721 ABI: %v", err))
+	}
+	return myabi
+}()
+
+// NFTOwnerOf returns the owner of tokenID of the ERC721 contract.
+func NFTOwnerOf(ctx context.Context, client Client, contract string, tokenID *big.Int) (common.Address, error) {
+	out, err := nftCall(ctx, client, contract, "ownerOf", tokenID)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return out.(common.Address), nil
+}
+
+// NFTTokenURI returns the metadata URI of tokenID of the ERC721 contract.
+func NFTTokenURI(ctx context.Context, client Client, contract string, tokenID *big.Int) (string, error) {
+	out, err := nftCall(ctx, client, contract, "tokenURI", tokenID)
+	if err != nil {
+		return "", err
+	}
+	return out.(string), nil
+}
+
+// NFTBalanceOf returns the number of tokens of the ERC721 contract held by owner.
+func NFTBalanceOf(ctx context.Context, client Client, contract, owner string) (*big.Int, error) {
+	out, err := nftCall(ctx, client, contract, "balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*big.Int), nil
+}
+
+// NFTSafeTransferFrom transfers tokenID of the ERC721 contract from the account of privateKeyHex to to, using
+// safeTransferFrom. If the transfer would revert, for example because to is a contract which does not
+// implement onERC721Received, the error wraps a *RevertError with the reason when gas is being estimated.
+func NFTSafeTransferFrom(ctx context.Context, client Client, privateKeyHex, contract, to string, tokenID *big.Int, opts TransactOptions) (*Transaction, error) {
+	_, from, err := KeyFromHex(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkERC721(ctx, client, contract); err != nil {
+		return nil, err
+	}
+	return SendContractTransaction(ctx, client, privateKeyHex, erc721StdABI, contract, "safeTransferFrom", nil, opts, from.Hex(), to, tokenID)
+}
+
+// nftCall calls an ERC721 method with a single output, after checking that the contract implements ERC721.
+func nftCall(ctx context.Context, client Client, contract, method string, params ...interface{}) (interface{}, error) {
+	if err := checkERC721(ctx, client, contract); err != nil {
+		return nil, err
+	}
+	return contractCall(ctx, client, erc721ABI, ErrNotERC721, contract, method, params...)
+}
+
+// checkERC721 returns ErrNotERC721 unless the contract reports support for ERC721 via ERC165.
+func checkERC721(ctx context.Context, client Client, contract string) error {
+	out, err := contractCall(ctx, client, erc721ABI, ErrNotERC721, contract, "supportsInterface", erc721InterfaceID)
+	if err != nil {
+		return err
+	}
+	if supported, _ := out.(bool); !supported {
+		return fmt.Errorf("%s: %w", contract, ErrNotERC721)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package web3_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+// fakeNFT returns a call handler implementing the read-only ERC721 methods for the given owners.
+// If erc721 is false, supportsInterface reports no support.
+func fakeNFT(erc721 bool, owners map[int64]common.Address) func(web3.CallMsg) ([]byte, error) {
+	return func(msg web3.CallMsg) ([]byte, error) {
+		fn, err := web3.ERC721ABI.MethodById(msg.Data)
+		if err != nil {
+			return nil, err
+		}
+		args, err := fn.Inputs.UnpackValues(msg.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		switch fn.Name {
+		case "supportsInterface":
+			return fn.Outputs.Pack(erc721 && args[0].([4]byte) == web3.ERC721InterfaceID)
+		case "ownerOf":
+			return fn.Outputs.Pack(owners[args[0].(*big.Int).Int64()])
+		case "tokenURI":
+			return fn.Outputs.Pack(fmt.Sprintf("ipfs://token/%s", args[0]))
+		case "balanceOf":
+			var n int64
+			for _, owner := range owners {
+				if owner == args[0].(common.Address) {
+					n++
+				}
+			}
+			return fn.Outputs.Pack(big.NewInt(n))
+		}
+		return nil, fmt.Errorf("unsupported method %s", fn.Name)
+	}
+}
+
+func TestNFTCalls(t *testing.T) {
+	const contract = "0x0000000000000000000000000000000000000001"
+	owner := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetCode(common.HexToAddress(contract), []byte{0x60, 0x80})
+	c.SetCallFunc(fakeNFT(true, map[int64]common.Address{1: owner, 2: owner}))
+
+	if got, err := web3.NFTOwnerOf(ctx, c, contract, big.NewInt(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got != owner {
+		t.Errorf("expected owner %s but got %s", owner.Hex(), got.Hex())
+	}
+	if got, err := web3.NFTTokenURI(ctx, c, contract, big.NewInt(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got != "ipfs://token/2" {
+		t.Errorf("expected uri ipfs://token/2 but got %q", got)
+	}
+	if got, err := web3.NFTBalanceOf(ctx, c, contract, owner.Hex()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if got.Int64() != 2 {
+		t.Errorf("expected balance 2 but got %s", got)
+	}
+
+	c.SetCallFunc(fakeNFT(false, nil))
+	if _, err := web3.NFTOwnerOf(ctx, c, contract, big.NewInt(1)); !errors.Is(err, web3.ErrNotERC721) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotERC721, err)
+	}
+	// Calls to accounts without code return no data.
+	c.SetCallFunc(noData)
+	if _, err := web3.NFTOwnerOf(ctx, c, "0x0000000000000000000000000000000000000002", big.NewInt(1)); !errors.Is(err, web3.ErrNotContract) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotContract, err)
+	}
+}
+
+func TestNFTSafeTransferFrom(t *testing.T) {
+	const contract, to = "0x0000000000000000000000000000000000000001", "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetCode(common.HexToAddress(contract), []byte{0x60, 0x80})
+	c.SetCallFunc(fakeNFT(true, nil))
+	c.SetGasEstimate(80000)
+	c.SetChainID(big.NewInt(60))
+	key := devKey
+	from := common.HexToAddress(devAddress)
+
+	if _, err := web3.NFTSafeTransferFrom(ctx, c, key, contract, to, big.NewInt(3), web3.TransactOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fn, err := web3.ERC721ABI.MethodById(c.Sent()[0].Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := fn.Inputs.UnpackValues(c.Sent()[0].Data()[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fn.Name != "safeTransferFrom" || args[0] != from || args[1] != common.HexToAddress(to) || args[2].(*big.Int).Int64() != 3 {
+		t.Errorf("expected safeTransferFrom(%s, %s, 3) but got %s%v", from.Hex(), to, fn.Name, args)
+	}
+
+	const reason = "ERC721: transfer to non ERC721Receiver implementer"
+	c.SetError("EstimateGas", &web3.RevertError{Reason: reason})
+	_, err = web3.NFTSafeTransferFrom(ctx, c, key, contract, to, big.NewInt(3), web3.TransactOptions{})
+	var revertErr *web3.RevertError
+	if !errors.As(err, &revertErr) || revertErr.Reason != reason {
+		t.Errorf("expected revert reason %q but got: %v", reason, err)
+	}
+
+	c.SetCallFunc(fakeNFT(false, nil))
+	if _, err := web3.NFTSafeTransferFrom(ctx, c, key, contract, to, big.NewInt(3), web3.TransactOptions{}); !errors.Is(err, web3.ErrNotERC721) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotERC721, err)
+	}
+	if sent := c.Sent(); len(sent) != 1 {
+		t.Errorf("expected only 1 sent tx, got %d", len(sent))
+	}
+}
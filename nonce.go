@@ -0,0 +1,194 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gochain/gochain/v3/common"
+)
+
+// NonceSource hands out nonces for transactions from an account, ie: a NonceManager. Set it in TransactOptions,
+// DeployOptions or TransferOptions to use it instead of the node's pending nonce.
+type NonceSource interface {
+	// Next reserves and returns the next nonce of account.
+	Next(ctx context.Context, account common.Address) (uint64, error)
+	// Release returns a reserved nonce which was not used, because the transaction failed before it was sent.
+	Release(account common.Address, nonce uint64)
+}
+
+// NonceManager is a Client which tracks the next nonce of each sending address locally, so concurrent
+// transactions from the same account don't all use the pending nonce reported by the node. The first nonce of an
+// address is fetched from the node, and each call to GetPendingTransactionCount or GetPendingNonce after that
+// reserves the next one. Nonces read any other way, ie: with RawCall, bypass it. Pass it as the client to
+// DeployContract, Transfer, etc., or as the NonceSource in their options. It is safe for concurrent use, and
+// fetching the nonce of one account doesn't block the others.
+//
+// If the node rejects a transaction's nonce, ie: as "nonce too low", the sender's nonce is fetched from the node again
+// next time. Other send failures, which may be transient or leave the transaction broadcast anyway, are left to the
+// caller. Call Release or Reset if a transaction is abandoned after taking a nonce, ie: when gas estimation fails,
+// to avoid leaving a gap. The send functions do this themselves, whether it is their client or their NonceSource.
+type NonceManager struct {
+	Client
+
+	mu     sync.Mutex // guards nonces, but not their contents
+	nonces map[common.Address]*accountNonces
+}
+
+// accountNonces are the nonces of an account tracked by a NonceManager.
+type accountNonces struct {
+	mu       sync.Mutex
+	fetched  bool
+	next     uint64
+	released []uint64 // below next, sorted
+}
+
+// NewNonceManager returns a NonceManager sending through client.
+func NewNonceManager(client Client) *NonceManager {
+	return &NonceManager{Client: client, nonces: make(map[common.Address]*accountNonces)}
+}
+
+// GetPendingTransactionCount reserves and returns the next nonce of account.
+func (m *NonceManager) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	return m.Next(ctx, account)
+}
+
+// GetPendingNonce reserves and returns the next nonce of address.
+func (m *NonceManager) GetPendingNonce(ctx context.Context, address string) (uint64, error) {
+	account, err := parseAddress(address)
+	if err != nil {
+		return 0, err
+	}
+	return m.Next(ctx, account)
+}
+
+// account returns the nonces of account, adding them if missing.
+func (m *NonceManager) account(account common.Address) *accountNonces {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.nonces[account]
+	if !ok {
+		n = &accountNonces{}
+		m.nonces[account] = n
+	}
+	return n
+}
+
+// Next reserves and returns the next nonce of account, reusing the lowest released nonce first.
+func (m *NonceManager) Next(ctx context.Context, account common.Address) (uint64, error) {
+	n := m.account(account)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.fetched {
+		next, err := m.Client.GetPendingTransactionCount(ctx, account)
+		if err != nil {
+			return 0, err
+		}
+		n.next, n.fetched = next, true
+	}
+	if len(n.released) > 0 {
+		nonce := n.released[0]
+		n.released = n.released[1:]
+		return nonce, nil
+	}
+	n.next++
+	return n.next - 1, nil
+}
+
+// Release returns nonce, reserved by Next, so it is handed out again. It is ignored if account was reset since.
+func (m *NonceManager) Release(account common.Address, nonce uint64) {
+	m.mu.Lock()
+	n, ok := m.nonces[account]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.fetched || nonce >= n.next {
+		return
+	}
+	i := sort.Search(len(n.released), func(i int) bool { return n.released[i] >= nonce })
+	if i < len(n.released) && n.released[i] == nonce {
+		return
+	}
+	n.released = append(n.released, 0)
+	copy(n.released[i+1:], n.released[i:])
+	n.released[i] = nonce
+	// Released nonces at the end are simply next again.
+	for len(n.released) > 0 && n.released[len(n.released)-1] == n.next-1 {
+		n.released = n.released[:len(n.released)-1]
+		n.next--
+	}
+}
+
+// Reset forgets the next nonce of account, so it is fetched from the node again.
+func (m *NonceManager) Reset(account common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.nonces, account)
+}
+
+// nonceRejections are the errors of nodes rejecting a transaction because its nonce is already used.
+var nonceRejections = []string{"nonce too low", "already known", "known transaction"}
+
+// SendRawTransaction sends tx, and resets the sender's nonce if the node rejects it as already used. It is not reset
+// for other errors, since tx may have been broadcast, and resyncing from the node could hand out nonces which are
+// reserved by other transactions which aren't sent yet.
+func (m *NonceManager) SendRawTransaction(ctx context.Context, tx []byte) error {
+	err := m.Client.SendRawTransaction(ctx, tx)
+	if err == nil {
+		return nil
+	}
+	for _, rejection := range nonceRejections {
+		if !rpcErrorContains(err, rejection) {
+			continue
+		}
+		if t, decodeErr := DecodeTransaction(tx); decodeErr == nil {
+			if from, senderErr := txSender(t); senderErr == nil {
+				m.Reset(from)
+			}
+		}
+		break
+	}
+	return err
+}
+
+// nonceReservation is the nonce of a transaction being sent, from a NonceSource or the node.
+type nonceReservation struct {
+	source  NonceSource
+	account common.Address
+	nonce   uint64
+	sent    bool
+}
+
+// reserveNonce returns nonce if set, or the next nonce of account from source if set, or from client if it is a
+// NonceSource, ie: a NonceManager, or else the node's pending nonce. Call release when done, which returns the nonce
+// to its source unless the transaction was sent.
+func reserveNonce(ctx context.Context, client Client, account common.Address, nonce *uint64, source NonceSource) (*nonceReservation, error) {
+	if nonce != nil {
+		return &nonceReservation{nonce: *nonce}, nil
+	}
+	if source == nil {
+		source, _ = client.(NonceSource)
+	}
+	r := &nonceReservation{account: account}
+	var err error
+	if source != nil {
+		r.nonce, err = source.Next(ctx, account)
+		r.source = source
+	} else {
+		r.nonce, err = client.GetPendingTransactionCount(ctx, account)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get nonce: %w", err)
+	}
+	return r, nil
+}
+
+func (r *nonceReservation) release() {
+	if r.source != nil && !r.sent {
+		r.source.Release(r.account, r.nonce)
+	}
+}
@@ -0,0 +1,251 @@
+package web3_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+func TestNonceManager_concurrent(t *testing.T) {
+	const n = 20
+	acct, err := web3.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetNonce(acct.Address(), 7)
+	m := web3.NewNonceManager(c)
+	const to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := web3.TransferWithAccount(context.Background(), m, acct, to, big.NewInt(1), web3.TransferOptions{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	sent := c.Sent()
+	nonces := make([]int, len(sent))
+	for i, tx := range sent {
+		nonces[i] = int(tx.Nonce())
+	}
+	sort.Ints(nonces)
+	if len(nonces) != n {
+		t.Fatalf("expected %d transactions but got %d", n, len(nonces))
+	}
+	for i, nonce := range nonces {
+		if nonce != 7+i {
+			t.Fatalf("expected sequential nonces from 7 but got %v", nonces)
+		}
+	}
+	if fetched := c.CallCount("GetPendingNonce") + c.CallCount("GetPendingTransactionCount"); fetched != 1 {
+		t.Errorf("expected the nonce to be fetched once but got %d", fetched)
+	}
+}
+
+// blockingNonceClient blocks fetching the nonce of an account until unblock is closed.
+type blockingNonceClient struct {
+	web3.Client
+	account common.Address
+	unblock chan struct{}
+}
+
+func (c *blockingNonceClient) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	if account == c.account {
+		<-c.unblock
+	}
+	return c.Client.GetPendingTransactionCount(ctx, account)
+}
+
+func TestNonceManager_slowAccount(t *testing.T) {
+	ctx := context.Background()
+	slow := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	other := common.HexToAddress("0x2fe70f1df222c85ad6dd24a3376eb5ac32136978")
+	c := web3test.NewFakeClient()
+	c.SetNonce(slow, 4)
+	c.SetNonce(other, 9)
+	blocking := &blockingNonceClient{Client: c, account: slow, unblock: make(chan struct{})}
+	m := web3.NewNonceManager(blocking)
+
+	done := make(chan error, 1)
+	go func() {
+		nonce, err := m.Next(ctx, slow)
+		if err == nil && nonce != 4 {
+			err = fmt.Errorf("expected nonce 4 but got %d", nonce)
+		}
+		done <- err
+	}()
+	// The other account is served while the slow one is still fetching its nonce.
+	if nonce, err := m.Next(ctx, other); err != nil || nonce != 9 {
+		t.Fatalf("expected nonce 9 but got %d: %v", nonce, err)
+	}
+	if nonce, err := m.GetPendingNonce(ctx, other.Hex()); err != nil || nonce != 10 {
+		t.Fatalf("expected GetPendingNonce to reserve nonce 10 but got %d: %v", nonce, err)
+	}
+	m.Release(other, 10)
+	m.Reset(other)
+	select {
+	case err := <-done:
+		t.Fatalf("expected the slow account to still be fetching, got: %v", err)
+	default:
+	}
+	close(blocking.unblock)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sendErrClient fails to send every transaction.
+type sendErrClient struct {
+	web3.Client
+	err error
+}
+
+func (c *sendErrClient) SendRawTransaction(ctx context.Context, tx []byte) error {
+	return c.err
+}
+
+func TestNonceManager_reset(t *testing.T) {
+	ctx := context.Background()
+	acct, err := web3.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := acct.Address()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetNonce(from, 3)
+	failing := &sendErrClient{Client: c, err: &web3.RPCError{Method: "eth_sendRawTransaction", Err: errors.New("nonce too low")}}
+	m := web3.NewNonceManager(failing)
+
+	for i := 0; i < 2; i++ {
+		if nonce, err := m.Next(ctx, from); err != nil || nonce != uint64(3+i) {
+			t.Fatalf("expected nonce %d but got %d: %v", 3+i, nonce, err)
+		}
+	}
+	const to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	if _, err := web3.TransferWithAccount(ctx, m, acct, to, big.NewInt(1), web3.TransferOptions{}); !errors.Is(err, failing.err) {
+		t.Fatalf("expected %v but got: %v", failing.err, err)
+	}
+	// The failed send resynced from the node.
+	c.SetNonce(from, 10)
+	if nonce, err := m.Next(ctx, from); err != nil || nonce != 10 {
+		t.Errorf("expected nonce 10 after a failed send but got %d: %v", nonce, err)
+	}
+	m.Reset(from)
+	c.SetNonce(from, 12)
+	if nonce, err := m.Next(ctx, from); err != nil || nonce != 12 {
+		t.Errorf("expected nonce 12 after reset but got %d: %v", nonce, err)
+	}
+}
+
+func TestNonceManager_transientSendError(t *testing.T) {
+	ctx := context.Background()
+	acct, err := web3.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := acct.Address()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetNonce(from, 3)
+	m := web3.NewNonceManager(c)
+	const to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+
+	low, err := m.Next(ctx, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	high, err := m.Next(ctx, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lower nonce's send fails with a broken connection, so it may or may not have been broadcast.
+	c.FailNext("SendRawTransaction", io.ErrUnexpectedEOF)
+	if _, err := web3.TransferWithAccount(ctx, m, acct, to, big.NewInt(1), web3.TransferOptions{Nonce: &low}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected %v but got: %v", io.ErrUnexpectedEOF, err)
+	}
+	// The node's nonce is still 3, but it wasn't refetched, so the higher reserved nonce isn't handed out again.
+	if nonce, err := m.Next(ctx, from); err != nil || nonce != high+1 {
+		t.Fatalf("expected nonce %d but got %d: %v", high+1, nonce, err)
+	}
+	m.Release(from, low)
+	if nonce, err := m.Next(ctx, from); err != nil || nonce != low {
+		t.Errorf("expected released nonce %d but got %d: %v", low, nonce, err)
+	}
+}
+
+func TestNonceManager_release(t *testing.T) {
+	ctx := context.Background()
+	acct, err := web3.CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := acct.Address()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetNonce(from, 5)
+	m := web3.NewNonceManager(c)
+	next := func(want uint64) {
+		t.Helper()
+		if nonce, err := m.Next(ctx, from); err != nil || nonce != want {
+			t.Fatalf("expected nonce %d but got %d: %v", want, nonce, err)
+		}
+	}
+
+	for i := uint64(5); i < 9; i++ {
+		next(i)
+	}
+	// Released nonces are reused lowest first, and the last one simply becomes next again.
+	m.Release(from, 6)
+	m.Release(from, 8)
+	m.Release(from, 6)
+	m.Release(from, 20)
+	next(6)
+	next(8)
+	next(9)
+	m.Release(from, 9)
+	m.Release(from, 8)
+	next(8)
+	next(9)
+
+	// A failed send through a NonceSource releases its nonce.
+	failing := &sendErrClient{Client: c, err: errors.New("insufficient funds")}
+	const to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	if _, err := web3.TransferWithAccount(ctx, failing, acct, to, big.NewInt(1), web3.TransferOptions{NonceSource: m}); !errors.Is(err, failing.err) {
+		t.Fatalf("expected %v but got: %v", failing.err, err)
+	}
+	next(10)
+	tx, err := web3.TransferWithAccount(ctx, c, acct, to, big.NewInt(1), web3.TransferOptions{NonceSource: m})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if tx.Nonce != 11 {
+		t.Errorf("expected nonce 11 but got %d", tx.Nonce)
+	}
+
+	// Releases are ignored after a reset, which resyncs from the node's nonce after the one transaction it got.
+	m.Reset(from)
+	m.Release(from, 11)
+	next(6)
+}
@@ -0,0 +1,170 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gochain/gochain/v3"
+	"github.com/gochain/gochain/v3/common"
+)
+
+// PendingTxOptions configures WatchPendingTransactions.
+type PendingTxOptions struct {
+	// From and To, if set, only pass on transactions sent from or to the address. Filtering requires fetching
+	// each transaction, and transactions which leave the pool before they are fetched are skipped.
+	From, To *common.Address
+	// Buffer is the number of hashes held for a slow consumer, after which further hashes are dropped.
+	// Defaults to 256.
+	Buffer int
+	// Workers is the number of transactions fetched at once for filtering. Hashes which arrive while all of them
+	// are busy are dropped. Defaults to 8.
+	Workers int
+}
+
+// PendingTxSubscription is a subscription to pending transactions which drops hashes rather than blocking
+// when the consumer falls behind.
+type PendingTxSubscription struct {
+	dropped uint64 // first for 64 bit alignment
+	sub     gochain.Subscription
+	err     chan error
+}
+
+// Err returns a channel which receives the subscription error, if any, and is closed when the subscription ends.
+func (s *PendingTxSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription.
+func (s *PendingTxSubscription) Unsubscribe() {
+	s.sub.Unsubscribe()
+}
+
+// Dropped returns the number of hashes dropped so far because the buffer was full, or because all the filter
+// workers were busy.
+func (s *PendingTxSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// WatchPendingTransactions is like Client.SubscribePendingTransactions, but optionally filtered by sender or
+// recipient, and buffered so that a slow consumer does not hold up the subscription. Filtering is done off the
+// subscription by opts.Workers goroutines. Hashes which don't fit in the buffer, or arrive while all the workers
+// are busy, are dropped and counted by Dropped. Over HTTP it fails with an error wrapping
+// ErrSubscriptionUnsupported. The returned channel is closed once the subscription ends.
+func WatchPendingTransactions(ctx context.Context, client Client, opts PendingTxOptions) (<-chan common.Hash, *PendingTxSubscription, error) {
+	hashes, sub, err := client.SubscribePendingTransactions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	size := opts.Buffer
+	if size == 0 {
+		size = 256
+	}
+	s := &PendingTxSubscription{sub: sub, err: make(chan error, 1)}
+	out := make(chan common.Hash, size)
+	send := func(hash common.Hash) {
+		select {
+		case out <- hash:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+	go func() {
+		defer close(s.err)
+		defer close(out)
+		if opts.From == nil && opts.To == nil {
+			for hash := range hashes {
+				send(hash)
+			}
+		} else if err := s.filter(ctx, client, hashes, opts, send); err != nil {
+			s.err <- err
+			return
+		}
+		if err := <-sub.Err(); err != nil {
+			s.err <- err
+		}
+	}()
+	return out, s, nil
+}
+
+// filter passes the hashes matching opts to send, until hashes is closed or a transaction can't be fetched. Each
+// hash is handed to an idle worker, or dropped if there is none, so slow fetches never hold up the subscription.
+func (s *PendingTxSubscription) filter(ctx context.Context, client Client, hashes <-chan common.Hash, opts PendingTxOptions,
+	send func(common.Hash)) error {
+	workers := opts.Workers
+	if workers == 0 {
+		workers = 8
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan common.Hash)
+	failed := make(chan error, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for hash := range jobs {
+				ok, err := matchPendingTx(ctx, client, hash, opts)
+				if err != nil {
+					select {
+					case failed <- err:
+						cancel()
+					default:
+					}
+				} else if ok {
+					send(hash)
+				}
+			}
+		}()
+	}
+	var err error
+loop:
+	for {
+		select {
+		case hash, ok := <-hashes:
+			if !ok {
+				break loop
+			}
+			select {
+			case jobs <- hash:
+			default:
+				atomic.AddUint64(&s.dropped, 1)
+			}
+		case err = <-failed:
+			s.sub.Unsubscribe()
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err == nil {
+		// A fetch may have failed after the subscription ended.
+		select {
+		case err = <-failed:
+		default:
+		}
+	}
+	return err
+}
+
+// matchPendingTx reports whether the transaction with hash matches the From and To filters of opts.
+func matchPendingTx(ctx context.Context, client Client, hash common.Hash, opts PendingTxOptions) (bool, error) {
+	tx, err := client.GetTransactionByHash(ctx, hash)
+	if errors.Is(err, NotFoundErr) {
+		return false, nil
+	} else if err != nil {
+		if ctx.Err() == nil && isTransient(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if opts.From != nil && tx.From != *opts.From {
+		return false, nil
+	}
+	if opts.To != nil && (tx.To == nil || *tx.To != *opts.To) {
+		return false, nil
+	}
+	return true, nil
+}
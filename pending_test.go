@@ -0,0 +1,138 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+func TestWatchPendingTransactions(t *testing.T) {
+	alice, bob := common.HexToAddress("0xa"), common.HexToAddress("0xb")
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pending []*Transaction
+	for i, fromTo := range [][2]common.Address{{alice, bob}, {bob, alice}, {alice, alice}} {
+		tx, err := types.SignTx(types.NewTransaction(uint64(i), fromTo[1], big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pending = append(pending, convertTx(tx, fromTo[0]))
+	}
+	eth := &FakeEthService{Pending: pending}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subCtx, subCancel := context.WithCancel(ctx)
+	defer subCancel()
+	hashes, sub, err := WatchPendingTransactions(subCtx, c, PendingTxOptions{From: &alice, To: &alice})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case h, ok := <-hashes:
+			if !ok {
+				t.Fatalf("channel closed early: %v", <-sub.Err())
+			}
+			if h != pending[2].Hash {
+				t.Errorf("expected only %s to match but got %s", pending[2].Hash.Hex(), h.Hex())
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for pending transactions")
+		}
+	}
+	subCancel()
+	for range hashes {
+	}
+	if err := <-sub.Err(); err != context.Canceled {
+		t.Errorf("expected %v but got: %v", context.Canceled, err)
+	}
+
+	t.Run("dropped", func(t *testing.T) {
+		hashes, sub, err := WatchPendingTransactions(ctx, c, PendingTxOptions{Buffer: 2})
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		for sub.Dropped() == 0 {
+			select {
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for hashes to be dropped")
+			case <-time.After(time.Millisecond):
+			}
+		}
+		if len(hashes) != 2 {
+			t.Errorf("expected full buffer of 2 but got %d", len(hashes))
+		}
+		sub.Unsubscribe()
+		for range hashes {
+		}
+		if err, ok := <-sub.Err(); ok {
+			t.Errorf("expected no error after unsubscribe but got: %v", err)
+		}
+	})
+
+	t.Run("busy filters", func(t *testing.T) {
+		// The only worker is stuck fetching, so further hashes are dropped without holding up the subscription.
+		release := make(chan struct{})
+		slow := &slowTxClient{Client: c, release: release}
+		hashes, sub, err := WatchPendingTransactions(ctx, slow, PendingTxOptions{To: &alice, Workers: 1})
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		for sub.Dropped() < 3 {
+			select {
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for hashes to be dropped")
+			case <-time.After(time.Millisecond):
+			}
+		}
+		close(release)
+		select {
+		case h := <-hashes:
+			if h != pending[1].Hash && h != pending[2].Hash {
+				t.Errorf("expected a transaction to %s but got %s", alice.Hex(), h.Hex())
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for pending transactions")
+		}
+		sub.Unsubscribe()
+		for range hashes {
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		c, err := Dial("http://127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer c.Close()
+		_, _, err = WatchPendingTransactions(ctx, c, PendingTxOptions{})
+		if !errors.Is(err, ErrSubscriptionUnsupported) {
+			t.Errorf("expected %v but got: %v", ErrSubscriptionUnsupported, err)
+		}
+	})
+}
+
+// slowTxClient blocks fetching transactions until release is closed.
+type slowTxClient struct {
+	Client
+	release chan struct{}
+}
+
+func (c *slowTxClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return c.Client.GetTransactionByHash(ctx, hash)
+}
@@ -0,0 +1,94 @@
+package web3
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ClientStats are counters of a client's rate limiting, to help tune ClientOptions.RateLimit.
+type ClientStats struct {
+	// Requests is the number of requests made, counting each element of a batch.
+	Requests uint64
+	// Delayed is the number of calls which waited for the rate limit.
+	Delayed uint64
+	// Wait is the total time spent waiting for the rate limit.
+	Wait time.Duration
+}
+
+// AverageWait returns the average wait of delayed calls.
+func (s ClientStats) AverageWait() time.Duration {
+	if s.Delayed == 0 {
+		return 0
+	}
+	return s.Wait / time.Duration(s.Delayed)
+}
+
+// limiter is a token bucket rate limiter, refilled at rate tokens per second up to burst.
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	stats  ClientStats
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait takes n tokens, waiting until they are available or ctx is done. Tokens are reserved up front, so
+// concurrent callers are served in order.
+func (l *limiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay == 0 {
+		l.record(n, 0)
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		l.record(n, delay)
+		return nil
+	case <-ctx.Done():
+		// Return the reservation.
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) record(n int, delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Requests += uint64(n)
+	if delay > 0 {
+		l.stats.Delayed++
+		l.stats.Wait += delay
+	}
+}
+
+func (l *limiter) Stats() ClientStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
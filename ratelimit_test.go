@@ -0,0 +1,62 @@
+package web3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/rpc"
+)
+
+func TestClient_RateLimit(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("test", EchoService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	c := NewClientWithOptions(rpc.DialInProc(srv), ClientOptions{RateLimit: 50, RateBurst: 2})
+	defer c.Close()
+	ctx := context.Background()
+
+	// The burst is free, and each following call waits 20ms.
+	start := time.Now()
+	for i := 0; i < 7; i++ {
+		if err := c.RawCall(ctx, nil, "test_echo", "hello", i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Errorf("expected 7 calls to take at least 100ms but took %s", d)
+	}
+	stats := c.Stats()
+	if stats.Requests != 7 || stats.Delayed == 0 || stats.Delayed > 5 {
+		t.Errorf("expected 7 requests with up to 5 delayed but got %+v", stats)
+	}
+	if avg := stats.AverageWait(); avg <= 0 || avg > 20*time.Millisecond {
+		t.Errorf("expected average wait up to 20ms but got %s", avg)
+	}
+
+	// Batches count each element. GetID fails without the eth namespace, but makes a batch of 3.
+	c.GetID(ctx)
+	if got := c.Stats().Requests; got != 10 {
+		t.Errorf("expected batch to count as 3 requests but got %d in total", got-7)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := c.RawCall(ctx, nil, "test_echo", "hello", 0); err != context.DeadlineExceeded {
+		t.Errorf("expected %v while waiting for the rate limit but got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestClient_noRateLimit(t *testing.T) {
+	c := newTestClient(t, map[string]interface{}{"test": EchoService{}})
+	for i := 0; i < 3; i++ {
+		if err := c.RawCall(context.Background(), nil, "test_echo", "hello", i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if stats := c.Stats(); stats != (ClientStats{}) {
+		t.Errorf("expected no stats without a rate limit but got %+v", stats)
+	}
+}
@@ -0,0 +1,113 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+)
+
+// MinReplacementBump is the minimum gas price increase, in percent, for nodes to accept a replacement transaction.
+const MinReplacementBump = 10
+
+var (
+	// ErrAlreadyMined is matched by the *AlreadyMinedError returned when replacing a mined transaction.
+	ErrAlreadyMined = errors.New("transaction already mined")
+	// ErrReplacementUnderpriced is returned, wrapped, when a replacement's gas price bump is below
+	// MinReplacementBump, or the node rejects it as underpriced.
+	ErrReplacementUnderpriced = errors.New("replacement transaction underpriced")
+)
+
+// AlreadyMinedError is returned when replacing a transaction which has already been mined, with its receipt. It
+// matches ErrAlreadyMined with errors.Is.
+type AlreadyMinedError struct {
+	Receipt *Receipt
+}
+
+func (e *AlreadyMinedError) Error() string {
+	return fmt.Sprintf("%v: %s in block %d", ErrAlreadyMined, e.Receipt.TxHash.Hex(), e.Receipt.BlockNumber)
+}
+
+func (e *AlreadyMinedError) Is(target error) bool {
+	return target == ErrAlreadyMined
+}
+
+// SpeedUpTransaction replaces the pending transaction with hash by resending it with the same nonce, recipient,
+// value, and data, but with the gas price raised by bumpPercent, or to the suggested gas price if that is higher.
+// bumpPercent 0 means MinReplacementBump, and smaller bumps fail with ErrReplacementUnderpriced. It must be signed
+// by the same account, and returns an *AlreadyMinedError if it's too late.
+func SpeedUpTransaction(ctx context.Context, client Client, privateKeyHex string, hash common.Hash, bumpPercent int) (*Transaction, error) {
+	return replaceTransaction(ctx, client, privateKeyHex, hash, bumpPercent, false)
+}
+
+// CancelTransaction is like SpeedUpTransaction, but replaces the pending transaction with an empty transfer from the
+// account to itself, so it does nothing.
+func CancelTransaction(ctx context.Context, client Client, privateKeyHex string, hash common.Hash, bumpPercent int) (*Transaction, error) {
+	return replaceTransaction(ctx, client, privateKeyHex, hash, bumpPercent, true)
+}
+
+func replaceTransaction(ctx context.Context, client Client, privateKeyHex string, hash common.Hash, bumpPercent int, cancel bool) (*Transaction, error) {
+	if bumpPercent == 0 {
+		bumpPercent = MinReplacementBump
+	} else if bumpPercent < MinReplacementBump {
+		return nil, fmt.Errorf("%w: gas price bump %d%% is below the minimum %d%%", ErrReplacementUnderpriced, bumpPercent, MinReplacementBump)
+	}
+	s, err := signerFromHex(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	orig, err := client.GetTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get transaction: %w", err)
+	}
+	if orig.BlockNumber != nil {
+		receipt, err := client.GetTransactionReceipt(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w in block %s, but cannot get receipt: %v", hash.Hex(), ErrAlreadyMined, orig.BlockNumber, err)
+		}
+		return nil, &AlreadyMinedError{Receipt: receipt}
+	}
+	if orig.From != s.Address() {
+		return nil, fmt.Errorf("transaction %s is from %s, not %s", hash.Hex(), orig.From.Hex(), s.Address().Hex())
+	}
+	gasPrice := bumpGasPrice(orig.GasPrice, bumpPercent)
+	if suggested, err := client.GetGasPrice(ctx); err != nil {
+		return nil, fmt.Errorf("cannot get gas price: %w", err)
+	} else if suggested.Cmp(gasPrice) > 0 {
+		gasPrice = suggested
+	}
+	var tx *types.Transaction
+	switch {
+	case cancel:
+		tx = types.NewTransaction(orig.Nonce, s.Address(), new(big.Int), 21000, gasPrice, nil)
+	case orig.To == nil:
+		tx = types.NewContractCreation(orig.Nonce, orig.Value, orig.GasLimit, gasPrice, orig.Input)
+	default:
+		tx = types.NewTransaction(orig.Nonce, *orig.To, orig.Value, orig.GasLimit, gasPrice, orig.Input)
+	}
+	chainID, err := signingChainID(ctx, client, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := s.SignTx(tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign transaction: %w", err)
+	}
+	if err := SendTransaction(ctx, client, signedTx); err != nil {
+		if rpcErrorContains(err, "underpriced") {
+			return nil, fmt.Errorf("%w: %v", ErrReplacementUnderpriced, err)
+		}
+		return nil, fmt.Errorf("cannot send transaction: %w", err)
+	}
+	return convertTx(signedTx, s.Address()), nil
+}
+
+// bumpGasPrice returns price raised by percent, rounded up.
+func bumpGasPrice(price *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(price, big.NewInt(int64(100+percent)))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
@@ -0,0 +1,92 @@
+package web3_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+func TestBumpGasPrice(t *testing.T) {
+	for _, tt := range []struct {
+		price   int64
+		percent int
+		want    int64
+	}{
+		{price: 100, percent: 10, want: 110},
+		{price: 100, percent: 50, want: 150},
+		{price: 15, percent: 10, want: 17}, // 16.5 rounded up
+		{price: 0, percent: 10, want: 0},
+	} {
+		if got := web3.BumpGasPrice(big.NewInt(tt.price), tt.percent); got.Int64() != tt.want {
+			t.Errorf("bump %d by %d%%: expected %d but got %s", tt.price, tt.percent, tt.want, got)
+		}
+	}
+}
+
+func TestSpeedUpTransaction(t *testing.T) {
+	ctx := context.Background()
+	key, from, err := web3.KeyFromHex(devKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	signed, err := web3.SignTransaction(key, big.NewInt(60), types.NewTransaction(4, to, web3.Base(1), 50000, web3.Gwei(10), []byte{1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := web3.ConvertTx(signed, from)
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.AddTransaction(pending)
+
+	if _, err := web3.SpeedUpTransaction(ctx, c, devKey, pending.Hash, 20); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := c.Sent()[0]
+	if sent.Nonce() != 4 || *sent.To() != to || sent.Value().Cmp(web3.Base(1)) != 0 || sent.Gas() != 50000 || len(sent.Data()) != 1 {
+		t.Errorf("expected the same transaction with a higher gas price but got %+v", sent)
+	}
+	if sent.GasPrice().Cmp(web3.Gwei(12)) != 0 {
+		t.Errorf("expected gas price %s but got %s", web3.Gwei(12), sent.GasPrice())
+	}
+
+	if _, err := web3.CancelTransaction(ctx, c, devKey, pending.Hash, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent = c.Sent()[1]
+	if sent.Nonce() != 4 || *sent.To() != from || sent.Value().Sign() != 0 || sent.GasPrice().Cmp(web3.Gwei(11)) != 0 {
+		t.Errorf("expected an empty self transfer at nonce 4 with gas price %s but got %+v", web3.Gwei(11), sent)
+	}
+
+	if _, err := web3.SpeedUpTransaction(ctx, c, testKeyHex(t), pending.Hash, 20); err == nil {
+		t.Error("expected error for a different account")
+	}
+
+	// Too small a bump is rejected before sending, and by the node.
+	if _, err := web3.SpeedUpTransaction(ctx, c, devKey, pending.Hash, 5); !errors.Is(err, web3.ErrReplacementUnderpriced) {
+		t.Errorf("expected %v but got: %v", web3.ErrReplacementUnderpriced, err)
+	}
+	rejecting := &sendErrClient{Client: c, err: &web3.RPCError{Method: "eth_sendRawTransaction", Err: errors.New("replacement transaction underpriced")}}
+	if _, err := web3.SpeedUpTransaction(ctx, rejecting, devKey, pending.Hash, 10); !errors.Is(err, web3.ErrReplacementUnderpriced) {
+		t.Errorf("expected %v but got: %v", web3.ErrReplacementUnderpriced, err)
+	}
+
+	pending.BlockNumber = big.NewInt(100)
+	c.AddReceipt(&web3.Receipt{TxHash: pending.Hash, BlockNumber: 100, Status: 1})
+	_, err = web3.SpeedUpTransaction(ctx, c, devKey, pending.Hash, 20)
+	var mined *web3.AlreadyMinedError
+	if !errors.As(err, &mined) || !errors.Is(err, web3.ErrAlreadyMined) {
+		t.Errorf("expected *web3.AlreadyMinedError but got: %v", err)
+	} else if mined.Receipt.TxHash != pending.Hash || mined.Receipt.BlockNumber != 100 {
+		t.Errorf("expected the receipt of %s but got %+v", pending.Hash.Hex(), mined.Receipt)
+	}
+	if sent := c.Sent(); len(sent) != 2 {
+		t.Errorf("expected no more transactions sent but got %d", len(sent))
+	}
+}
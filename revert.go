@@ -0,0 +1,80 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v3/accounts/abi"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+)
+
+// ErrNoRevert is returned by RevertReason when the replayed transaction does not revert, which usually means
+// that it failed by running out of gas.
+var ErrNoRevert = errors.New("transaction did not revert on replay, it may have run out of gas")
+
+var (
+	// errorSelector is the selector of the standard Error(string) revert payload.
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+	// panicSelector is the selector of the Panic(uint256) payload used by Solidity 0.8 for failed assertions.
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// RevertReason replays tx with eth_call at blockNumber, usually the block it was included in, and returns the
+// reason it reverted. Payloads other than Error(string) and Panic(uint256), like custom errors, are returned as
+// hex.
+func RevertReason(ctx context.Context, client Client, tx *types.Transaction, blockNumber *big.Int) (string, error) {
+	from, err := txSender(tx)
+	if err != nil {
+		return "", fmt.Errorf("cannot recover sender: %w", err)
+	}
+	msg := CallMsg{From: from, To: tx.To(), Gas: tx.Gas(), GasPrice: tx.GasPrice(), Value: tx.Value(), Data: tx.Data()}
+	return callRevertReason(ctx, client, msg, blockNumber)
+}
+
+// callRevertReason calls msg with eth_call at blockNumber, and returns the reason it reverted, or ErrNoRevert.
+func callRevertReason(ctx context.Context, client Client, msg CallMsg, blockNumber *big.Int) (string, error) {
+	var res hexutil.Bytes
+	err := client.RawCall(ctx, &res, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
+	if err != nil {
+		// Newer nodes report the reason in the error instead of returning the payload.
+		var revertErr *RevertError
+		if errors.As(toRevertError(err), &revertErr) {
+			return revertErr.Reason, nil
+		}
+		return "", err
+	}
+	// Revert payloads are a 4 byte selector followed by 32 byte words, while return values are only words.
+	if len(res)%32 != 4 {
+		return "", ErrNoRevert
+	}
+	return decodeRevert(res)
+}
+
+// decodeRevert decodes an Error(string) or Panic(uint256) revert payload, or returns other payloads as hex.
+func decodeRevert(data []byte) (string, error) {
+	var typName string
+	switch {
+	case bytes.HasPrefix(data, errorSelector):
+		typName = "string"
+	case bytes.HasPrefix(data, panicSelector):
+		typName = "uint256"
+	default:
+		return hexutil.Encode(data), nil
+	}
+	typ, err := abi.NewType(typName, "", nil)
+	if err != nil {
+		return "", err
+	}
+	vals, err := abi.Arguments{{Type: typ}}.UnpackValues(data[4:])
+	if err != nil {
+		return "", fmt.Errorf("failed to unpack revert reason from %s: %w", hexutil.Encode(data), err)
+	}
+	if code, ok := vals[0].(*big.Int); ok {
+		return fmt.Sprintf("panic: 0x%x", code), nil
+	}
+	return vals[0].(string), nil
+}
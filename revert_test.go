@@ -0,0 +1,123 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v3/accounts/abi"
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+// testRevertPayload returns the Error(string) revert payload for reason.
+func testRevertPayload(t *testing.T, reason string) []byte {
+	t.Helper()
+	typ, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := abi.Arguments{{Type: typ}}.Pack(reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(append([]byte{}, errorSelector...), packed...)
+}
+
+func TestRevertReason(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x01")
+	tx, err := types.SignTx(types.NewTransaction(0, to, big.NewInt(0), 50000, Gwei(1), []byte{0x12, 0x34, 0x56, 0x78}),
+		types.NewEIP155Signer(big.NewInt(60)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []map[string]interface{}
+	eth := &FakeEthService{}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+
+	eth.CallFunc = func(msg map[string]interface{}) ([]byte, error) {
+		calls = append(calls, msg)
+		return testRevertPayload(t, "Ownable: caller is not the owner"), nil
+	}
+	reason, err := RevertReason(ctx, c, tx, big.NewInt(5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if reason != "Ownable: caller is not the owner" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+	if got := calls[0]; got["from"] != strings.ToLower(from.Hex()) || got["data"] != "0x12345678" || got["gas"] != "0xc350" {
+		t.Errorf("unexpected call: %v", got)
+	}
+	if eth.Tags[0] != "0x5" {
+		t.Errorf("expected call at block 0x5 but got %s", eth.Tags[0])
+	}
+
+	// Homestead transactions have no chain id to recover the sender with.
+	homestead, err := types.SignTx(types.NewTransaction(0, to, big.NewInt(0), 50000, Gwei(1), nil), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RevertReason(ctx, c, homestead, big.NewInt(5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := calls[1]; got["from"] != strings.ToLower(from.Hex()) {
+		t.Errorf("expected call from %s but got: %v", from.Hex(), got)
+	}
+
+	eth.CallFunc = func(msg map[string]interface{}) ([]byte, error) {
+		return nil, errors.New("execution reverted: insufficient balance")
+	}
+	if reason, err := RevertReason(ctx, c, tx, big.NewInt(5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if reason != "insufficient balance" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+
+	eth.CallFunc = nil
+	if _, err := RevertReason(ctx, c, tx, big.NewInt(5)); err != ErrNoRevert {
+		t.Errorf("expected %v but got: %v", ErrNoRevert, err)
+	}
+	eth.CallFunc = func(msg map[string]interface{}) ([]byte, error) {
+		return common.LeftPadBytes([]byte{1}, 32), nil
+	}
+	if _, err := RevertReason(ctx, c, tx, big.NewInt(5)); err != ErrNoRevert {
+		t.Errorf("expected %v for return value but got: %v", ErrNoRevert, err)
+	}
+}
+
+func Test_decodeRevert(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"error", testRevertPayload(t, "not allowed"), "not allowed"},
+		{"panic", hexutil.MustDecode("0x4e487b710000000000000000000000000000000000000000000000000000000000000011"), "panic: 0x11"},
+		// error InsufficientBalance(uint256 available, uint256 required)
+		{"custom", hexutil.MustDecode("0xcf479181" + strings.Repeat("0", 63) + "1" + strings.Repeat("0", 63) + "2"),
+			"0xcf479181" + strings.Repeat("0", 63) + "1" + strings.Repeat("0", 63) + "2"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRevert(tt.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q but got %q", tt.want, got)
+			}
+		})
+	}
+	if _, err := decodeRevert(errorSelector); err == nil {
+		t.Error("expected error for truncated Error(string) payload")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/gochain/gochain/v3/common"
@@ -32,6 +33,7 @@ type rpcBlock struct {
 	MixHash         *common.Hash      `json:"mixHash"`
 	Nonce           *types.BlockNonce `json:"nonce"`
 	Hash            *common.Hash      `json:"hash"`
+	BaseFee         *hexutil.Big      `json:"baseFeePerGas,omitempty"`
 	Txs             json.RawMessage   `json:"transactions,omitempty"`
 	Uncles          []common.Hash     `json:"uncles"`
 }
@@ -110,6 +112,9 @@ func (r *rpcBlock) copyTo(b *Block) error {
 		return errors.New("missing 'hash'")
 	}
 	b.Hash = *r.Hash
+	if r.BaseFee != nil {
+		b.BaseFee = r.BaseFee.ToInt()
+	}
 
 	// Try tx hashes first.
 	var hashes []common.Hash
@@ -151,6 +156,7 @@ func (r *rpcBlock) copyFrom(b *Block) error {
 	r.MixHash = &b.MixHash
 	r.Nonce = &b.Nonce
 	r.Hash = &b.Hash
+	r.BaseFee = (*hexutil.Big)(b.BaseFee)
 	if b.TxHashes != nil {
 		data, err := json.Marshal(b.TxHashes)
 		if err != nil {
@@ -341,3 +347,35 @@ func (rr *rpcReceipt) copyFrom(r *Receipt) {
 	rr.From = &r.From
 	rr.To = r.To
 }
+
+type rpcStateOverride struct {
+	Balance   *hexutil.Big                `json:"balance,omitempty"`
+	Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+	Code      *hexutil.Bytes              `json:"code,omitempty"`
+	State     map[common.Hash]common.Hash `json:"state,omitempty"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+}
+
+func (r *rpcStateOverride) copyTo(o *StateOverride) error {
+	if r.State != nil && r.StateDiff != nil {
+		return errors.New("both 'state' and 'stateDiff' set")
+	}
+	o.Balance = (*big.Int)(r.Balance)
+	o.Nonce = (*uint64)(r.Nonce)
+	if r.Code != nil {
+		o.Code = *r.Code
+	}
+	o.State = r.State
+	o.StateDiff = r.StateDiff
+	return nil
+}
+
+func (r *rpcStateOverride) copyFrom(o *StateOverride) {
+	r.Balance = (*hexutil.Big)(o.Balance)
+	r.Nonce = (*hexutil.Uint64)(o.Nonce)
+	if o.Code != nil {
+		r.Code = (*hexutil.Bytes)(&o.Code)
+	}
+	r.State = o.State
+	r.StateDiff = o.StateDiff
+}
@@ -0,0 +1,643 @@
+package web3_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+// testKeyHex returns a new random private key as a hex string.
+func testKeyHex(t *testing.T) string {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(crypto.FromECDSA(key))
+}
+
+// estimates returns the messages c was asked to estimate gas for.
+func estimates(c *web3test.FakeClient) []web3.CallMsg {
+	var msgs []web3.CallMsg
+	for _, call := range c.Calls() {
+		if call.Method == "EstimateGas" {
+			msgs = append(msgs, call.Args[0].(web3.CallMsg))
+		}
+	}
+	return msgs
+}
+
+func TestDeployContractWithOptions(t *testing.T) {
+	const code = "0x6080604052"
+	nonce := uint64(7)
+	for _, tt := range []struct {
+		name      string
+		opts      web3.DeployOptions
+		wantPrice *big.Int
+		wantValue *big.Int
+		wantNonce uint64
+	}{
+		{name: "defaults", opts: web3.DeployOptions{GasLimit: 2000000}, wantPrice: web3.Gwei(1), wantValue: big.NewInt(0), wantNonce: 3},
+		{name: "overrides", opts: web3.DeployOptions{GasLimit: 3000000, GasPrice: web3.Gwei(5), Value: web3.Base(1), Nonce: &nonce},
+			wantPrice: web3.Gwei(5), wantValue: web3.Base(1), wantNonce: nonce},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := web3test.NewFakeClient()
+			c.SetChainID(big.NewInt(60))
+			c.SetNonce(common.HexToAddress(devAddress), 3)
+			_, err := web3.DeployContractWithOptions(context.Background(), c, devKey, code, "", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sent := c.Sent()
+			if len(sent) != 1 {
+				t.Fatalf("expected 1 sent tx, got %d", len(sent))
+			}
+			tx := sent[0]
+			if tx.Gas() != tt.opts.GasLimit {
+				t.Errorf("expected gas limit %d but got %d", tt.opts.GasLimit, tx.Gas())
+			}
+			if tx.GasPrice().Cmp(tt.wantPrice) != 0 {
+				t.Errorf("expected gas price %s but got %s", tt.wantPrice, tx.GasPrice())
+			}
+			if tx.Value().Cmp(tt.wantValue) != 0 {
+				t.Errorf("expected value %s but got %s", tt.wantValue, tx.Value())
+			}
+			if tx.Nonce() != tt.wantNonce {
+				t.Errorf("expected nonce %d but got %d", tt.wantNonce, tx.Nonce())
+			}
+		})
+	}
+}
+
+func TestDeployContract_wrappedErrors(t *testing.T) {
+	c := web3test.NewFakeClient()
+	ctx := context.Background()
+
+	_, err := web3.DeployContract(ctx, c, "0xzz", "0x6080", "", 2000000)
+	if err == nil || errors.Unwrap(err) == nil {
+		t.Errorf("expected wrapped private key error but got: %v", err)
+	}
+
+	_, err = web3.DeployContract(ctx, c, testKeyHex(t), "6080", "", 2000000)
+	if !errors.Is(err, hexutil.ErrMissingPrefix) {
+		t.Errorf("expected %v to be wrapped but got: %v", hexutil.ErrMissingPrefix, err)
+	}
+}
+
+func TestDeployContract_estimateGas(t *testing.T) {
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(100000)
+	if _, err := web3.DeployContract(ctx, c, testKeyHex(t), "0x6080", "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msgs := estimates(c)
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 gas estimate but got %d", len(msgs))
+	}
+	if got := msgs[0].Data; !bytes.Equal(got, []byte{0x60, 0x80}) {
+		t.Errorf("expected estimate for contract data 0x6080 but got %x", got)
+	}
+	if got := c.Sent()[0].Gas(); got != 120000 {
+		t.Errorf("expected estimated gas limit with default multiplier 120000 but got %d", got)
+	}
+
+	c = web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(100000)
+	if _, err := web3.DeployContractWithOptions(ctx, c, testKeyHex(t), "0x6080", "", web3.DeployOptions{GasMultiplier: 1.5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.Sent()[0].Gas(); got != 150000 {
+		t.Errorf("expected estimated gas limit 150000 but got %d", got)
+	}
+
+	// Large contracts need more than the 2,000,000 gas limit that used to be the default.
+	c = web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(3000000)
+	if _, err := web3.DeployContract(ctx, c, testKeyHex(t), "0x6080", "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := c.Sent()[0].Gas(); got != 3600000 {
+		t.Errorf("expected estimated gas limit 3600000 but got %d", got)
+	}
+
+	c = web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetError("EstimateGas", errors.New("execution reverted"))
+	if _, err := web3.DeployContract(ctx, c, testKeyHex(t), "0x6080", "", 0); err == nil {
+		t.Error("expected gas estimation error")
+	} else if len(c.Sent()) > 0 {
+		t.Error("expected no transaction to be sent after failed estimate")
+	}
+	if _, err := web3.DeployContractWithOptions(ctx, c, testKeyHex(t), "0x6080", "", web3.DeployOptions{FallbackGasLimit: 500000}); err != nil {
+		t.Fatalf("unexpected error with fallback: %v", err)
+	}
+	if got := c.Sent()[0].Gas(); got != 500000 {
+		t.Errorf("expected fallback gas limit 500000 but got %d", got)
+	}
+}
+
+func TestDeployContract_signer(t *testing.T) {
+	ctx := context.Background()
+	from := common.HexToAddress(devAddress)
+	opts := web3.DeployOptions{GasLimit: 100000}
+
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	if _, err := web3.DeployContractWithOptions(ctx, c, devKey, "0x6080", "", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx := c.Sent()[0]
+	if !tx.Protected() {
+		t.Error("expected replay protected transaction")
+	}
+	if got, err := types.Sender(types.NewEIP155Signer(big.NewInt(60)), tx); err != nil {
+		t.Errorf("failed to recover sender: %v", err)
+	} else if got != from {
+		t.Errorf("expected sender %s but got %s", from.Hex(), got.Hex())
+	}
+
+	c = web3test.NewFakeClient()
+	c.SetError("ChainID", errors.New("the method eth_chainId does not exist/is not available"))
+	if _, err := web3.DeployContractWithOptions(ctx, c, devKey, "0x6080", "", opts); err == nil {
+		t.Error("expected error without chain id")
+	}
+	opts.AllowHomestead = true
+	if _, err := web3.DeployContractWithOptions(ctx, c, devKey, "0x6080", "", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Sent()[0].Protected() {
+		t.Error("expected unprotected homestead transaction")
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	ctx := context.Background()
+	const to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasPrice(web3.Gwei(2))
+	c.SetNonce(common.HexToAddress(devAddress), 5)
+
+	tx, err := web3.Transfer(ctx, c, devKey, to, web3.Base(1), web3.TransferOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := c.Sent()[0]
+	if sent.Hash() != tx.Hash {
+		t.Errorf("expected returned tx %s to be sent, but got %s", tx.Hash.Hex(), sent.Hash().Hex())
+	}
+	if *sent.To() != common.HexToAddress(to) {
+		t.Errorf("expected to %s but got %s", to, sent.To().Hex())
+	}
+	if sent.Value().Cmp(web3.Base(1)) != 0 {
+		t.Errorf("expected value %s but got %s", web3.Base(1), sent.Value())
+	}
+	if sent.Gas() != 21000 || sent.Nonce() != 5 || sent.GasPrice().Cmp(web3.Gwei(2)) != 0 {
+		t.Errorf("unexpected gas %d, nonce %d, or gas price %s", sent.Gas(), sent.Nonce(), sent.GasPrice())
+	}
+
+	nonce := uint64(9)
+	opts := web3.TransferOptions{GasLimit: 30000, GasPrice: web3.Gwei(3), Nonce: &nonce}
+	if _, err := web3.Transfer(ctx, c, devKey, to, web3.Base(1), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent = c.Sent()[1]
+	if sent.Gas() != 30000 || sent.Nonce() != 9 || sent.GasPrice().Cmp(web3.Gwei(3)) != 0 {
+		t.Errorf("unexpected gas %d, nonce %d, or gas price %s", sent.Gas(), sent.Nonce(), sent.GasPrice())
+	}
+
+	for _, bad := range []string{"", "0x1234", "0xzz5b5e2d2d63dad7fa940e239925f29320f5103d"} {
+		if _, err := web3.Transfer(ctx, c, devKey, bad, web3.Base(1), web3.TransferOptions{}); err == nil {
+			t.Errorf("expected error for invalid address %q", bad)
+		}
+	}
+	const zero = "0x0000000000000000000000000000000000000000"
+	if _, err := web3.Transfer(ctx, c, devKey, zero, big.NewInt(0), web3.TransferOptions{}); err == nil {
+		t.Error("expected error for transfer to the zero address")
+	}
+	if _, err := web3.Transfer(ctx, c, devKey, zero, big.NewInt(0), web3.TransferOptions{AllowZeroAddress: true}); err != nil {
+		t.Errorf("unexpected error for allowed transfer to the zero address: %v", err)
+	}
+}
+
+func TestTransfer_estimateGas(t *testing.T) {
+	ctx := context.Background()
+	const to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(30000)
+
+	// A contract recipient gets the estimate with a margin.
+	if _, err := web3.Transfer(ctx, c, devKey, to, web3.Base(1), web3.TransferOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := web3.Transfer(ctx, c, devKey, to, web3.Base(1), web3.TransferOptions{GasMultiplier: 1.5}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := c.Sent()
+	if sent[0].Gas() != 36000 || sent[1].Gas() != 45000 {
+		t.Errorf("expected gas limits 36000 and 45000 but got %d and %d", sent[0].Gas(), sent[1].Gas())
+	}
+
+	c.SetError("EstimateGas", &web3.RevertError{Reason: "no deposits"})
+	_, err := web3.Transfer(ctx, c, devKey, to, web3.Base(1), web3.TransferOptions{})
+	var revertErr *web3.RevertError
+	if !errors.As(err, &revertErr) || revertErr.Reason != "no deposits" {
+		t.Errorf("expected revert error but got: %v", err)
+	}
+	if len(c.Sent()) != 2 {
+		t.Errorf("expected a reverting transfer not to be sent, got %d sent", len(c.Sent()))
+	}
+}
+
+func TestSend(t *testing.T) {
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	tx, err := web3.Send(ctx, c, devKey, common.HexToAddress("0x01"), web3.Base(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := c.Sent()
+	if len(sent) != 1 || sent[0].Gas() != 21000 || sent[0].ChainId().Int64() != 60 {
+		t.Fatalf("expected a transaction with 21000 gas for chain 60 but got %+v", sent)
+	}
+	if tx.Hash != sent[0].Hash() {
+		t.Errorf("expected hash %s but got %s", sent[0].Hash().Hex(), tx.Hash.Hex())
+	}
+
+	c = web3test.NewFakeClient()
+	c.SetError("ChainID", errors.New("the method eth_chainId does not exist/is not available"))
+	if _, err := web3.Send(ctx, c, devKey, common.HexToAddress("0x01"), web3.Base(1)); err == nil {
+		t.Error("expected error without a chain id")
+	}
+	if sent := c.Sent(); len(sent) != 0 {
+		t.Errorf("expected nothing to be sent without replay protection, got %d sent", len(sent))
+	}
+}
+
+const testStorageABI = `[
+{"inputs":[{"name":"v","type":"uint256"}],"name":"set","outputs":[],"stateMutability":"nonpayable","type":"function"},
+{"inputs":[],"name":"get","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"deposit","outputs":[],"stateMutability":"payable","type":"function"}]`
+
+func TestSendContractTransaction(t *testing.T) {
+	const address = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(50000)
+	// The storage contract's get returns the argument of the last set transaction.
+	c.SetCallFunc(func(web3.CallMsg) ([]byte, error) {
+		sent := c.Sent()
+		if len(sent) == 0 {
+			return make([]byte, 32), nil
+		}
+		return sent[len(sent)-1].Data()[4:], nil
+	})
+
+	tx, err := web3.SendContractTransaction(ctx, c, devKey, testStorageABI, address, "set", nil, web3.TransactOptions{}, "42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := c.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 sent tx, got %d", len(sent))
+	}
+	if sent[0].To() == nil || *sent[0].To() != common.HexToAddress(address) {
+		t.Errorf("expected tx to %s but got %v", address, sent[0].To())
+	}
+	if sent[0].Gas() != 60000 {
+		t.Errorf("expected estimated gas limit with default multiplier 60000 but got %d", sent[0].Gas())
+	}
+	if sent[0].ChainId().Int64() != 60 {
+		t.Errorf("expected chain id 60 but got %s", sent[0].ChainId())
+	}
+	if tx.Hash != sent[0].Hash() {
+		t.Errorf("expected hash %s but got %s", sent[0].Hash().Hex(), tx.Hash.Hex())
+	}
+	got, err := web3.CallContract(ctx, c, testStorageABI, address, "get")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, ok := got[0].(*big.Int); !ok || v.Int64() != 42 {
+		t.Errorf("expected stored value 42 but got %v", got)
+	}
+
+	if _, err := web3.SendContractTransaction(ctx, c, devKey, testStorageABI, address, "deposit", web3.Base(1), web3.TransactOptions{GasLimit: 30000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if sent := c.Sent()[1]; sent.Value().Cmp(web3.Base(1)) != 0 || sent.Gas() != 30000 {
+		t.Errorf("expected value %s and gas 30000 but got %s and %d", web3.Base(1), sent.Value(), sent.Gas())
+	}
+
+	for _, tt := range []struct {
+		name    string
+		address string
+		method  string
+		amount  *big.Int
+		params  []interface{}
+		want    string
+	}{
+		{"invalid-address", "0x01", "set", nil, []interface{}{"1"}, "invalid address"},
+		{"unknown-method", address, "missing", nil, nil, `method "missing" not found in ABI`},
+		{"non-payable", address, "set", web3.Base(1), []interface{}{"1"}, `cannot send value to nonpayable method "set"`},
+		{"arg-type", address, "set", nil, []interface{}{"one"}, `invalid arguments for method "set"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := web3.SendContractTransaction(ctx, c, devKey, testStorageABI, tt.address, tt.method, tt.amount, web3.TransactOptions{}, tt.params...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q but got: %v", tt.want, err)
+			}
+		})
+	}
+	if sent := c.Sent(); len(sent) != 2 {
+		t.Errorf("expected invalid calls not to send transactions, got %d sent", len(sent))
+	}
+}
+
+func TestCallTransactFunction(t *testing.T) {
+	const address = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(50000)
+	c.SetNonce(common.HexToAddress(devAddress), 3)
+	nm := web3.NewNonceManager(c)
+	myabi := mustParseABI(testStorageABI)
+
+	// A failed estimate releases the nonce taken from the NonceManager.
+	c.FailNext("EstimateGas", errors.New("execution reverted"))
+	if _, err := web3.CallTransactFunction(ctx, nm, myabi, address, devKey, "set", nil, 0, "42"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := web3.CallTransactFunction(ctx, nm, myabi, address, devKey, "set", nil, 0, "42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := web3.CallTransactFunction(ctx, nm, myabi, address, devKey, "set", nil, 40000, "43"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sent := c.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 sent txs, got %d", len(sent))
+	}
+	for i, want := range []struct{ nonce, gas uint64 }{{3, 60000}, {4, 40000}} {
+		if sent[i].Nonce() != want.nonce || sent[i].Gas() != want.gas || sent[i].ChainId().Int64() != 60 {
+			t.Errorf("%d: expected nonce %d, gas %d and chain id 60 but got %d, %d and %s", i, want.nonce, want.gas,
+				sent[i].Nonce(), sent[i].Gas(), sent[i].ChainId())
+		}
+	}
+
+	if _, err := web3.CallTransactFunction(ctx, nm, myabi, "", devKey, "set", nil, 0, "1"); err == nil {
+		t.Error("expected error for missing contract address")
+	}
+}
+
+const testTokenABI = `[{"inputs":[{"name":"name","type":"string"},{"name":"symbol","type":"string"},{"name":"decimals","type":"uint8"}],
+"stateMutability":"nonpayable","type":"constructor"}]`
+
+func TestDeployContract_constructorArgs(t *testing.T) {
+	const code = "0x6080604052"
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+
+	if _, err := web3.DeployContract(ctx, c, devKey, code, testTokenABI, 2000000, "Token", "TKN", "18"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	myabi := mustParseABI(testTokenABI)
+	args, err := myabi.Pack("", "Token", "TKN", uint8(18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(hexutil.MustDecode(code), args...)
+	if got := c.Sent()[0].Data(); !bytes.Equal(got, want) {
+		t.Errorf("expected data %x but got %x", want, got)
+	}
+
+	for _, tt := range []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"count", []interface{}{"Token", "TKN"}, "mismatched argument (3) and parameter (2) counts"},
+		{"type", []interface{}{"Token", "TKN", "eighteen"}, `argument 2 "decimals": expected uint8 but got string`},
+		{"float", []interface{}{"Token", "TKN", 18.0}, `argument 2 "decimals": expected uint8 but got float64`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := web3.DeployContract(ctx, c, devKey, code, testTokenABI, 2000000, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q but got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDeployContractWithABI(t *testing.T) {
+	const (
+		code     = "0x6080604052"
+		storeABI = `[{"inputs":[{"name":"count","type":"uint256"},{"name":"label","type":"string"}],"stateMutability":"nonpayable","type":"constructor"}]`
+	)
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(100000)
+	c.SetNonce(common.HexToAddress(devAddress), 7)
+
+	tx, addr, err := web3.DeployContractWithABI(ctx, c, devKey, storeABI, code, "42", "store")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := crypto.CreateAddress(common.HexToAddress(devAddress), 7); tx.Nonce != 7 || addr != want {
+		t.Errorf("expected contract address %s at nonce 7 but got %s at %d", want.Hex(), addr.Hex(), tx.Nonce)
+	}
+	args, err := mustParseABI(storeABI).Pack("", big.NewInt(42), "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := append(hexutil.MustDecode(code), args...), c.Sent()[0].Data(); !bytes.Equal(got, want) {
+		t.Errorf("expected data %x but got %x", want, got)
+	}
+
+	for _, tt := range []struct {
+		name string
+		abi  string
+		args []interface{}
+		want string
+	}{
+		{"bad abi", "{", []interface{}{"42", "store"}, "failed to parse ABI"},
+		{"no args", storeABI, nil, "got 0 arguments for 2 parameters"},
+		{"count", storeABI, []interface{}{"42"}, "got 1 arguments for 2 parameters"},
+		{"type", storeABI, []interface{}{"many", "store"}, `argument 0 "count": expected uint256 but got string`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := web3.DeployContractWithABI(ctx, c, devKey, tt.abi, code, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q but got: %v", tt.want, err)
+			}
+		})
+	}
+	if sent := c.Sent(); len(sent) != 1 {
+		t.Errorf("expected invalid deployments not to be sent, got %d sent", len(sent))
+	}
+}
+
+func TestContractAddress(t *testing.T) {
+	ctx := context.Background()
+	from := common.HexToAddress(devAddress)
+	for _, tt := range []struct {
+		name       string
+		opts       web3.DeployOptions
+		chainIDErr error
+	}{
+		{name: "eip155", opts: web3.DeployOptions{GasLimit: 100000, ChainID: big.NewInt(60)}},
+		{name: "homestead", opts: web3.DeployOptions{GasLimit: 100000, AllowHomestead: true}, chainIDErr: errors.New("not supported")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := web3test.NewFakeClient()
+			c.SetChainID(big.NewInt(60))
+			c.SetError("ChainID", tt.chainIDErr)
+			c.SetNonce(from, 4)
+			tx, err := web3.DeployContractWithOptions(ctx, c, devKey, "0x6080", "", tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := crypto.CreateAddress(from, 4)
+			if got, err := tx.ContractAddress(); err != nil || got != want {
+				t.Errorf("expected contract address %s but got %s: %v", want.Hex(), got.Hex(), err)
+			}
+			if got, err := web3.ContractAddress(c.Sent()[0]); err != nil || got != want {
+				t.Errorf("expected the same contract address from the signed transaction but got %s: %v", got.Hex(), err)
+			}
+		})
+	}
+
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	if _, err := web3.ContractAddress(tx); !errors.Is(err, web3.ErrNotContractCreation) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotContractCreation, err)
+	}
+	if _, err := web3.ConvertTx(tx, from).ContractAddress(); !errors.Is(err, web3.ErrNotContractCreation) {
+		t.Errorf("expected %v but got: %v", web3.ErrNotContractCreation, err)
+	}
+}
+
+func TestWaitForReceiptWithOptions(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	notFound := &web3.NotFoundError{Kind: "receipt", ID: hash.Hex()}
+	opts := web3.ReceiptOptions{PollInterval: time.Millisecond, MaxAttempts: 3}
+	ctx := context.Background()
+
+	c := web3test.NewFakeClient()
+	c.AddReceipt(&web3.Receipt{TxHash: hash, Status: 1, BlockNumber: 1})
+	c.FailNext("GetTransactionReceipt", notFound, notFound)
+	got, err := web3.WaitForReceiptWithOptions(ctx, c, hash, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.TxHash != hash {
+		t.Errorf("expected receipt for %s but got %s", hash.Hex(), got.TxHash.Hex())
+	}
+
+	c.FailNext("GetTransactionReceipt", notFound, notFound, notFound)
+	_, err = web3.WaitForReceiptWithOptions(ctx, c, hash, opts)
+	var timeout *web3.ReceiptTimeoutError
+	if !errors.As(err, &timeout) || timeout.Attempts != opts.MaxAttempts {
+		t.Errorf("expected *ReceiptTimeoutError after %d attempts but got: %v", opts.MaxAttempts, err)
+	}
+	if !errors.Is(err, web3.ErrReceiptTimeout) || !errors.Is(err, web3.NotFoundErr) {
+		t.Errorf("expected error matching %v and %v but got: %v", web3.ErrReceiptTimeout, web3.NotFoundErr, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	missing := common.HexToHash("0x02")
+	if _, err := web3.WaitForReceiptWithOptions(ctx, c, missing, web3.ReceiptOptions{PollInterval: time.Hour}); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWaitForPending(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, web3.Gwei(1), nil), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := web3.ConvertTx(signed, crypto.PubkeyToAddress(key.PublicKey))
+	notFound := &web3.NotFoundError{Kind: "transaction", ID: tx.Hash.Hex()}
+	opts := web3.ReceiptOptions{PollInterval: time.Millisecond, MaxAttempts: 3}
+	ctx := context.Background()
+
+	// Visible after two polls.
+	c := web3test.NewFakeClient()
+	c.AddTransaction(tx)
+	c.FailNext("GetTransactionByHash", notFound, notFound)
+	got, err := web3.WaitForPending(ctx, c, tx.Hash, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if polls := c.CallCount("GetTransactionByHash"); got.Hash != tx.Hash || polls != 3 {
+		t.Errorf("expected %s on the third poll but got %s after %d", tx.Hash.Hex(), got.Hash.Hex(), polls)
+	}
+
+	c.FailNext("GetTransactionByHash", notFound, notFound, notFound)
+	if _, err := web3.WaitForPending(ctx, c, tx.Hash, opts); !errors.Is(err, web3.ErrTxNotSeen) {
+		t.Errorf("expected %v after %d attempts but got: %v", web3.ErrTxNotSeen, opts.MaxAttempts, err)
+	}
+	_, err = web3.WaitForPending(ctx, c, common.HexToHash("0x02"), web3.ReceiptOptions{PollInterval: time.Millisecond, MaxDuration: 10 * time.Millisecond})
+	if !errors.Is(err, web3.ErrTxNotSeen) {
+		t.Errorf("expected %v after max duration but got: %v", web3.ErrTxNotSeen, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := web3.WaitForPending(ctx, c, common.HexToHash("0x02"), web3.ReceiptOptions{PollInterval: time.Hour}); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWaitMined(t *testing.T) {
+	ok, reverted := common.HexToHash("0x01"), common.HexToHash("0x02")
+	c := web3test.NewFakeClient()
+	c.AddReceipt(&web3.Receipt{TxHash: ok, Status: types.ReceiptStatusSuccessful, GasUsed: 21000})
+	c.AddReceipt(&web3.Receipt{TxHash: reverted, Status: types.ReceiptStatusFailed, GasUsed: 30000})
+	ctx := context.Background()
+
+	got, err := web3.WaitMined(ctx, c, ok)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.TxHash != ok {
+		t.Errorf("expected receipt for %s but got %s", ok.Hex(), got.TxHash.Hex())
+	}
+
+	got, err = web3.WaitMined(ctx, c, reverted)
+	var revertErr *web3.TxRevertedError
+	if !errors.As(err, &revertErr) || !errors.Is(err, web3.ErrTxReverted) {
+		t.Fatalf("expected *web3.TxRevertedError but got: %v", err)
+	}
+	if got == nil || revertErr.Receipt.TxHash != reverted {
+		t.Errorf("expected reverted receipt for %s but got %+v", reverted.Hex(), got)
+	}
+	if msg := err.Error(); !strings.Contains(msg, reverted.Hex()) || !strings.Contains(msg, "30000") {
+		t.Errorf("expected error with hash and gas used but got %q", msg)
+	}
+}
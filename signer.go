@@ -0,0 +1,227 @@
+package web3
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/gochain/gochain/v3/rlp"
+)
+
+// Signer signs transactions for an account, ie: with a local key, a hardware wallet, or a remote signing service.
+type Signer interface {
+	// Address returns the address of the signing account.
+	Address() common.Address
+	// SignTx returns tx signed for chainID with EIP-155, or without replay protection if chainID is nil.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// LocalSigner is a Signer using a private key in memory.
+type LocalSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewLocalSigner returns a Signer for key.
+func NewLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *LocalSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return SignTransaction(s.key, chainID, tx)
+}
+
+// SignTx signs tx with the account's key, so Account is a Signer.
+func (a *Account) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return SignTransaction(a.key, chainID, tx)
+}
+
+// SignTransaction signs tx with key for chainID with EIP-155, or without replay protection if chainID is nil. It
+// works offline: use EncodeTransaction to transport the result, and SendTransaction or Client.SendRawTransaction to
+// broadcast it.
+func SignTransaction(key *ecdsa.PrivateKey, chainID *big.Int, tx *types.Transaction) (*types.Transaction, error) {
+	var s types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		s = types.NewEIP155Signer(chainID)
+	}
+	return types.SignTx(tx, s, key)
+}
+
+// signerFromHex returns a LocalSigner for a hex private key, like KeyFromHex.
+func signerFromHex(privateKeyHex string) (Signer, error) {
+	key, _, err := KeyFromHex(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalSigner(key), nil
+}
+
+// EncodeTransaction returns the RLP encoding of tx, as sent by Client.SendRawTransaction.
+func EncodeTransaction(tx *types.Transaction) ([]byte, error) {
+	return rlp.EncodeToBytes(tx)
+}
+
+// DecodeTransaction decodes an RLP encoded transaction, ie: from EncodeTransaction.
+func DecodeTransaction(raw []byte) (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction encoding: %w", err)
+	}
+	return tx, nil
+}
+
+// TransactionDetails is a signed transaction decoded by DecodeRawTransaction, with the sender recovered from the
+// signature.
+type TransactionDetails struct {
+	Hash     common.Hash     `json:"hash"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"` // nil for contract creation
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Value    *hexutil.Big    `json:"value"`
+	GasLimit hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Data     hexutil.Bytes   `json:"input"`
+	// ChainID is nil for legacy transactions signed without EIP-155 replay protection.
+	ChainID   *hexutil.Big `json:"chainId"`
+	Protected bool         `json:"protected"`
+}
+
+// DecodeRawTransaction decodes a signed transaction from RLP hex, offline, and recovers its sender.
+func DecodeRawTransaction(hexStr string) (*TransactionDetails, error) {
+	raw, err := hexutil.Decode(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction hex: %w", err)
+	}
+	tx, err := DecodeTransaction(raw)
+	if err != nil {
+		return nil, err
+	}
+	d := &TransactionDetails{
+		Hash:      tx.Hash(),
+		To:        tx.To(),
+		Nonce:     hexutil.Uint64(tx.Nonce()),
+		Value:     (*hexutil.Big)(tx.Value()),
+		GasLimit:  hexutil.Uint64(tx.Gas()),
+		GasPrice:  (*hexutil.Big)(tx.GasPrice()),
+		Data:      tx.Data(),
+		Protected: tx.Protected(),
+	}
+	if d.Protected {
+		d.ChainID = (*hexutil.Big)(tx.ChainId())
+	}
+	d.From, err = txSender(tx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	return d, nil
+}
+
+// txSender recovers the sender of a signed transaction, with or without EIP-155 replay protection.
+func txSender(tx *types.Transaction) (common.Address, error) {
+	var txSigner types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		txSigner = types.NewEIP155Signer(tx.ChainId())
+	}
+	return types.Sender(txSigner, tx)
+}
+
+// BuildTransaction returns an unsigned transaction sending amount wei (nil for zero) and data from one address to
+// another, or creating a contract if to is empty. The nonce, gas price, and gas limit are filled in from the node
+// unless set in opts, but no key is needed, so it can be signed elsewhere with SignTransactionHex. A nonce from
+// opts.NonceSource is only released on error, so Release it if the returned transaction isn't sent.
+func BuildTransaction(ctx context.Context, client Client, from, to string, amount *big.Int, data []byte, opts TransactOptions) (*types.Transaction, error) {
+	fromAddress, err := parseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	var toAddress *common.Address
+	if to != "" {
+		addr, err := parseAddress(to)
+		if err != nil {
+			return nil, err
+		}
+		toAddress = &addr
+	}
+	if amount == nil {
+		amount = new(big.Int)
+	} else if amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount: %v", amount)
+	}
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = client.GetGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get gas price: %w", err)
+		}
+	}
+	reserved, err := reserveNonce(ctx, client, fromAddress, opts.Nonce, opts.NonceSource)
+	if err != nil {
+		return nil, err
+	}
+	defer reserved.release()
+	nonce := reserved.nonce
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		msg := CallMsg{From: fromAddress, To: toAddress, GasPrice: gasPrice, Value: amount, Data: data}
+		gasLimit, err = EstimateGasLimit(ctx, client, msg, opts.GasMultiplier)
+		if err != nil {
+			return nil, fmt.Errorf("cannot estimate gas limit: %w", err)
+		}
+	}
+	reserved.sent = true
+	if toAddress == nil {
+		return types.NewContractCreation(nonce, amount, gasLimit, gasPrice, data), nil
+	}
+	return types.NewTransaction(nonce, *toAddress, amount, gasLimit, gasPrice, data), nil
+}
+
+// SignTransactionHex is like SignTransaction, but takes a hex private key and returns the signed transaction as RLP
+// hex, for SendRawTransactionHex.
+func SignTransactionHex(tx *types.Transaction, privateKeyHex string, chainID *big.Int) (string, error) {
+	key, _, err := KeyFromHex(privateKeyHex)
+	if err != nil {
+		return "", err
+	}
+	signed, err := SignTransaction(key, chainID, tx)
+	if err != nil {
+		return "", fmt.Errorf("cannot sign transaction: %w", err)
+	}
+	raw, err := EncodeTransaction(signed)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(raw), nil
+}
+
+// SendRawTransactionHex is like SendRawTransaction, but takes the signed transaction as RLP hex, ie: from
+// SignTransactionHex.
+func SendRawTransactionHex(ctx context.Context, client Client, signedHex string) (common.Hash, error) {
+	raw, err := hexutil.Decode(signedHex)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid transaction hex: %w", err)
+	}
+	return SendRawTransaction(ctx, client, raw)
+}
+
+// SendRawTransaction broadcasts an RLP encoded signed transaction, ie: signed by another service, and returns its
+// hash. The transaction is decoded first, so malformed input never reaches the node. Node errors, like "nonce too
+// low", are returned as a *RPCError with the node's message.
+func SendRawTransaction(ctx context.Context, client Client, raw []byte) (common.Hash, error) {
+	tx, err := DecodeTransaction(raw)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := client.SendRawTransaction(ctx, raw); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
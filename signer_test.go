@@ -0,0 +1,287 @@
+package web3_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/gochain/gochain/v3/rlp"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+// remoteSigner is a Signer which only knows the address, and sends transactions to be signed by a separate
+// goroutine holding the key, like a hardware wallet or signing service.
+type remoteSigner struct {
+	address common.Address
+	reqs    chan signRequest
+}
+
+type signRequest struct {
+	raw     []byte
+	chainID *big.Int
+	resp    chan []byte
+}
+
+func newRemoteSigner(t *testing.T) *remoteSigner {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := make(chan signRequest)
+	t.Cleanup(func() { close(reqs) })
+	go func() {
+		for req := range reqs {
+			// Only the encoded transaction crosses the boundary.
+			var tx types.Transaction
+			if err := rlp.DecodeBytes(req.raw, &tx); err != nil {
+				close(req.resp)
+				continue
+			}
+			signed, err := web3.NewLocalSigner(key).SignTx(&tx, req.chainID)
+			if err != nil {
+				close(req.resp)
+				continue
+			}
+			raw, _ := rlp.EncodeToBytes(signed)
+			req.resp <- raw
+		}
+	}()
+	return &remoteSigner{address: crypto.PubkeyToAddress(key.PublicKey), reqs: reqs}
+}
+
+func (s *remoteSigner) Address() common.Address { return s.address }
+
+func (s *remoteSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make(chan []byte)
+	s.reqs <- signRequest{raw: raw, chainID: chainID, resp: resp}
+	signed, ok := <-resp
+	if !ok {
+		return nil, errors.New("remote signing failed")
+	}
+	var out types.Transaction
+	if err := rlp.DecodeBytes(signed, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func TestSigner_remote(t *testing.T) {
+	ctx := context.Background()
+	c := web3test.NewFakeClient()
+	c.SetChainID(big.NewInt(60))
+	c.SetGasEstimate(50000)
+	s := newRemoteSigner(t)
+	const to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+
+	if _, err := web3.TransferWithSigner(ctx, c, s, to, web3.Base(1), web3.TransferOptions{}); err != nil {
+		t.Fatalf("failed to transfer: %v", err)
+	}
+	if _, err := web3.DeployContractWithSigner(ctx, c, s, "0x6080", "", web3.DeployOptions{}); err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	sent := c.Sent()
+	for i, tx := range sent {
+		from, err := types.Sender(types.NewEIP155Signer(big.NewInt(60)), tx)
+		if err != nil {
+			t.Fatalf("tx %d: unexpected error: %v", i, err)
+		}
+		if from != s.Address() {
+			t.Errorf("tx %d: expected sender %s but got %s", i, s.Address().Hex(), from.Hex())
+		}
+	}
+	if len(sent) != 2 {
+		t.Errorf("expected 2 transactions sent but got %d", len(sent))
+	}
+}
+
+func TestLocalSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := web3.NewLocalSigner(key)
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	for _, chainID := range []*big.Int{nil, big.NewInt(60)} {
+		signed, err := s.SignTx(tx, chainID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := signed.Protected(); got != (chainID != nil) {
+			t.Errorf("expected replay protected %t for chain id %v", chainID != nil, chainID)
+		}
+		var txSigner types.Signer = types.HomesteadSigner{}
+		if chainID != nil {
+			txSigner = types.NewEIP155Signer(chainID)
+		}
+		if from, err := types.Sender(txSigner, signed); err != nil || from != s.Address() {
+			t.Errorf("expected sender %s but got %s: %v", s.Address().Hex(), from.Hex(), err)
+		}
+	}
+}
+
+func TestSignTransaction_offline(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	chainID := big.NewInt(60)
+	tx := types.NewTransaction(7, to, web3.Base(1), 21000, web3.Gwei(2), []byte{1, 2, 3})
+
+	signed, err := web3.SignTransaction(key, chainID, tx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw, err := web3.EncodeTransaction(signed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded, err := web3.DecodeTransaction(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Hash() != signed.Hash() {
+		t.Errorf("expected hash %s but got %s", signed.Hash().Hex(), decoded.Hash().Hex())
+	}
+	if decoded.Nonce() != 7 || *decoded.To() != to || decoded.Value().Cmp(web3.Base(1)) != 0 || string(decoded.Data()) != "\x01\x02\x03" {
+		t.Errorf("unexpected decoded transaction: %+v", decoded)
+	}
+	if got, err := types.Sender(types.NewEIP155Signer(chainID), decoded); err != nil || got != from {
+		t.Errorf("expected sender %s but got %s: %v", from.Hex(), got.Hex(), err)
+	}
+
+	c := web3test.NewFakeClient()
+	c.SetChainID(chainID)
+	if err := c.SendRawTransaction(context.Background(), raw); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	if sent := c.Sent(); len(sent) != 1 || sent[0].Hash() != signed.Hash() {
+		t.Errorf("expected %s to be sent", signed.Hash().Hex())
+	}
+
+	if _, err := web3.DecodeTransaction([]byte{1, 2, 3}); err == nil {
+		t.Error("expected error for invalid encoding")
+	}
+}
+
+func TestBuildTransaction_coldWallet(t *testing.T) {
+	ctx := context.Background()
+	const key, to = devKey, "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	from := common.HexToAddress(devAddress)
+	chainID := big.NewInt(60)
+	c := web3test.NewFakeClient()
+	c.SetChainID(chainID)
+	c.SetGasPrice(web3.Gwei(2))
+	c.SetGasEstimate(25000)
+	c.SetNonce(from, 3)
+
+	// Online, without the key.
+	tx, err := web3.BuildTransaction(ctx, c, from.Hex(), to, web3.Base(1), []byte{0xab}, web3.TransactOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Nonce() != 3 || tx.GasPrice().Cmp(web3.Gwei(2)) != 0 || tx.Gas() != uint64(25000*web3.DefaultGasMultiplier) {
+		t.Errorf("unexpected nonce %d, gas price %s, or gas %d", tx.Nonce(), tx.GasPrice(), tx.Gas())
+	}
+
+	// Offline.
+	signedHex, err := web3.SignTransactionHex(tx, key, chainID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Online again.
+	hash, err := web3.SendRawTransactionHex(ctx, c, signedHex)
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	sent := c.Sent()[0]
+	if sent.Hash() != hash {
+		t.Errorf("expected %s to be sent but got %s", hash.Hex(), sent.Hash().Hex())
+	}
+	if sent.Nonce() != tx.Nonce() || sent.Gas() != tx.Gas() || sent.GasPrice().Cmp(tx.GasPrice()) != 0 ||
+		*sent.To() != *tx.To() || sent.Value().Cmp(tx.Value()) != 0 || string(sent.Data()) != string(tx.Data()) {
+		t.Errorf("expected fields of %+v to round trip but got %+v", tx, sent)
+	}
+	if got, err := types.Sender(types.NewEIP155Signer(chainID), sent); err != nil || got != from {
+		t.Errorf("expected sender %s but got %s: %v", from.Hex(), got.Hex(), err)
+	}
+
+	create, err := web3.BuildTransaction(ctx, c, from.Hex(), "", nil, []byte{0x60}, web3.TransactOptions{GasLimit: 90000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if create.To() != nil || create.Gas() != 90000 {
+		t.Errorf("expected contract creation with gas 90000 but got %+v", create)
+	}
+	if _, err := web3.SendRawTransactionHex(ctx, c, "0xzz"); err == nil {
+		t.Error("expected error for invalid hex")
+	}
+}
+
+func TestDecodeRawTransaction(t *testing.T) {
+	// Example transaction from EIP-155, signed for chain id 1.
+	const eip155 = "0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	d, err := web3.DecodeRawTransaction(eip155)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := common.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"); d.From != want {
+		t.Errorf("expected sender %s but got %s", want.Hex(), d.From.Hex())
+	}
+	if !d.Protected || d.ChainID.ToInt().Int64() != 1 || d.Nonce != 9 || d.GasLimit != 21000 ||
+		d.GasPrice.ToInt().Cmp(web3.Gwei(20)) != 0 || d.Value.ToInt().Cmp(web3.Base(1)) != 0 ||
+		*d.To != common.HexToAddress("0x3535353535353535353535353535353535353535") {
+		t.Errorf("unexpected details: %+v", d)
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(b), `"chainId":"0x1"`) || !strings.Contains(string(b), `"nonce":"0x9"`) {
+		t.Errorf("expected hex fields but got %s", b)
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := types.NewContractCreation(1, nil, 90000, web3.Gwei(1), []byte{0x60})
+	signed, err := web3.SignTransaction(key, nil, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := web3.EncodeTransaction(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = web3.DecodeRawTransaction(hexutil.Encode(raw))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Protected || d.ChainID != nil || d.To != nil || d.From != crypto.PubkeyToAddress(key.PublicKey) || d.Hash != signed.Hash() {
+		t.Errorf("unexpected legacy details: %+v", d)
+	}
+
+	if _, err := web3.DecodeRawTransaction("0x1234"); err == nil {
+		t.Error("expected error for malformed RLP")
+	}
+	// Corrupt the signature's s value.
+	bad := eip155[:len(eip155)-64] + strings.Repeat("f", 64)
+	if _, err := web3.DecodeRawTransaction(bad); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("expected invalid signature error but got: %v", err)
+	}
+}
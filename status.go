@@ -0,0 +1,64 @@
+package web3
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+)
+
+// TxStatus is the state of a transaction, as reported by GetTransactionStatus.
+type TxStatus int
+
+const (
+	// TxUnknown is a transaction the node doesn't know, ie: never sent, dropped, or not yet propagated.
+	TxUnknown TxStatus = iota
+	// TxPending is a transaction waiting to be mined.
+	TxPending
+	// TxMined is a transaction mined successfully.
+	TxMined
+	// TxReverted is a transaction mined with a failed status.
+	TxReverted
+)
+
+func (s TxStatus) String() string {
+	switch s {
+	case TxUnknown:
+		return "unknown"
+	case TxPending:
+		return "pending"
+	case TxMined:
+		return "mined"
+	case TxReverted:
+		return "reverted"
+	}
+	return "invalid"
+}
+
+// GetTransactionStatus returns the status of the transaction with hash, from the transaction and its receipt. A
+// transaction which is mined between the two lookups is reported as mined. If the transaction is reported in a block
+// without a receipt, ie: during a reorg, both are fetched once more, and it is reported as pending if they still
+// disagree.
+func GetTransactionStatus(ctx context.Context, client Client, hash common.Hash) (TxStatus, error) {
+	for attempt := 1; ; attempt++ {
+		tx, err := client.GetTransactionByHash(ctx, hash)
+		if errors.Is(err, NotFoundErr) {
+			return TxUnknown, nil
+		} else if err != nil {
+			return TxUnknown, err
+		}
+		receipt, err := client.GetTransactionReceipt(ctx, hash)
+		if err == nil {
+			if receipt.Status == types.ReceiptStatusFailed {
+				return TxReverted, nil
+			}
+			return TxMined, nil
+		} else if !errors.Is(err, NotFoundErr) {
+			return TxUnknown, err
+		}
+		if tx.BlockNumber == nil || attempt == 2 {
+			return TxPending, nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+)
+
+// statusClient is a Client which serves scripted transactions and receipts, and panics on any other call. The last
+// of each is repeated, and nil entries are reported as not found.
+type statusClient struct {
+	Client
+	txs      []*Transaction
+	receipts []*Receipt
+	// receiptErr, if set, is returned for every receipt.
+	receiptErr error
+	calls      int
+}
+
+func (c *statusClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	c.calls++
+	tx := c.txs[0]
+	if len(c.txs) > 1 {
+		c.txs = c.txs[1:]
+	}
+	if tx == nil {
+		return nil, &NotFoundError{Kind: "transaction", ID: hash.Hex()}
+	}
+	return tx, nil
+}
+
+func (c *statusClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	c.calls++
+	if c.receiptErr != nil {
+		return nil, c.receiptErr
+	}
+	r := c.receipts[0]
+	if len(c.receipts) > 1 {
+		c.receipts = c.receipts[1:]
+	}
+	if r == nil {
+		return nil, &NotFoundError{Kind: "receipt", ID: hash.Hex()}
+	}
+	return r, nil
+}
+
+func TestGetTransactionStatus(t *testing.T) {
+	pending := &Transaction{}
+	mined := &Transaction{BlockNumber: big.NewInt(10)}
+	success := &Receipt{Status: types.ReceiptStatusSuccessful}
+	failed := &Receipt{Status: types.ReceiptStatusFailed}
+	for _, tt := range []struct {
+		name      string
+		txs       []*Transaction
+		receipts  []*Receipt
+		want      TxStatus
+		wantCalls int
+	}{
+		{name: "unknown", txs: []*Transaction{nil}, receipts: []*Receipt{nil}, want: TxUnknown, wantCalls: 1},
+		{name: "pending", txs: []*Transaction{pending}, receipts: []*Receipt{nil}, want: TxPending, wantCalls: 2},
+		{name: "mined", txs: []*Transaction{mined}, receipts: []*Receipt{success}, want: TxMined, wantCalls: 2},
+		{name: "reverted", txs: []*Transaction{mined}, receipts: []*Receipt{failed}, want: TxReverted, wantCalls: 2},
+		// Mined between the two calls.
+		{name: "pending-mined", txs: []*Transaction{pending}, receipts: []*Receipt{success}, want: TxMined, wantCalls: 2},
+		// Receipt not yet available, then checked again.
+		{name: "mined-lagging", txs: []*Transaction{mined}, receipts: []*Receipt{nil, success}, want: TxMined, wantCalls: 4},
+		// Reorged back into the pool.
+		{name: "mined-reorged", txs: []*Transaction{mined, pending}, receipts: []*Receipt{nil}, want: TxPending, wantCalls: 4},
+		{name: "mined-dropped", txs: []*Transaction{mined, nil}, receipts: []*Receipt{nil}, want: TxUnknown, wantCalls: 3},
+		{name: "mined-inconsistent", txs: []*Transaction{mined}, receipts: []*Receipt{nil}, want: TxPending, wantCalls: 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &statusClient{txs: tt.txs, receipts: tt.receipts}
+			got, err := GetTransactionStatus(context.Background(), c, common.HexToHash("0x01"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s but got %s", tt.want, got)
+			}
+			if c.calls != tt.wantCalls {
+				t.Errorf("expected %d calls but got %d", tt.wantCalls, c.calls)
+			}
+		})
+	}
+}
+
+func TestGetTransactionStatus_error(t *testing.T) {
+	nodeErr := errors.New("connection refused")
+	c := &statusClient{txs: []*Transaction{{}}, receiptErr: nodeErr}
+	if _, err := GetTransactionStatus(context.Background(), c, common.Hash{}); !errors.Is(err, nodeErr) {
+		t.Errorf("expected %v but got: %v", nodeErr, err)
+	}
+}
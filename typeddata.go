@@ -0,0 +1,379 @@
+package web3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/common/math"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+// ErrInvalidTypedData is returned, wrapped, when typed data has an unknown type, or a missing or invalid value.
+var ErrInvalidTypedData = errors.New("invalid typed data")
+
+// TypedData is EIP-712 typed structured data, as passed to eth_signTypedData_v4, ie: a permit or an order.
+//
+// Values in Domain and Message may be strings, numbers, bools, []byte, *big.Int, common.Address, slices for arrays,
+// and maps for structs. Integers may be decimal or 0x hex strings, and bytes must be 0x hex strings.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// TypedDataField is a named member of a struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// domainType is the name of the EIP-712 domain struct type.
+const domainType = "EIP712Domain"
+
+// domainFields are the standard fields of the domain, in order, for when Types doesn't include EIP712Domain.
+var domainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+// UnmarshalJSON decodes eth_signTypedData_v4 JSON, keeping numbers exact.
+func (td *TypedData) UnmarshalJSON(b []byte) error {
+	type typedData TypedData
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode((*typedData)(td))
+}
+
+// HashTypedData returns the EIP-712 hash of td, which is signed by eth_signTypedData_v4: the keccak256 of "\x19\x01",
+// the hash of the domain, and the hash of the message.
+func HashTypedData(td TypedData) (common.Hash, error) {
+	domainHash, err := td.hashStruct(domainType, td.Domain)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if td.PrimaryType == domainType {
+		return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainHash), nil
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return common.Hash{}, fmt.Errorf("%w: unknown primary type %q", ErrInvalidTypedData, td.PrimaryType)
+	}
+	messageHash, err := td.hashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainHash, messageHash), nil
+}
+
+// SignTypedData signs the EIP-712 hash of td with the private key, like eth_signTypedData_v4 and MetaMask. The 65 byte
+// signature is r, s, and v, with v as 27 or 28.
+func SignTypedData(privateKeyHex string, td TypedData) ([]byte, error) {
+	key, _, err := KeyFromHex(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := HashTypedData(td)
+	if err != nil {
+		return nil, err
+	}
+	return signHash(key, hash)
+}
+
+// RecoverTypedDataSigner returns the address which signed td, from a 65 byte signature as returned by SignTypedData.
+// v may be 0 or 1, or 27 or 28.
+func RecoverTypedDataSigner(td TypedData, signature []byte) (common.Address, error) {
+	hash, err := HashTypedData(td)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return recoverHashSigner(hash, signature)
+}
+
+// fields returns the fields of the struct type name.
+func (td *TypedData) fields(name string) ([]TypedDataField, bool) {
+	fields, ok := td.Types[name]
+	if !ok && name == domainType {
+		// Derive the domain type from the fields present, like ethers.js.
+		for _, f := range domainFields {
+			if _, ok := td.Domain[f.Name]; ok {
+				fields = append(fields, f)
+			}
+		}
+		return fields, true
+	}
+	return fields, ok
+}
+
+// encodeType returns the EIP-712 type encoding of the struct type name, ie: "Mail(Person from,Person to)Person(...)",
+// with the types it references sorted by name.
+func (td *TypedData) encodeType(name string) string {
+	deps := map[string]bool{}
+	td.dependencies(name, deps)
+	delete(deps, name)
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, t := range append([]string{name}, sorted...) {
+		fields, _ := td.fields(t)
+		b.WriteString(t)
+		b.WriteByte('(')
+		for i, f := range fields {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(f.Type)
+			b.WriteByte(' ')
+			b.WriteString(f.Name)
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// dependencies adds name and the struct types it references, recursively, to found.
+func (td *TypedData) dependencies(name string, found map[string]bool) {
+	if found[name] {
+		return
+	}
+	fields, ok := td.fields(name)
+	if !ok {
+		return
+	}
+	found[name] = true
+	for _, f := range fields {
+		td.dependencies(baseType(f.Type), found)
+	}
+}
+
+// hashStruct returns the keccak256 of the type hash and encoded fields of data, as struct type name.
+func (td *TypedData) hashStruct(name string, data map[string]interface{}) ([]byte, error) {
+	fields, _ := td.fields(name)
+	enc := make([]byte, 0, 32*(len(fields)+1))
+	enc = append(enc, crypto.Keccak256([]byte(td.encodeType(name)))...)
+	for _, f := range fields {
+		v, ok := data[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing field %q of %s", ErrInvalidTypedData, f.Name, name)
+		}
+		b, err := td.encodeValue(f.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q of %s: %w", f.Name, name, err)
+		}
+		enc = append(enc, b...)
+	}
+	if len(data) > len(fields) {
+		for k := range data {
+			if !hasField(fields, k) {
+				return nil, fmt.Errorf("%w: unknown field %q of %s", ErrInvalidTypedData, k, name)
+			}
+		}
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+func hasField(fields []TypedDataField, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// baseType returns typ without any array suffixes, ie: "Person" for "Person[2][]".
+func baseType(typ string) string {
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+// encodeValue returns the 32 byte EIP-712 encoding of v as typ.
+func (td *TypedData) encodeValue(typ string, v interface{}) ([]byte, error) {
+	if strings.HasSuffix(typ, "]") {
+		i := strings.LastIndexByte(typ, '[')
+		if i < 0 {
+			return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidTypedData, typ)
+		}
+		elemType, size := typ[:i], typ[i+1:len(typ)-1]
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("%w: expected array for %s but got %T", ErrInvalidTypedData, typ, v)
+		}
+		if size != "" {
+			n, err := strconv.Atoi(size)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidTypedData, typ)
+			}
+			if rv.Len() != n {
+				return nil, fmt.Errorf("%w: expected %d elements for %s but got %d", ErrInvalidTypedData, n, typ, rv.Len())
+			}
+		}
+		enc := make([]byte, 0, 32*rv.Len())
+		for j := 0; j < rv.Len(); j++ {
+			b, err := td.encodeValue(elemType, rv.Index(j).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", j, err)
+			}
+			enc = append(enc, b...)
+		}
+		return crypto.Keccak256(enc), nil
+	}
+	if _, ok := td.fields(typ); ok {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: expected object for %s but got %T", ErrInvalidTypedData, typ, v)
+		}
+		return td.hashStruct(typ, m)
+	}
+	return encodeAtomic(typ, v)
+}
+
+// encodeAtomic returns the 32 byte EIP-712 encoding of v as an atomic type, or string or bytes.
+func encodeAtomic(typ string, v interface{}) ([]byte, error) {
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: expected string but got %T", ErrInvalidTypedData, v)
+		}
+		return crypto.Keccak256([]byte(s)), nil
+	case "bytes":
+		b, err := typedBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+	case "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: expected bool but got %T", ErrInvalidTypedData, v)
+		}
+		enc := make([]byte, 32)
+		if b {
+			enc[31] = 1
+		}
+		return enc, nil
+	case "address":
+		var addr common.Address
+		switch a := v.(type) {
+		case common.Address:
+			addr = a
+		case string:
+			var err error
+			addr, err = parseAddress(a)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: expected address but got %T", ErrInvalidTypedData, v)
+		}
+		return common.LeftPadBytes(addr.Bytes(), 32), nil
+	}
+	if strings.HasPrefix(typ, "bytes") {
+		n, err := strconv.Atoi(typ[len("bytes"):])
+		if err != nil || n < 1 || n > 32 {
+			return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidTypedData, typ)
+		}
+		b, err := typedBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != n {
+			return nil, fmt.Errorf("%w: expected %d bytes for %s but got %d", ErrInvalidTypedData, n, typ, len(b))
+		}
+		return common.RightPadBytes(b, 32), nil
+	}
+	signed := strings.HasPrefix(typ, "int")
+	if signed || strings.HasPrefix(typ, "uint") {
+		bits, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"))
+		if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidTypedData, typ)
+		}
+		i, err := typedInt(v)
+		if err != nil {
+			return nil, err
+		}
+		if signed {
+			limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+			if i.Cmp(limit) >= 0 || i.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return nil, fmt.Errorf("%w: %s overflows %s", ErrInvalidTypedData, i, typ)
+			}
+			return math.PaddedBigBytes(math.U256(new(big.Int).Set(i)), 32), nil
+		}
+		if i.Sign() < 0 || i.BitLen() > bits {
+			return nil, fmt.Errorf("%w: %s overflows %s", ErrInvalidTypedData, i, typ)
+		}
+		return math.PaddedBigBytes(i, 32), nil
+	}
+	return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidTypedData, typ)
+}
+
+// typedBytes returns v as bytes, from 0x hex or []byte.
+func typedBytes(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case hexutil.Bytes:
+		return b, nil
+	case string:
+		d, err := hexutil.Decode(b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid bytes %q: %v", ErrInvalidTypedData, b, err)
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("%w: expected bytes but got %T", ErrInvalidTypedData, v)
+}
+
+// typedInt returns v as an integer, from a number, or a decimal or 0x hex string.
+func typedInt(v interface{}) (*big.Int, error) {
+	var s string
+	switch i := v.(type) {
+	case *big.Int:
+		return i, nil
+	case int:
+		return big.NewInt(int64(i)), nil
+	case int64:
+		return big.NewInt(i), nil
+	case uint64:
+		return new(big.Int).SetUint64(i), nil
+	case float64:
+		if i != float64(int64(i)) || i > 1<<53 || i < -(1<<53) {
+			return nil, fmt.Errorf("%w: inexact integer %v", ErrInvalidTypedData, i)
+		}
+		return big.NewInt(int64(i)), nil
+	case json.Number:
+		s = string(i)
+	case string:
+		s = i
+	default:
+		return nil, fmt.Errorf("%w: expected integer but got %T", ErrInvalidTypedData, v)
+	}
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n, ok = n.SetString(s[2:], 16)
+	} else {
+		n, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid integer %q", ErrInvalidTypedData, s)
+	}
+	return n, nil
+}
@@ -0,0 +1,216 @@
+package web3
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/crypto"
+)
+
+// mailTypedData is the example from EIP-712.
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func parseTypedData(t *testing.T, s string) TypedData {
+	var td TypedData
+	if err := json.Unmarshal([]byte(s), &td); err != nil {
+		t.Fatal(err)
+	}
+	return td
+}
+
+func TestHashTypedData(t *testing.T) {
+	td := parseTypedData(t, mailTypedData)
+
+	// Reference values from EIP-712.
+	if got, want := td.encodeType("Mail"), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; got != want {
+		t.Errorf("expected type %q but got %q", want, got)
+	}
+	for _, tt := range []struct {
+		name string
+		got  func() ([]byte, error)
+		want string
+	}{
+		{"domain", func() ([]byte, error) { return td.hashStruct(domainType, td.Domain) }, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"},
+		{"message", func() ([]byte, error) { return td.hashStruct("Mail", td.Message) }, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"},
+		{"typed data", func() ([]byte, error) { h, err := HashTypedData(td); return h.Bytes(), err }, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"},
+	} {
+		got, err := tt.got()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if common.BytesToHash(got) != common.HexToHash(tt.want) {
+			t.Errorf("%s: expected hash %s but got %x", tt.name, tt.want, got)
+		}
+	}
+
+	// Without EIP712Domain in types, it is derived from the domain fields.
+	delete(td.Types, domainType)
+	if h, err := HashTypedData(td); err != nil || h != common.HexToHash("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2") {
+		t.Errorf("expected the same hash with a derived domain type but got %s: %v", h.Hex(), err)
+	}
+}
+
+func TestSignTypedData(t *testing.T) {
+	td := parseTypedData(t, mailTypedData)
+	// The EIP-712 example is signed by keccak256("cow").
+	key := common.Bytes2Hex(crypto.Keccak256([]byte("cow")))
+	cow := common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826")
+
+	sig, err := SignTypedData(key, td)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := common.FromHex("0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c")
+	if common.Bytes2Hex(sig) != common.Bytes2Hex(want) {
+		t.Errorf("expected signature %x but got %x", want, sig)
+	}
+	if got, err := RecoverTypedDataSigner(td, sig); err != nil || got != cow {
+		t.Errorf("expected signer %s but got %s: %v", cow.Hex(), got.Hex(), err)
+	}
+	td.Message["contents"] = "Hello, Alice!"
+	if got, err := RecoverTypedDataSigner(td, sig); err != nil || got == cow {
+		t.Errorf("expected another signer for a modified message but got %s: %v", got.Hex(), err)
+	}
+}
+
+func TestHashTypedData_types(t *testing.T) {
+	td := parseTypedData(t, `{
+  "types": {
+    "Item": [
+      {"name": "id", "type": "uint256"},
+      {"name": "delta", "type": "int8"},
+      {"name": "tag", "type": "bytes4"},
+      {"name": "data", "type": "bytes"},
+      {"name": "ok", "type": "bool"}
+    ],
+    "Order": [
+      {"name": "items", "type": "Item[]"},
+      {"name": "owners", "type": "address[2]"},
+      {"name": "grid", "type": "uint8[][]"}
+    ]
+  },
+  "primaryType": "Order",
+  "domain": {"name": "Test", "chainId": "0x3c"},
+  "message": {
+    "items": [
+      {"id": 115792089237316195423570985008687907853269984665640564039457584007913129639935, "delta": -1, "tag": "0x01020304", "data": "0xff", "ok": true}
+    ],
+    "owners": ["0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"],
+    "grid": [[1, 2], []]
+  }
+}`)
+	if got, want := td.encodeType("Order"), "Order(Item[] items,address[2] owners,uint8[][] grid)Item(uint256 id,int8 delta,bytes4 tag,bytes data,bool ok)"; got != want {
+		t.Errorf("expected type %q but got %q", want, got)
+	}
+
+	// Build the expected encoding by hand.
+	word := func(b ...byte) []byte { return common.LeftPadBytes(b, 32) }
+	ones := make([]byte, 32)
+	for i := range ones {
+		ones[i] = 0xff
+	}
+	item := crypto.Keccak256(
+		crypto.Keccak256([]byte("Item(uint256 id,int8 delta,bytes4 tag,bytes data,bool ok)")),
+		ones, // max uint256, kept exact from JSON
+		ones, // -1
+		common.RightPadBytes([]byte{1, 2, 3, 4}, 32),
+		crypto.Keccak256([]byte{0xff}),
+		word(1),
+	)
+	order := crypto.Keccak256(
+		crypto.Keccak256([]byte(td.encodeType("Order"))),
+		crypto.Keccak256(item),
+		crypto.Keccak256(word(1), word(2)),
+		crypto.Keccak256(crypto.Keccak256(word(1), word(2)), crypto.Keccak256()),
+	)
+	domain := crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,uint256 chainId)")),
+		crypto.Keccak256([]byte("Test")),
+		word(60),
+	)
+	want := crypto.Keccak256Hash([]byte{0x19, 0x01}, domain, order)
+	if got, err := HashTypedData(td); err != nil || got != want {
+		t.Errorf("expected hash %s but got %s: %v", want.Hex(), got.Hex(), err)
+	}
+}
+
+func TestHashTypedData_errors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		typ     string
+		value   interface{}
+		wantErr error
+	}{
+		{"unknown type", "Unknown", "x", ErrInvalidTypedData},
+		{"bad int size", "uint7", 1, ErrInvalidTypedData},
+		{"bad bytes size", "bytes33", "0x00", ErrInvalidTypedData},
+		{"uint overflow", "uint8", 256, ErrInvalidTypedData},
+		{"negative uint", "uint256", -1, ErrInvalidTypedData},
+		{"int overflow", "int8", 128, ErrInvalidTypedData},
+		{"inexact float", "uint256", 1.5, ErrInvalidTypedData},
+		{"bad integer", "uint256", "ten", ErrInvalidTypedData},
+		{"wrong bytes length", "bytes4", "0x0102", ErrInvalidTypedData},
+		{"bad hex", "bytes", "ff", ErrInvalidTypedData},
+		{"not a bool", "bool", "true", ErrInvalidTypedData},
+		{"bad address", "address", "0x1234", ErrInvalidAddress},
+		{"not an array", "uint8[]", 1, ErrInvalidTypedData},
+		{"wrong array length", "uint8[2]", []interface{}{1}, ErrInvalidTypedData},
+		{"bad element", "uint8[]", []interface{}{1, 300}, ErrInvalidTypedData},
+		{"not an object", "Person", "Bob", ErrInvalidTypedData},
+		{"missing field", "Person", map[string]interface{}{"name": "Bob"}, ErrInvalidTypedData},
+		{"unknown field", "Person", map[string]interface{}{"name": "Bob", "wallet": common.Address{}, "age": 1}, ErrInvalidTypedData},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			td := TypedData{
+				Types: map[string][]TypedDataField{
+					"Person": {{Name: "name", Type: "string"}, {Name: "wallet", Type: "address"}},
+					"Test":   {{Name: "value", Type: tt.typ}},
+				},
+				PrimaryType: "Test",
+				Domain:      map[string]interface{}{"name": "Test"},
+				Message:     map[string]interface{}{"value": tt.value},
+			}
+			if _, err := HashTypedData(td); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v but got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	td := TypedData{Types: map[string][]TypedDataField{}, PrimaryType: "Mail", Domain: map[string]interface{}{}}
+	if _, err := HashTypedData(td); !errors.Is(err, ErrInvalidTypedData) {
+		t.Errorf("expected %v for an unknown primary type but got: %v", ErrInvalidTypedData, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gochain/gochain/v3/common"
 	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
 )
 
 type CallMsg struct {
@@ -18,6 +19,30 @@ type CallMsg struct {
 	Data     []byte          // input data, usually an ABI-encoded contract method invocation
 }
 
+// StateOverride replaces parts of an account's state for a single call with CallAt. Nil fields are left unchanged.
+type StateOverride struct {
+	Balance *big.Int // wei
+	Nonce   *uint64
+	Code    []byte
+	// State replaces all of the account's storage, while StateDiff only replaces the given slots. Only one may be set.
+	State     map[common.Hash]common.Hash
+	StateDiff map[common.Hash]common.Hash
+}
+
+func (o StateOverride) MarshalJSON() ([]byte, error) {
+	var r rpcStateOverride
+	r.copyFrom(&o)
+	return json.Marshal(&r)
+}
+
+func (o *StateOverride) UnmarshalJSON(data []byte) error {
+	var r rpcStateOverride
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	return r.copyTo(o)
+}
+
 type Snapshot struct {
 	Number  uint64                      `json:"number"`
 	Hash    common.Hash                 `json:"hash"`
@@ -39,6 +64,7 @@ type Tally struct {
 	Votes     int  `json:"votes"`
 }
 
+// ID identifies a network. Fields which Client.GetID could not look up are left nil, or zero for GenesisHash.
 type ID struct {
 	NetworkID   *big.Int    `json:"network_id"`
 	ChainID     *big.Int    `json:"chain_id"`
@@ -98,6 +124,7 @@ type Block struct {
 	MixHash         common.Hash
 	Nonce           types.BlockNonce
 	Hash            common.Hash
+	BaseFee         *big.Int // nil for networks without EIP-1559
 
 	// Only one of TxHashes or TxDetails will be populated.
 	TxHashes  []common.Hash
@@ -123,6 +150,58 @@ func (b *Block) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&r)
 }
 
+// Header is the header of a Block, without its transactions or uncles.
+type Header struct {
+	ParentHash      common.Hash
+	Sha3Uncles      common.Hash
+	Miner           common.Address
+	Signers         []common.Address
+	Voters          []common.Address
+	Signer          []byte
+	StateRoot       common.Hash
+	TxsRoot         common.Hash
+	ReceiptsRoot    common.Hash
+	LogsBloom       *types.Bloom
+	Difficulty      *big.Int
+	TotalDifficulty *big.Int
+	Number          *big.Int
+	GasLimit        uint64
+	GasUsed         uint64
+	Timestamp       time.Time
+	ExtraData       []byte
+	MixHash         common.Hash
+	Nonce           types.BlockNonce
+	Hash            common.Hash
+	BaseFee         *big.Int // nil for networks without EIP-1559
+}
+
+// Header returns the header fields of b.
+func (b *Block) Header() *Header {
+	return &Header{
+		ParentHash:      b.ParentHash,
+		Sha3Uncles:      b.Sha3Uncles,
+		Miner:           b.Miner,
+		Signers:         b.Signers,
+		Voters:          b.Voters,
+		Signer:          b.Signer,
+		StateRoot:       b.StateRoot,
+		TxsRoot:         b.TxsRoot,
+		ReceiptsRoot:    b.ReceiptsRoot,
+		LogsBloom:       b.LogsBloom,
+		Difficulty:      b.Difficulty,
+		TotalDifficulty: b.TotalDifficulty,
+		Number:          b.Number,
+		GasLimit:        b.GasLimit,
+		GasUsed:         b.GasUsed,
+		Timestamp:       b.Timestamp,
+		ExtraData:       b.ExtraData,
+		MixHash:         b.MixHash,
+		Nonce:           b.Nonce,
+		Hash:            b.Hash,
+		BaseFee:         b.BaseFee,
+	}
+}
+
 func (b *Block) ExtraVanity() string {
 	l := len(b.ExtraData)
 	if l > 32 {
@@ -174,3 +253,13 @@ func (t *Transaction) MarshalJSON() ([]byte, error) {
 	r.copyFrom(t)
 	return json.Marshal(&r)
 }
+
+// ContractAddress returns the address of the contract created by t, ie: as returned by DeployContract, computed from
+// its sender and nonce, so it is available before t is mined. It fails with ErrNotContractCreation if t isn't a
+// contract creation.
+func (t *Transaction) ContractAddress() (common.Address, error) {
+	if t.To != nil {
+		return common.Address{}, ErrNotContractCreation
+	}
+	return crypto.CreateAddress(t.From, t.Nonce), nil
+}
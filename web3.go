@@ -2,24 +2,29 @@ package web3
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"math/big"
+	"math/rand"
+	"net"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gochain/gochain/v3"
 	"github.com/gochain/gochain/v3/accounts/abi"
 	"github.com/gochain/gochain/v3/common"
 	"github.com/gochain/gochain/v3/common/hexutil"
 	"github.com/gochain/gochain/v3/core/types"
 	"github.com/gochain/gochain/v3/crypto"
 	"github.com/gochain/gochain/v3/rlp"
+	"github.com/gochain/gochain/v3/rpc"
 	"github.com/shopspring/decimal"
 )
 
@@ -42,6 +47,119 @@ func Gwei(g int64) *big.Int {
 	return i.Mul(i, weiPerGwei)
 }
 
+// Units of value accepted by ToWei, ParseUnit, FromWei and FormatUnit.
+const (
+	UnitWei   = "wei"
+	UnitGwei  = "gwei"
+	UnitEther = "ether"
+)
+
+// unitDecimals is the number of decimals of each unit, relative to wei. The GoChain names are aliases.
+var unitDecimals = map[string]int{
+	UnitWei:   0,
+	"attogo":  0,
+	UnitGwei:  9,
+	"nanogo":  9,
+	UnitEther: 18,
+	"eth":     18,
+	"go":      18,
+}
+
+// ToWei converts amount of unit to wei. The amount is converted via its shortest decimal representation, so 0.1
+// is exactly 1e17 wei, but float64 only holds about 16 significant digits. Use ParseUnit for exact amounts.
+func ToWei(amount float64, unit string) (*big.Int, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return nil, fmt.Errorf("invalid amount: %v", amount)
+	}
+	return ParseUnit(strconv.FormatFloat(amount, 'f', -1, 64), unit)
+}
+
+// ParseUnit parses a decimal amount of unit, like "1.5" ether, to wei without losing precision. Amounts with more
+// decimals than the unit has are rejected, as is scientific notation.
+func ParseUnit(amount, unit string) (*big.Int, error) {
+	decimals, ok := unitDecimals[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+	return parseAmount(amount, decimals)
+}
+
+// ParseDecimals parses a decimal amount, like "1.5", of a token with decimals to its base units without losing
+// precision, ie: "1.5" with 6 decimals is 1500000. It is ParseUnit for tokens.
+func ParseDecimals(amount string, decimals uint8) (*big.Int, error) {
+	return parseAmount(amount, int(decimals))
+}
+
+// parseAmount parses a decimal amount, with an optional leading minus sign, to base units with decimals.
+func parseAmount(amount string, decimals int) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	abs := strings.TrimPrefix(amount, "-")
+	if strings.ContainsAny(abs, "eE") {
+		return nil, fmt.Errorf("invalid amount %q: scientific notation is not supported", amount)
+	}
+	for _, r := range abs {
+		if (r < '0' || r > '9') && r != '.' {
+			return nil, fmt.Errorf("invalid amount %q: unexpected %q", amount, r)
+		}
+	}
+	if abs == "" || abs == "." {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	if strings.HasPrefix(abs, ".") {
+		abs = "0" + abs
+	}
+	v, err := parseUnit(abs, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil), decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	if amount != abs && strings.HasPrefix(amount, "-") {
+		v.Neg(v)
+	}
+	return v, nil
+}
+
+// FromWei converts wei to unit, ie: 1e18 wei to 1 ether. Use FormatUnit to display amounts exactly.
+func FromWei(wei *big.Int, unit string) (*big.Float, error) {
+	decimals, ok := unitDecimals[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+	div := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	f := new(big.Float).SetPrec(256).SetInt(wei)
+	return f.Quo(f, new(big.Float).SetPrec(256).SetInt(div)), nil
+}
+
+// FormatUnit formats wei as an exact decimal amount of unit, without trailing zeros, ie: 1500000000000000000 wei is
+// "1.5" ether. It is the inverse of ParseUnit.
+func FormatUnit(wei *big.Int, unit string) (string, error) {
+	decimals, ok := unitDecimals[strings.ToLower(unit)]
+	if !ok {
+		return "", fmt.Errorf("unknown unit %q", unit)
+	}
+	return formatAmount(wei, decimals), nil
+}
+
+// FormatDecimals formats an amount of a token's base units as an exact decimal amount, without trailing zeros,
+// ie: 1500000 with 6 decimals is "1.5". It is the inverse of ParseDecimals.
+func FormatDecimals(amount *big.Int, decimals uint8) string {
+	return formatAmount(amount, int(decimals))
+}
+
+func formatAmount(amount *big.Int, decimals int) string {
+	abs := new(big.Int).Abs(amount)
+	div := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(abs, div, new(big.Int))
+	s := whole.String()
+	if frac.Sign() != 0 {
+		fs := frac.String()
+		s += "." + strings.TrimRight(strings.Repeat("0", decimals-len(fs))+fs, "0")
+	}
+	if amount.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
 // WeiAsBase converts w wei in to the base unit, and formats it as a decimal fraction with full precision (up to 18 decimals).
 func WeiAsBase(w *big.Int) string {
 	return new(big.Rat).SetFrac(w, weiPerGO).FloatString(18)
@@ -82,14 +200,20 @@ func CallConstantFunction(ctx context.Context, client Client, myabi abi.ABI, add
 	if address == "" {
 		return nil, errors.New("no contract address specified")
 	}
-	fn := myabi.Methods[functionName]
+	if err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	fn, ok := myabi.Methods[functionName]
+	if !ok {
+		return nil, fmt.Errorf("method %q not found in ABI", functionName)
+	}
 	goParams, err := ConvertArguments(fn.Inputs, params)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid arguments for method %q: %w", functionName, err)
 	}
 	input, err := myabi.Pack(functionName, goParams...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to pack values: %v", err)
+		return nil, fmt.Errorf("failed to pack values: %w", err)
 	}
 	toAddress := common.HexToAddress(address)
 	res, err := client.Call(ctx, CallMsg{Data: input, To: &toAddress})
@@ -100,62 +224,134 @@ func CallConstantFunction(ctx context.Context, client Client, myabi abi.ABI, add
 	// fmt.Printf("RESPONSE: %v\n", string(res))
 	vals, err := fn.Outputs.UnpackValues(res)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unpack values from %s: %v", hexutil.Encode(res), err)
+		return nil, fmt.Errorf("failed to unpack values from %s: %w", hexutil.Encode(res), err)
 	}
 	return convertOutputParams(vals), nil
 }
 
-// CallTransactFunction submits a transaction to execute a smart contract function call.
+// CallContract parses abiJSON and calls the constant method on the contract at address, returning the
+// unpacked results. See CallConstantFunction.
+func CallContract(ctx context.Context, client Client, abiJSON, address, method string, params ...interface{}) ([]interface{}, error) {
+	myabi, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+	return CallConstantFunction(ctx, client, myabi, address, method, params...)
+}
+
+// CallTransactFunction submits a transaction to execute a smart contract function call, signed for the network's
+// chain id. It is SendContractTransaction with an already parsed ABI, and a gas limit which is estimated if 0.
 func CallTransactFunction(ctx context.Context, client Client, myabi abi.ABI, address, privateKeyHex, functionName string,
 	amount *big.Int, gasLimit uint64, params ...interface{}) (*Transaction, error) {
 	if address == "" {
 		return nil, errors.New("no contract address specified")
 	}
-	fn := myabi.Methods[functionName]
-	goParams, err := ConvertArguments(fn.Inputs, params)
+	s, err := signerFromHex(privateKeyHex)
 	if err != nil {
 		return nil, err
 	}
-	input, err := myabi.Pack(functionName, goParams...)
+	return sendContractTransaction(ctx, client, s, myabi, address, functionName, amount, TransactOptions{GasLimit: gasLimit}, params...)
+}
+
+// TransactOptions configures a contract method transaction.
+type TransactOptions struct {
+	GasLimit      uint64   // 0 to estimate
+	GasMultiplier float64  // applied to estimated gas limits, 0 for DefaultGasMultiplier
+	GasPrice      *big.Int // nil for the suggested gas price
+	Nonce         *uint64  // nil for the pending nonce
+	ChainID       *big.Int // nil to look up the chain id for EIP-155 signing
+
+	// NonceSource, if set and Nonce is nil, provides the nonce instead of the node, ie: a NonceManager. The nonce
+	// is released back to it if the transaction isn't sent.
+	NonceSource NonceSource
+	// AllowHomestead permits signing without replay protection when the chain id can't be looked up.
+	AllowHomestead bool
+}
+
+// SendContractTransaction submits a transaction calling method on the contract at contractAddress with
+// amount wei (nil for zero), signed for the network's chain id. The gas limit is estimated when
+// opts.GasLimit is 0. A non-zero amount is rejected for methods the ABI declares as not payable.
+func SendContractTransaction(ctx context.Context, client Client, privateKeyHex, abiJSON, contractAddress, method string,
+	amount *big.Int, opts TransactOptions, params ...interface{}) (*Transaction, error) {
+	s, err := signerFromHex(privateKeyHex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to pack values: %v", err)
-	}
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
+		return nil, err
 	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	return SendContractTransactionWithSigner(ctx, client, s, abiJSON, contractAddress, method, amount, opts, params...)
+}
+
+// SendContractTransactionWithSigner is like SendContractTransaction, but signs with s.
+func SendContractTransactionWithSigner(ctx context.Context, client Client, s Signer, abiJSON, contractAddress, method string,
+	amount *big.Int, opts TransactOptions, params ...interface{}) (*Transaction, error) {
+	myabi, err := abi.JSON(strings.NewReader(abiJSON))
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
 	}
-	gasPrice, err := client.GetGasPrice(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get gas price: %v", err)
+	return sendContractTransaction(ctx, client, s, myabi, contractAddress, method, amount, opts, params...)
+}
+
+// sendContractTransaction implements SendContractTransactionWithSigner with a parsed ABI.
+func sendContractTransaction(ctx context.Context, client Client, s Signer, myabi abi.ABI, contractAddress, method string,
+	amount *big.Int, opts TransactOptions, params ...interface{}) (*Transaction, error) {
+	if err := ValidateAddress(contractAddress); err != nil {
+		return nil, err
 	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
+	fn, ok := myabi.Methods[method]
 	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
+		return nil, fmt.Errorf("method %q not found in ABI", method)
+	}
+	if amount == nil {
+		amount = big.NewInt(0)
+	} else if amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %s", amount)
 	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
+	// Older ABIs only set the payable flag, which isn't parsed, so only a declared mutability is checked.
+	if amount.Sign() > 0 && fn.StateMutability != "" && fn.StateMutability != "payable" {
+		return nil, fmt.Errorf("cannot send value to %s method %q", fn.StateMutability, method)
+	}
+	goParams, err := ConvertArguments(fn.Inputs, params)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
+		return nil, fmt.Errorf("invalid arguments for method %q: %w", method, err)
 	}
-	toAddress := common.HexToAddress(address)
-	// fmt.Println("Price: ", gasPrice)
-	tx := types.NewTransaction(nonce, toAddress, amount, gasLimit, gasPrice, input)
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, privateKey)
+	input, err := myabi.Pack(method, goParams...)
 	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
+		return nil, fmt.Errorf("failed to pack values: %w", err)
 	}
-	raw, err := rlp.EncodeToBytes(signedTx)
+	fromAddress := s.Address()
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = client.GetGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get gas price: %w", err)
+		}
+	}
+	nonce, err := reserveNonce(ctx, client, fromAddress, opts.Nonce, opts.NonceSource)
 	if err != nil {
 		return nil, err
 	}
-	err = client.SendRawTransaction(ctx, raw)
+	defer nonce.release()
+	toAddress := common.HexToAddress(contractAddress)
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		msg := CallMsg{From: fromAddress, To: &toAddress, GasPrice: gasPrice, Value: amount, Data: input}
+		gasLimit, err = EstimateGasLimit(ctx, client, msg, opts.GasMultiplier)
+		if err != nil {
+			return nil, fmt.Errorf("cannot estimate gas limit: %w", err)
+		}
+	}
+	chainID, err := signingChainID(ctx, client, opts.ChainID, opts.AllowHomestead)
 	if err != nil {
-		return nil, fmt.Errorf("cannot send transaction: %v", err)
+		return nil, err
 	}
+	tx := types.NewTransaction(nonce.nonce, toAddress, amount, gasLimit, gasPrice, input)
+	signedTx, err := s.SignTx(tx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign transaction: %w", err)
+	}
+	if err := SendTransaction(ctx, client, signedTx); err != nil {
+		return nil, fmt.Errorf("cannot send transaction: %w", err)
+	}
+	nonce.sent = true
 	return convertTx(signedTx, fromAddress), nil
 }
 
@@ -180,55 +376,179 @@ func DeployBin(ctx context.Context, client Client,
 
 // DeployContract submits a contract creation transaction.
 // abiJSON is only required when including params for the constructor.
+// The address of the new contract is available immediately from the returned transaction's ContractAddress.
 func DeployContract(ctx context.Context, client Client, privateKeyHex string, binHex, abiJSON string, gasLimit uint64, constructorArgs ...interface{}) (*Transaction, error) {
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
+	return DeployContractWithOptions(ctx, client, privateKeyHex, binHex, abiJSON, DeployOptions{GasLimit: gasLimit}, constructorArgs...)
+}
+
+// DeployOptions configures a contract creation transaction.
+type DeployOptions struct {
+	GasLimit      uint64   // 0 to estimate
+	GasMultiplier float64  // applied to estimated gas limits, 0 for DefaultGasMultiplier
+	GasPrice      *big.Int // nil for the suggested gas price
+	Value         *big.Int // nil for zero, must be non-zero only for payable constructors
+	Nonce         *uint64  // nil for the pending nonce
+	ChainID       *big.Int // nil to look up the chain id for EIP-155 signing
+
+	NonceSource    NonceSource // as in TransactOptions
+	AllowHomestead bool        // as in TransactOptions
+
+	// FallbackGasLimit is used when gas estimation fails, instead of returning the error, if non-zero.
+	FallbackGasLimit uint64
+}
+
+// signingChainID returns chainID for Signer.SignTx, looking it up from client if nil. If the lookup fails and
+// allowHomestead is set, nil is returned instead, to sign without replay protection.
+func signingChainID(ctx context.Context, client Client, chainID *big.Int, allowHomestead bool) (*big.Int, error) {
+	if chainID != nil {
+		return chainID, nil
 	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	chainID, err := client.ChainID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
+		if allowHomestead {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot get chain id: %w", err)
 	}
+	return chainID, nil
+}
+
+// DefaultGasMultiplier is the safety margin applied to estimated gas limits, since estimates are often tight.
+const DefaultGasMultiplier = 1.2
 
-	gasPrice, err := client.GetGasPrice(ctx)
+// EstimateGasLimit returns the node's gas estimate for msg, scaled by multiplier (0 for DefaultGasMultiplier) as a
+// safety margin. If msg would revert, the error is a *RevertError, and when the node doesn't include the reason in
+// its error, msg is replayed with eth_call to decode it from the revert payload.
+func EstimateGasLimit(ctx context.Context, client Client, msg CallMsg, multiplier float64) (uint64, error) {
+	if multiplier == 0 {
+		multiplier = DefaultGasMultiplier
+	}
+	gas, err := client.EstimateGas(ctx, msg)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get gas price: %v", err)
+		var revertErr *RevertError
+		if errors.As(err, &revertErr) && revertErr.Reason == "" {
+			if reason, callErr := callRevertReason(ctx, client, msg, nil); callErr == nil {
+				return 0, &RevertError{Reason: reason, err: err}
+			}
+		}
+		return 0, err
 	}
+	return uint64(float64(gas) * multiplier), nil
+}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
+// transferGas is the gas used by a value transfer to an account without code.
+const transferGas = 21000
+
+// estimateTransferGas estimates the gas limit for msg, a value transfer. Transfers to accounts without code get
+// exactly transferGas, and multiplier is only applied above that, when the recipient is a contract.
+func estimateTransferGas(ctx context.Context, client Client, msg CallMsg, multiplier float64) (uint64, error) {
+	gas, err := EstimateGasLimit(ctx, client, msg, 1)
+	if err != nil {
+		return 0, err
 	}
+	if gas <= transferGas {
+		return transferGas, nil
+	}
+	if multiplier == 0 {
+		multiplier = DefaultGasMultiplier
+	}
+	return uint64(float64(gas) * multiplier), nil
+}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
+// EstimateGasTransfer returns the node's gas estimate for sending value wei from one address to another.
+func EstimateGasTransfer(ctx context.Context, client Client, from, to string, value *big.Int) (uint64, error) {
+	if err := ValidateAddress(from); err != nil {
+		return 0, err
+	}
+	if err := ValidateAddress(to); err != nil {
+		return 0, err
+	}
+	toAddress := common.HexToAddress(to)
+	return client.EstimateGas(ctx, CallMsg{From: common.HexToAddress(from), To: &toAddress, Value: value})
+}
+
+// EstimateDeployGas returns the node's gas estimate for deploying the contract creation data from the given
+// address, without any safety multiplier applied.
+func EstimateDeployGas(ctx context.Context, client Client, from common.Address, data []byte) (uint64, error) {
+	return client.EstimateGas(ctx, CallMsg{From: from, Data: data})
+}
+
+// DeployContractWithOptions submits a contract creation transaction, like DeployContract, using opts
+// to override the gas price, value, and nonce. The gas limit is estimated when opts.GasLimit is 0.
+// Transactions are signed with an EIP-155 signer for the network's chain id.
+func DeployContractWithOptions(ctx context.Context, client Client, privateKeyHex string, binHex, abiJSON string, opts DeployOptions, constructorArgs ...interface{}) (*Transaction, error) {
+	s, err := signerFromHex(privateKeyHex)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
+		return nil, err
+	}
+	return DeployContractWithSigner(ctx, client, s, binHex, abiJSON, opts, constructorArgs...)
+}
+
+// DeployContractWithAccount is like DeployContractWithOptions, but signs with acct, ie: from ImportKeystore.
+func DeployContractWithAccount(ctx context.Context, client Client, acct *Account, binHex, abiJSON string, opts DeployOptions, constructorArgs ...interface{}) (*Transaction, error) {
+	return DeployContractWithSigner(ctx, client, acct, binHex, abiJSON, opts, constructorArgs...)
+}
+
+// DeployContractWithSigner is like DeployContractWithOptions, but signs with s.
+func DeployContractWithSigner(ctx context.Context, client Client, s Signer, binHex, abiJSON string, opts DeployOptions, constructorArgs ...interface{}) (*Transaction, error) {
+	fromAddress := s.Address()
+	var err error
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = client.GetGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get gas price: %w", err)
+		}
+	}
+
+	nonce, err := reserveNonce(ctx, client, fromAddress, opts.Nonce, opts.NonceSource)
+	if err != nil {
+		return nil, err
+	}
+	defer nonce.release()
+	value := opts.Value
+	if value == nil {
+		value = big.NewInt(0)
 	}
 	binData, err := hexutil.Decode(binHex)
 	if err != nil {
-		return nil, fmt.Errorf("cannot decode contract data: %v", err)
+		return nil, fmt.Errorf("cannot decode contract data: %w", err)
 	}
 	if len(constructorArgs) > 0 {
 		abiData, err := abi.JSON(strings.NewReader(abiJSON))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse ABI: %v", err)
+			return nil, fmt.Errorf("failed to parse ABI: %w", err)
 		}
 		goParams, err := ConvertArguments(abiData.Constructor.Inputs, constructorArgs)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid constructor arguments: %w", err)
 		}
 		input, err := abiData.Pack("", goParams...)
 		if err != nil {
-			return nil, fmt.Errorf("cannot pack parameters: %v", err)
+			return nil, fmt.Errorf("cannot pack parameters: %w", err)
 		}
 		binData = append(binData, input...)
 	}
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		msg := CallMsg{From: fromAddress, GasPrice: gasPrice, Value: value, Data: binData}
+		gasLimit, err = EstimateGasLimit(ctx, client, msg, opts.GasMultiplier)
+		if err != nil {
+			if opts.FallbackGasLimit == 0 {
+				return nil, fmt.Errorf("cannot estimate gas limit: %w", err)
+			}
+			gasLimit = opts.FallbackGasLimit
+		}
+	}
 	//TODO try to use web3.Transaction only; can't sign currently
-	tx := types.NewContractCreation(nonce, big.NewInt(0), gasLimit, gasPrice, binData)
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, privateKey)
+	chainID, err := signingChainID(ctx, client, opts.ChainID, opts.AllowHomestead)
+	if err != nil {
+		return nil, err
+	}
+	tx := types.NewContractCreation(nonce.nonce, value, gasLimit, gasPrice, binData)
+	signedTx, err := s.SignTx(tx, chainID)
 	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
+		return nil, fmt.Errorf("cannot sign transaction: %w", err)
 	}
 	raw, err := rlp.EncodeToBytes(signedTx)
 	if err != nil {
@@ -236,49 +556,151 @@ func DeployContract(ctx context.Context, client Client, privateKeyHex string, bi
 	}
 	err = client.SendRawTransaction(ctx, raw)
 	if err != nil {
-		return nil, fmt.Errorf("cannot send transaction: %v", err)
+		return nil, fmt.Errorf("cannot send transaction: %w", err)
 	}
-
+	nonce.sent = true
 	return convertTx(signedTx, fromAddress), nil
 }
 
-func Send(ctx context.Context, client Client, privateKeyHex string, address common.Address, amount *big.Int) (*Transaction, error) {
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
+// DeployContractWithABI is like DeployContract, with an estimated gas limit, but the constructor args are checked
+// against abiJSON even if there are none, and the address the contract will be created at is returned too, before the
+// transaction is mined.
+func DeployContractWithABI(ctx context.Context, client Client, privateKeyHex string, abiJSON, binHex string, constructorArgs ...interface{}) (*Transaction, common.Address, error) {
+	abiData, err := ParseABI(abiJSON)
+	if err != nil {
+		return nil, common.Address{}, err
 	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	if inputs := abiData.Constructor.Inputs; len(inputs) != len(constructorArgs) {
+		return nil, common.Address{}, fmt.Errorf("invalid constructor arguments: got %d arguments for %d parameters", len(constructorArgs), len(inputs))
+	}
+	tx, err := DeployContractWithOptions(ctx, client, privateKeyHex, binHex, abiJSON, DeployOptions{}, constructorArgs...)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
+		return nil, common.Address{}, err
 	}
-	gasPrice, err := client.GetGasPrice(ctx)
+	addr, err := tx.ContractAddress()
 	if err != nil {
-		return nil, fmt.Errorf("cannot get gas price: %v", err)
+		return nil, common.Address{}, err
 	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
+	return tx, addr, nil
+}
+
+// Send sends amount wei from the account of privateKeyHex to address, signed for the network's chain id. It is
+// Transfer with the default options, so plain transfers use 21000 gas, except that the zero address is allowed.
+func Send(ctx context.Context, client Client, privateKeyHex string, address common.Address, amount *big.Int) (*Transaction, error) {
+	return Transfer(ctx, client, privateKeyHex, address.Hex(), amount, TransferOptions{AllowZeroAddress: true})
+}
+
+// TransferOptions configures a value transfer.
+type TransferOptions struct {
+	GasLimit      uint64   // 0 to estimate, which is 21000 unless the recipient is a contract
+	GasMultiplier float64  // applied to estimated gas limits above 21000, 0 for DefaultGasMultiplier
+	GasPrice      *big.Int // nil for the suggested gas price
+	Nonce         *uint64  // nil for the pending nonce
+	ChainID       *big.Int // nil to look up the chain id for EIP-155 signing
+
+	NonceSource    NonceSource // as in TransactOptions
+	AllowHomestead bool        // as in TransactOptions
+
+	// AllowZeroAddress permits transfers to the zero address, which burns the amount.
+	AllowZeroAddress bool
+}
+
+// Transfer sends amount wei from the account of privateKeyHex to toAddress, signed for the network's chain id.
+func Transfer(ctx context.Context, client Client, privateKeyHex, toAddress string, amount *big.Int, opts TransferOptions) (*Transaction, error) {
+	s, err := signerFromHex(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return TransferWithSigner(ctx, client, s, toAddress, amount, opts)
+}
+
+// TransferWithAccount is like Transfer, but signs with acct, ie: from ImportKeystore.
+func TransferWithAccount(ctx context.Context, client Client, acct *Account, toAddress string, amount *big.Int, opts TransferOptions) (*Transaction, error) {
+	return TransferWithSigner(ctx, client, acct, toAddress, amount, opts)
+}
+
+// TransferWithSigner is like Transfer, but signs with s.
+func TransferWithSigner(ctx context.Context, client Client, s Signer, toAddress string, amount *big.Int, opts TransferOptions) (*Transaction, error) {
+	fromAddress := s.Address()
+	if err := ValidateAddress(toAddress); err != nil {
+		return nil, err
+	}
+	to := common.HexToAddress(toAddress)
+	if to == (common.Address{}) && !opts.AllowZeroAddress {
+		return nil, errors.New("refusing to transfer to the zero address")
+	}
+	if amount == nil || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount: %v", amount)
+	}
+	var err error
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = client.GetGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get gas price: %w", err)
+		}
+	}
+	nonce, err := reserveNonce(ctx, client, fromAddress, opts.Nonce, opts.NonceSource)
+	if err != nil {
+		return nil, err
+	}
+	defer nonce.release()
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		msg := CallMsg{From: fromAddress, To: &to, GasPrice: gasPrice, Value: amount}
+		gasLimit, err = estimateTransferGas(ctx, client, msg, opts.GasMultiplier)
+		if err != nil {
+			return nil, fmt.Errorf("cannot estimate gas limit: %w", err)
+		}
 	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
+	chainID, err := signingChainID(ctx, client, opts.ChainID, opts.AllowHomestead)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
+		return nil, err
 	}
-	tx := types.NewTransaction(nonce, address, amount, 100000, gasPrice, nil)
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, privateKey)
+	tx := types.NewTransaction(nonce.nonce, to, amount, gasLimit, gasPrice, nil)
+	signedTx, err := s.SignTx(tx, chainID)
 	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
+		return nil, fmt.Errorf("cannot sign transaction: %w", err)
 	}
 	err = SendTransaction(ctx, client, signedTx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %v", err)
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
 	}
+	nonce.sent = true
 	return convertTx(signedTx, fromAddress), nil
 }
 
+// ErrNotContractCreation is returned for the contract address of a transaction which doesn't create a contract.
+var ErrNotContractCreation = errors.New("not a contract creation transaction")
+
+// ContractAddress is like Transaction.ContractAddress, for the signed transaction tx, whose sender is recovered
+// from its signature.
+func ContractAddress(tx *types.Transaction) (common.Address, error) {
+	if tx.To() != nil {
+		return common.Address{}, ErrNotContractCreation
+	}
+	from, err := txSender(tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("cannot recover sender: %w", err)
+	}
+	return convertTx(tx, from).ContractAddress()
+}
+
+// CreateAddress2 returns the address of a contract created with CREATE2 by deployer, from salt and the hash of its
+// init code, as defined by EIP-1014. It doesn't depend on the deployer's nonce, so it can be known before deployment.
+func CreateAddress2(deployer common.Address, salt [32]byte, initCodeHash common.Hash) common.Address {
+	return crypto.CreateAddress2(deployer, salt, initCodeHash[:])
+}
+
+// CreateAddress2FromCode is like CreateAddress2, but hashes the init code, ie: the contract bytecode with any ABI
+// encoded constructor args appended.
+func CreateAddress2FromCode(deployer common.Address, salt [32]byte, initCode []byte) common.Address {
+	return CreateAddress2(deployer, salt, crypto.Keccak256Hash(initCode))
+}
+
 // SendTransaction sends the Transaction
 func SendTransaction(ctx context.Context, client Client, signedTx *types.Transaction) error {
-	raw, err := rlp.EncodeToBytes(signedTx)
+	raw, err := EncodeTransaction(signedTx)
 	if err != nil {
 		return err
 	}
@@ -314,7 +736,7 @@ func ConvertArguments(args abi.Arguments, params []interface{}) ([]interface{},
 	for i, input := range args {
 		param, err := ConvertArgument(input.Type.T, input.Type.Size, params[i])
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("argument %d %q: expected %s but got %T: %w", i, input.Name, input.Type, params[i], err)
 		}
 		convertedParams = append(convertedParams, param)
 	}
@@ -361,8 +783,8 @@ func ConvertArgument(abiType byte, size int, param interface{}) (interface{}, er
 		}
 	case abi.AddressTy:
 		if s, ok := param.(string); ok {
-			if !common.IsHexAddress(s) {
-				return nil, fmt.Errorf("invalid hex address: %s", s)
+			if err := ValidateAddress(s); err != nil {
+				return nil, err
 			}
 			return common.HexToAddress(s), nil
 		}
@@ -399,7 +821,18 @@ func ConvertArgument(abiType byte, size int, param interface{}) (interface{}, er
 				return common.BytesToHash(val), nil
 			}
 		default:
-			return nil, fmt.Errorf("unsupported input byte array size %v", size)
+			if s, ok := param.(string); ok {
+				val, err := hexutil.Decode(s)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse bytes%d %q: %v", size, s, err)
+				}
+				if len(val) != size {
+					return nil, fmt.Errorf("invalid length %d: bytes%d must be %d bytes", len(val), size, size)
+				}
+				arr := reflect.New(reflect.ArrayOf(size, reflect.TypeOf(byte(0)))).Elem()
+				reflect.Copy(arr, reflect.ValueOf(val))
+				return arr.Interface(), nil
+			}
 		}
 	default:
 		return nil, fmt.Errorf("unsupported input type %v", abiType)
@@ -542,20 +975,254 @@ func ConvertInt(signed bool, size int, i *big.Int) (interface{}, error) {
 
 // WaitForReceipt polls for a transaction receipt until it is available, or ctx is cancelled.
 func WaitForReceipt(ctx context.Context, client Client, hash common.Hash) (*Receipt, error) {
-	for {
-		receipt, err := client.GetTransactionReceipt(ctx, hash)
+	return WaitForReceiptWithOptions(ctx, client, hash, ReceiptOptions{})
+}
+
+// ReceiptOptions configures how WaitForReceiptWithOptions, and WaitForPending, poll.
+type ReceiptOptions struct {
+	PollInterval time.Duration // 0 for 2 seconds
+	MaxAttempts  int           // 0 to poll until ctx is cancelled
+	MaxDuration  time.Duration // 0 to poll until ctx is cancelled
+
+	// Backoff multiplies the interval after each attempt, up to MaxInterval (if set). 0 or 1 for a fixed interval.
+	Backoff     float64
+	MaxInterval time.Duration
+	// Jitter adds a random fraction, up to Jitter, of the interval to each wait.
+	Jitter float64
+	// FailOnReorg makes WaitForConfirmations return ErrReorged if a reorg drops the transaction, instead of
+	// waiting for it to be included again.
+	FailOnReorg bool
+}
+
+// ErrReorged is returned by WaitForConfirmations when a reorg dropped the transaction and opts.FailOnReorg is set.
+var ErrReorged = errors.New("transaction dropped by reorg")
+
+// ErrReceiptTimeout is matched by the *ReceiptTimeoutError returned by WaitForReceiptWithOptions.
+var ErrReceiptTimeout = errors.New("timed out waiting for receipt")
+
+// ReceiptTimeoutError is returned when a receipt was still not available after ReceiptOptions.MaxAttempts or
+// MaxDuration. It matches ErrReceiptTimeout with errors.Is, and unwraps to the error from the last attempt, usually
+// NotFoundErr.
+type ReceiptTimeoutError struct {
+	Hash     common.Hash
+	Attempts int
+	err      error
+}
+
+func (e *ReceiptTimeoutError) Error() string {
+	return fmt.Sprintf("%v for %s after %d attempts: %v", ErrReceiptTimeout, e.Hash.Hex(), e.Attempts, e.err)
+}
+
+func (e *ReceiptTimeoutError) Is(target error) bool {
+	return target == ErrReceiptTimeout
+}
+
+func (e *ReceiptTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// WaitForReceiptWithOptions polls for a transaction receipt until it is available, opts.MaxAttempts or
+// opts.MaxDuration is reached, or ctx is cancelled. A *ReceiptTimeoutError is returned if the receipt was still not
+// available in time, and ctx's error if it is done first. Transient network errors are retried like a missing
+// receipt, but any other error is returned immediately.
+func WaitForReceiptWithOptions(ctx context.Context, client Client, hash common.Hash, opts ReceiptOptions) (*Receipt, error) {
+	var receipt *Receipt
+	attempts, timedOut, err := poll(ctx, opts, func(ctx context.Context) (err error) {
+		receipt, err = client.GetTransactionReceipt(ctx, hash)
+		return err
+	})
+	if timedOut {
+		return nil, &ReceiptTimeoutError{Hash: hash, Attempts: attempts, err: err}
+	} else if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// ErrTxNotSeen is returned, wrapped, by WaitForPending when the node still didn't know the transaction in time.
+var ErrTxNotSeen = errors.New("transaction not seen by node")
+
+// WaitForPending polls for the transaction with hash until the node knows it, pending or mined, and returns it. It
+// confirms that a sent transaction reached the node, ie: one behind a load balancer, before waiting for its receipt.
+// opts configures polling like WaitForReceiptWithOptions, and the error wraps ErrTxNotSeen if opts.MaxAttempts or
+// opts.MaxDuration is reached, or is ctx's error if it is done first.
+func WaitForPending(ctx context.Context, client Client, hash common.Hash, opts ReceiptOptions) (*Transaction, error) {
+	var tx *Transaction
+	attempts, timedOut, err := poll(ctx, opts, func(ctx context.Context) (err error) {
+		tx, err = client.GetTransactionByHash(ctx, hash)
+		return err
+	})
+	if timedOut {
+		return nil, fmt.Errorf("%w: %s after %d attempts: %v", ErrTxNotSeen, hash.Hex(), attempts, err)
+	} else if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// poll calls fn every opts.PollInterval until it succeeds, opts.MaxAttempts or opts.MaxDuration is reached, or ctx
+// is cancelled. Errors matching NotFoundErr and transient network errors are retried, but any other error is
+// returned immediately. It returns the number of attempts, and whether they ran out, with the last error from fn, or
+// ctx's error if it is done first.
+func poll(ctx context.Context, opts ReceiptOptions, fn func(context.Context) error) (int, bool, error) {
+	parent := ctx
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
+	}
+	interval := opts.PollInterval
+	if interval == 0 {
+		interval = 2 * time.Second
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
 		if err == nil {
-			return receipt, nil
+			return attempt, false, nil
+		}
+		if !errors.Is(err, NotFoundErr) && !isTransient(err) {
+			return attempt, false, err
 		}
-		if err != NotFoundErr {
-			return nil, err
+		if opts.MaxAttempts > 0 && attempt >= opts.MaxAttempts {
+			return attempt, true, err
+		}
+		wait := interval
+		if opts.Jitter > 0 {
+			wait += time.Duration(rand.Float64() * opts.Jitter * float64(interval))
+		}
+		select {
+		case <-ctx.Done():
+			if parent.Err() == nil {
+				// Only opts.MaxDuration is up.
+				return attempt, true, err
+			}
+			return attempt, false, ctx.Err()
+		case <-time.After(wait):
+		}
+		if opts.Backoff > 1 {
+			interval = time.Duration(float64(interval) * opts.Backoff)
+			if opts.MaxInterval > 0 && interval > opts.MaxInterval {
+				interval = opts.MaxInterval
+			}
+		}
+	}
+}
+
+// ErrTxReverted is matched by the *TxRevertedError returned by WaitMined.
+var ErrTxReverted = errors.New("transaction reverted")
+
+// TxRevertedError is returned when a mined transaction's receipt has a failed status. It matches ErrTxReverted with
+// errors.Is.
+type TxRevertedError struct {
+	Receipt *Receipt
+}
+
+func (e *TxRevertedError) Error() string {
+	return fmt.Sprintf("%v: %s used %d gas", ErrTxReverted, e.Receipt.TxHash.Hex(), e.Receipt.GasUsed)
+}
+
+func (e *TxRevertedError) Is(target error) bool {
+	return target == ErrTxReverted
+}
+
+// WaitMined is like WaitForReceipt, but also fails with a *TxRevertedError if the transaction reverted, so a nil
+// error means it succeeded. The receipt is returned either way once mined.
+func WaitMined(ctx context.Context, client Client, hash common.Hash) (*Receipt, error) {
+	receipt, err := WaitForReceipt(ctx, client, hash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return receipt, &TxRevertedError{Receipt: receipt}
+	}
+	return receipt, nil
+}
+
+// WaitForConfirmations waits for the transaction receipt, and then for confirmations more blocks to be mined on top
+// of the receipt's block. Before returning, the receipt is fetched again to make sure the transaction is still in the
+// canonical chain. If a reorg dropped it, this goes back to waiting for it to be included again, or returns
+// ErrReorged if opts.FailOnReorg is set. If it was included in a different block, confirmations are counted from
+// that block instead. The polling interval and receipt wait are configured by opts.
+func WaitForConfirmations(ctx context.Context, client Client, hash common.Hash, confirmations uint64, opts ReceiptOptions) (*Receipt, error) {
+	interval := opts.PollInterval
+	if interval == 0 {
+		interval = 2 * time.Second
+	}
+	receipt, err := WaitForReceiptWithOptions(ctx, client, hash, opts)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		head, err := client.GetLatestBlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get latest block number: %w", err)
+		}
+		if head >= receipt.BlockNumber+confirmations {
+			current, err := client.GetTransactionReceipt(ctx, hash)
+			if errors.Is(err, NotFoundErr) {
+				// Dropped by a reorg.
+				if opts.FailOnReorg {
+					return nil, fmt.Errorf("%s was in block %d (%s): %w", hash.Hex(), receipt.BlockNumber, receipt.BlockHash.Hex(), ErrReorged)
+				}
+				receipt, err = WaitForReceiptWithOptions(ctx, client, hash, opts)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			if current.BlockHash == receipt.BlockHash {
+				return current, nil
+			}
+			// Included in a different block after a reorg.
+			receipt = current
+			continue
 		}
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(2 * time.Second):
+		case <-time.After(interval):
+		}
+	}
+}
+
+// isTransient reports whether err is a temporary failure reaching the node, which is worth retrying, rather than
+// an error response from the node itself.
+func isTransient(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	return isRetryableStatus(err.Error())
+}
+
+// isRetryableStatus reports whether msg is the HTTP status of a rate limit or server error response, ie:
+// "503 Service Unavailable" followed by the body, which is all the rpc package reports of them. Standard codes
+// must be followed by their standard text, so other errors which happen to start with a number aren't mistaken
+// for one.
+func isRetryableStatus(msg string) bool {
+	if len(msg) < 5 || msg[3] != ' ' {
+		return false
+	}
+	for _, c := range msg[:3] {
+		if c < '0' || c > '9' {
+			return false
 		}
 	}
+	code, _ := strconv.Atoi(msg[:3])
+	if code != http.StatusTooManyRequests && (code < 500 || code > 599) {
+		return false
+	}
+	text := http.StatusText(code)
+	return text == "" || strings.HasPrefix(msg[4:], text)
 }
 
 func FindEventById(abi abi.ABI, id common.Hash) *abi.Event {
@@ -576,6 +1243,111 @@ func getInputs(args abi.Arguments, indexed bool) []abi.Argument {
 	return out
 }
 
+// FilterLogsByAddress returns the logs emitted by the contract at address between fromBlock and toBlock
+// (nil for genesis and latest respectively), optionally matching topics.
+func FilterLogsByAddress(ctx context.Context, client Client, address string, fromBlock, toBlock *big.Int, topics [][]common.Hash) ([]types.Log, error) {
+	if err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	return client.FilterLogs(ctx, gochain.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []common.Address{common.HexToAddress(address)},
+		Topics:    topics,
+	})
+}
+
+// FilterLogsChunked calls fn with the logs matching q, in block order, querying at most chunkSize blocks at a
+// time. Chunks which the node rejects as too large, by block range or number of results, are retried as two
+// halves. A nil q.ToBlock is resolved to the latest block before starting. Returning an error from fn, or
+// cancelling ctx, stops the iteration and returns that error.
+func FilterLogsChunked(ctx context.Context, client Client, q gochain.FilterQuery, chunkSize uint64, fn func([]types.Log) error) error {
+	if q.BlockHash != nil {
+		return errors.New("cannot chunk a query by block hash")
+	}
+	if chunkSize == 0 {
+		return errors.New("chunk size must be positive")
+	}
+	var from, to uint64
+	if q.FromBlock != nil {
+		from = q.FromBlock.Uint64()
+	}
+	if q.ToBlock != nil {
+		to = q.ToBlock.Uint64()
+	} else {
+		head, err := client.GetLatestBlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot get latest block number: %w", err)
+		}
+		to = head
+	}
+	if from > to {
+		return fmt.Errorf("invalid block range: fromBlock %d is after toBlock %d", from, to)
+	}
+	for from <= to {
+		end := to
+		if to-from >= chunkSize {
+			end = from + chunkSize - 1
+		}
+		if err := filterLogsRange(ctx, client, q, from, end, fn); err != nil {
+			return err
+		}
+		if end == to {
+			break
+		}
+		from = end + 1
+	}
+	return nil
+}
+
+// filterLogsRange calls fn with the logs matching q between from and to, splitting the range in half for as long
+// as the node reports it as too large.
+func filterLogsRange(ctx context.Context, client Client, q gochain.FilterQuery, from, to uint64, fn func([]types.Log) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.FromBlock = new(big.Int).SetUint64(from)
+	q.ToBlock = new(big.Int).SetUint64(to)
+	logs, err := client.FilterLogs(ctx, q)
+	if err != nil {
+		if from == to || !isLogLimitErr(err) {
+			return fmt.Errorf("cannot get logs for blocks %d to %d: %w", from, to, err)
+		}
+		mid := from + (to-from)/2
+		if err := filterLogsRange(ctx, client, q, from, mid, fn); err != nil {
+			return err
+		}
+		return filterLogsRange(ctx, client, q, mid+1, to, fn)
+	}
+	return fn(logs)
+}
+
+// logLimitErrs are fragments of the errors that nodes and providers return for log queries which span too many
+// blocks or match too many logs.
+var logLimitErrs = []string{
+	"query returned more than",
+	"block range",
+	"range is too large",
+	"too many",
+	"limit exceeded",
+	"response size exceeded",
+}
+
+// isLogLimitErr reports whether err is a log query size limit error, which may succeed with a smaller range.
+func isLogLimitErr(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, frag := range logLimitErrs {
+		if strings.Contains(msg, frag) {
+			return true
+		}
+	}
+	return false
+}
+
 // func ParseReceipt(myabi abi.ABI, receipt *Receipt) (map[string]map[string]interface{}, error) {
 func ParseLogs(myabi abi.ABI, logs []*types.Log) ([]Event, error) {
 	var output []Event
@@ -617,6 +1389,74 @@ func ParseLogs(myabi abi.ABI, logs []*types.Log) ([]Event, error) {
 	return output, nil
 }
 
+// DecodedEvent is a log, decoded as an event if it matched one in the ABI.
+type DecodedEvent struct {
+	Event
+	Log types.Log `json:"log"`
+	// Note explains why the log could not be decoded, in which case Event is empty.
+	Note string `json:"note,omitempty"`
+}
+
+// DecodeLogs decodes logs as events of abiJSON, with indexed and non-indexed inputs in Fields by name.
+// Indexed inputs of dynamic types, like string, are only available as the hash in the topic. Logs which can't be
+// decoded, like anonymous events, are returned with a Note rather than failing the whole decode.
+func DecodeLogs(abiJSON string, logs []types.Log) ([]DecodedEvent, error) {
+	myabi, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+	decoded := make([]DecodedEvent, len(logs))
+	for i, log := range logs {
+		decoded[i].Log = log
+		event, err := decodeLog(myabi, log)
+		if err != nil {
+			decoded[i].Note = err.Error()
+			continue
+		}
+		decoded[i].Event = *event
+	}
+	return decoded, nil
+}
+
+func decodeLog(myabi abi.ABI, log types.Log) (*Event, error) {
+	if len(log.Topics) == 0 {
+		return nil, errors.New("no topics, possibly an anonymous event")
+	}
+	event := FindEventById(myabi, log.Topics[0])
+	if event == nil {
+		return nil, fmt.Errorf("no event in ABI with id %s", log.Topics[0].Hex())
+	}
+	fields := make(map[string]interface{})
+	nonIndexed := event.Inputs.NonIndexed()
+	vals, err := nonIndexed.UnpackValues(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s data: %v", event.Name, err)
+	}
+	for i, v := range convertOutputParams(vals) {
+		fields[nonIndexed[i].Name] = v
+	}
+	indexed := getInputs(event.Inputs, true)
+	if len(indexed) != len(log.Topics)-1 {
+		return nil, fmt.Errorf("%s has %d indexed inputs but log has %d topics", event.Name, len(indexed), len(log.Topics)-1)
+	}
+	for i, input := range indexed {
+		topic := log.Topics[i+1]
+		switch input.Type.T {
+		case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy, abi.HashTy:
+			input.Indexed = false
+			vals, err := abi.Arguments{input}.UnpackValues(topic.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("failed to unpack %s topic %q: %v", event.Name, input.Name, err)
+			}
+			fields[input.Name] = convertOutputParams(vals)[0]
+		default:
+			// Dynamic types are hashed.
+			fields[input.Name] = topic
+		}
+	}
+	return &Event{Name: event.Name, Fields: fields}, nil
+}
+
 // ParseAmount parses a string (human readable amount with units ie 1go, 1nanogo...) and returns big.Int value of this string in wei/atto
 func ParseAmount(amount string) (*big.Int, error) {
 	var ret = new(big.Int)
@@ -660,11 +1500,11 @@ func ParseBigInt(value string) (*big.Int, error) {
 }
 
 func ParseGwei(g string) (*big.Int, error) {
-	return parseUnit(g, weiPerGwei, 9)
+	return parseAmount(g, 9)
 }
 
 func ParseBase(b string) (*big.Int, error) {
-	return parseUnit(b, weiPerGO, 18)
+	return parseAmount(b, 18)
 }
 
 func parseUnit(g string, mult *big.Int, digits int) (*big.Int, error) {
@@ -685,6 +1525,10 @@ func parseUnit(g string, mult *big.Int, digits int) (*big.Int, error) {
 		return nil, errors.New("invalid value: more than one decimal point")
 	}
 	decStr := parts[1]
+	if decStr == "" {
+		// A trailing decimal point, like "1.", has no fraction.
+		return whole, nil
+	}
 	if len(decStr) > digits {
 		return nil, fmt.Errorf("too many decimal digits %d: limit %d", len(decStr), digits)
 	}
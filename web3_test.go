@@ -1,13 +1,25 @@
 package web3
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"math/big"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gochain/gochain/v3"
 	"github.com/gochain/gochain/v3/accounts/abi"
 	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
 )
 
 func Test_parseParam(t *testing.T) {
@@ -45,6 +57,8 @@ func Test_parseParam(t *testing.T) {
 
 		{"hash<-hash", abi.FixedBytesTy, 32, common.HexToHash(hash), common.HexToHash(hash), false},
 		{"hash<-hex", abi.FixedBytesTy, 32, hash, common.HexToHash(hash), false},
+		{"bytes4<-bytes4", abi.FixedBytesTy, 4, [4]byte{0x80, 0xac, 0x58, 0xcd}, [4]byte{0x80, 0xac, 0x58, 0xcd}, false},
+		{"bytes4<-hex", abi.FixedBytesTy, 4, "0x80ac58cd", [4]byte{0x80, 0xac, 0x58, 0xcd}, false},
 
 		{"bytes<-bytes", abi.BytesTy, 0, common.Hex2Bytes("1234"), common.Hex2Bytes("1234"), false},
 		{"bytes<-hex", abi.BytesTy, 0, "0x1234", common.Hex2Bytes("1234"), false},
@@ -55,6 +69,7 @@ func Test_parseParam(t *testing.T) {
 		{"int256<-float64", abi.IntTy, 256, float64(1), nil, true},
 		{"uint8<-float", abi.UintTy, 8, 1.1, nil, true},
 		{"uint8<-negative-float", abi.UintTy, 8, -1.1, nil, true},
+		{"bytes4<-short-hex", abi.FixedBytesTy, 4, "0x80ac58", nil, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -101,3 +116,706 @@ func TestParseGwei(t *testing.T) {
 		})
 	}
 }
+
+func TestToWei(t *testing.T) {
+	for _, tt := range []struct {
+		amount float64
+		unit   string
+		exp    *big.Int
+	}{
+		{amount: 1, unit: UnitEther, exp: big.NewInt(1e18)},
+		{amount: 0.1, unit: UnitEther, exp: big.NewInt(1e17)},
+		{amount: 1.5, unit: "ETH", exp: big.NewInt(15e17)},
+		{amount: 1e-18, unit: UnitEther, exp: big.NewInt(1)},
+		{amount: 2.5, unit: UnitGwei, exp: big.NewInt(2500000000)},
+		{amount: 42, unit: UnitWei, exp: big.NewInt(42)},
+		{amount: -1, unit: UnitGwei, exp: big.NewInt(-1e9)},
+		{amount: 1e21, unit: UnitWei, exp: new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil)},
+	} {
+		got, err := ToWei(tt.amount, tt.unit)
+		if err != nil {
+			t.Errorf("%v %s: unexpected error: %v", tt.amount, tt.unit, err)
+		} else if got.Cmp(tt.exp) != 0 {
+			t.Errorf("%v %s: expected %s but got %s", tt.amount, tt.unit, tt.exp, got)
+		}
+	}
+	for _, bad := range []struct {
+		amount float64
+		unit   string
+	}{{1, "finney"}, {0.5, UnitWei}, {math.NaN(), UnitEther}} {
+		if got, err := ToWei(bad.amount, bad.unit); err == nil {
+			t.Errorf("%v %s: expected error but got %s", bad.amount, bad.unit, got)
+		}
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	for _, tt := range []struct {
+		amount  string
+		unit    string
+		exp     string
+		wantErr string
+	}{
+		{amount: "1", unit: UnitEther, exp: "1000000000000000000"},
+		{amount: "1.5", unit: UnitEther, exp: "1500000000000000000"},
+		{amount: " 1.5 ", unit: "ETH", exp: "1500000000000000000"},
+		{amount: "1.000000000000000001", unit: UnitEther, exp: "1000000000000000001"},
+		{amount: "0.000000000000000001", unit: UnitEther, exp: "1"},
+		{amount: ".5", unit: UnitGwei, exp: "500000000"},
+		{amount: "1.", unit: UnitGwei, exp: "1000000000"},
+		{amount: "1.", unit: UnitEther, exp: "1000000000000000000"},
+		{amount: "1.", unit: UnitWei, exp: "1"},
+		{amount: "-1.", unit: UnitWei, exp: "-1"},
+		{amount: "0001.10", unit: UnitGwei, exp: "1100000000"},
+		{amount: "-2.25", unit: UnitGwei, exp: "-2250000000"},
+		{amount: "-0", unit: UnitWei, exp: "0"},
+		{amount: "123456789012345678901234567890", unit: UnitWei, exp: "123456789012345678901234567890"},
+		{amount: "1.0000000000000000001", unit: UnitEther, wantErr: "too many decimal digits"},
+		{amount: "1.0000000001", unit: UnitGwei, wantErr: "too many decimal digits"},
+		{amount: "0.5", unit: UnitWei, wantErr: "too many decimal digits"},
+		{amount: "1e18", unit: UnitWei, wantErr: "scientific notation"},
+		{amount: "1.5E3", unit: UnitEther, wantErr: "scientific notation"},
+		{amount: "--1", unit: UnitEther, wantErr: "unexpected"},
+		{amount: "+1", unit: UnitEther, wantErr: "unexpected"},
+		{amount: "1.-5", unit: UnitEther, wantErr: "unexpected"},
+		{amount: "1,5", unit: UnitEther, wantErr: "unexpected"},
+		{amount: "1.2.3", unit: UnitEther, wantErr: "more than one decimal point"},
+		{amount: "", unit: UnitEther, wantErr: "invalid amount"},
+		{amount: "-", unit: UnitEther, wantErr: "invalid amount"},
+		{amount: ".", unit: UnitEther, wantErr: "invalid amount"},
+		{amount: "1", unit: "finney", wantErr: "unknown unit"},
+	} {
+		got, err := ParseUnit(tt.amount, tt.unit)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q %s: expected error containing %q but got %v, %v", tt.amount, tt.unit, tt.wantErr, got, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q %s: unexpected error: %v", tt.amount, tt.unit, err)
+		} else if got.String() != tt.exp {
+			t.Errorf("%q %s: expected %s but got %s", tt.amount, tt.unit, tt.exp, got)
+		}
+	}
+}
+
+func TestParseDecimals(t *testing.T) {
+	for _, tt := range []struct {
+		amount   string
+		decimals uint8
+		exp      string
+		wantErr  bool
+	}{
+		{amount: "1.5", decimals: 6, exp: "1500000"},
+		{amount: "0.000001", decimals: 6, exp: "1"},
+		{amount: "-0.000001", decimals: 6, exp: "-1"},
+		{amount: "42", decimals: 0, exp: "42"},
+		{amount: "42.", decimals: 0, exp: "42"},
+		{amount: "1.", decimals: 6, exp: "1000000"},
+		{amount: "1", decimals: 255, exp: "1" + strings.Repeat("0", 255)},
+		{amount: "0.0000001", decimals: 6, wantErr: true},
+		{amount: "0.1", decimals: 0, wantErr: true},
+		{amount: "1e6", decimals: 6, wantErr: true},
+		{amount: "NaN", decimals: 6, wantErr: true},
+	} {
+		got, err := ParseDecimals(tt.amount, tt.decimals)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q with %d decimals: expected error but got %s", tt.amount, tt.decimals, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q with %d decimals: unexpected error: %v", tt.amount, tt.decimals, err)
+		} else if got.String() != tt.exp {
+			t.Errorf("%q with %d decimals: expected %s but got %s", tt.amount, tt.decimals, tt.exp, got)
+		}
+	}
+}
+
+func TestFormatDecimals(t *testing.T) {
+	for _, tt := range []struct {
+		amount   string
+		decimals uint8
+		exp      string
+	}{
+		{amount: "0", decimals: 18, exp: "0"},
+		{amount: "1", decimals: 18, exp: "0.000000000000000001"},
+		{amount: "1500000000000000000", decimals: 18, exp: "1.5"},
+		{amount: "1000000000000000000", decimals: 18, exp: "1"},
+		{amount: "999999999999999999", decimals: 18, exp: "0.999999999999999999"},
+		{amount: "1000000000000000001", decimals: 18, exp: "1.000000000000000001"},
+		{amount: "-1500000", decimals: 6, exp: "-1.5"},
+		{amount: "-1", decimals: 6, exp: "-0.000001"},
+		{amount: "1234", decimals: 0, exp: "1234"},
+		{amount: "10", decimals: 1, exp: "1"},
+	} {
+		amount, _ := new(big.Int).SetString(tt.amount, 10)
+		got := FormatDecimals(amount, tt.decimals)
+		if got != tt.exp {
+			t.Errorf("%s with %d decimals: expected %q but got %q", tt.amount, tt.decimals, tt.exp, got)
+		}
+		// Round trip.
+		if back, err := ParseDecimals(got, tt.decimals); err != nil || back.Cmp(amount) != 0 {
+			t.Errorf("%s with %d decimals: expected %q to parse back but got %s: %v", tt.amount, tt.decimals, got, back, err)
+		}
+	}
+}
+
+func TestFormatUnit(t *testing.T) {
+	for _, tt := range []struct {
+		wei  *big.Int
+		unit string
+		exp  string
+	}{
+		{wei: big.NewInt(15e17), unit: UnitEther, exp: "1.5"},
+		{wei: big.NewInt(2500000000), unit: UnitGwei, exp: "2.5"},
+		{wei: big.NewInt(1), unit: UnitGwei, exp: "0.000000001"},
+		{wei: big.NewInt(42), unit: UnitWei, exp: "42"},
+		{wei: big.NewInt(-1e18), unit: "GO", exp: "-1"},
+	} {
+		got, err := FormatUnit(tt.wei, tt.unit)
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", tt.wei, tt.unit, err)
+		} else if got != tt.exp {
+			t.Errorf("%s wei in %s: expected %q but got %q", tt.wei, tt.unit, tt.exp, got)
+		}
+	}
+	if _, err := FormatUnit(big.NewInt(1), "finney"); err == nil {
+		t.Error("expected error for unknown unit")
+	}
+}
+
+func TestFromWei(t *testing.T) {
+	eth, err := FromWei(big.NewInt(1e18), UnitEther)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eth.Cmp(big.NewFloat(1)) != 0 {
+		t.Errorf("expected 1 ether but got %s", eth)
+	}
+	for _, g := range []float64{1, 1e-9, 123.456, 1e6} {
+		wei, err := ToWei(g, UnitGwei)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", g, err)
+		}
+		f, err := FromWei(wei, UnitGwei)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", g, err)
+		}
+		if back, _ := f.Float64(); back != g {
+			t.Errorf("expected round trip to %v gwei but got %v via %s wei", g, back, wei)
+		}
+	}
+	if _, err := FromWei(big.NewInt(1), "finney"); err == nil {
+		t.Error("expected error for unknown unit")
+	}
+}
+
+func TestEstimateDeployGas(t *testing.T) {
+	eth := &FakeEthService{Gas: 3000000}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	from := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	gas, err := EstimateDeployGas(context.Background(), c, from, []byte{0x60, 0x80})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gas != 3000000 {
+		t.Errorf("expected unadjusted estimate 3000000 but got %d", gas)
+	}
+	if got := eth.Estimated[0]; got["data"] != "0x6080" || got["to"] != nil || got["from"] != strings.ToLower(from.Hex()) {
+		t.Errorf("unexpected estimate request: %v", got)
+	}
+}
+
+func TestCreateAddress2(t *testing.T) {
+	// Test vectors from EIP-1014.
+	for _, tt := range []struct {
+		deployer, salt, initCode, want string
+	}{
+		{"0x0000000000000000000000000000000000000000", "0x00", "0x00", "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x00", "0x00", "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x000000000000000000000000feed000000000000000000000000000000000000", "0x00", "0xD04116cDd17beBE565EB2422F2497E06cC1C9833"},
+		{"0x0000000000000000000000000000000000000000", "0x00", "0xdeadbeef", "0x70f2b2914A2a4b783FaEFb75f459A580616Fcb5e"},
+		{"0x00000000000000000000000000000000deadbeef", "0xcafebabe", "0xdeadbeef", "0x60f3f640a8508fC6a86d45DF051962668E1e8AC7"},
+		{"0x00000000000000000000000000000000deadbeef", "0xcafebabe", "0x" + strings.Repeat("deadbeef", 11), "0x1d8bfDC5D46DC4f61D6b6115972536eBE6A8854C"},
+		{"0x0000000000000000000000000000000000000000", "0x00", "0x", "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0"},
+	} {
+		deployer, salt := common.HexToAddress(tt.deployer), common.HexToHash(tt.salt)
+		initCode := hexutil.MustDecode(tt.initCode)
+		want := common.HexToAddress(tt.want)
+		if got := CreateAddress2FromCode(deployer, salt, initCode); got != want {
+			t.Errorf("%s %s %s: expected %s but got %s", tt.deployer, tt.salt, tt.initCode, want.Hex(), got.Hex())
+		}
+		if got := CreateAddress2(deployer, salt, crypto.Keccak256Hash(initCode)); got != want {
+			t.Errorf("%s %s %s: expected %s from the hash but got %s", tt.deployer, tt.salt, tt.initCode, want.Hex(), got.Hex())
+		}
+	}
+}
+
+// receiptClient is a Client which serves receipts from fn, and panics on any other call.
+type receiptClient struct {
+	Client
+	fn func() (*Receipt, error)
+}
+
+func (c *receiptClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	return c.fn()
+}
+
+// chainClient is a Client which serves scripted receipts and heads, and panics on any other call.
+type chainClient struct {
+	Client
+	receipts []*Receipt // nil entries are reported as not found
+	heads    []uint64
+}
+
+func (c *chainClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	r := c.receipts[0]
+	if len(c.receipts) > 1 {
+		c.receipts = c.receipts[1:]
+	}
+	if r == nil {
+		return nil, NotFoundErr
+	}
+	return r, nil
+}
+
+func (c *chainClient) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	h := c.heads[0]
+	if len(c.heads) > 1 {
+		c.heads = c.heads[1:]
+	}
+	return h, nil
+}
+
+func TestWaitForConfirmations(t *testing.T) {
+	ctx := context.Background()
+	opts := ReceiptOptions{PollInterval: time.Millisecond}
+	a := &Receipt{BlockNumber: 10, BlockHash: common.HexToHash("0xa")}
+	b := &Receipt{BlockNumber: 12, BlockHash: common.HexToHash("0xb")}
+	for _, tt := range []struct {
+		name     string
+		receipts []*Receipt
+		heads    []uint64
+		want     *Receipt
+	}{
+		{name: "confirmed", receipts: []*Receipt{nil, a, a}, heads: []uint64{10, 11, 12}, want: a},
+		{name: "dropped", receipts: []*Receipt{a, nil, nil, b, b}, heads: []uint64{12, 14}, want: b},
+		{name: "moved", receipts: []*Receipt{a, b, b}, heads: []uint64{12, 13, 14}, want: b},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &chainClient{receipts: tt.receipts, heads: tt.heads}
+			got, err := WaitForConfirmations(ctx, c, common.Hash{}, 2, opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.BlockHash != tt.want.BlockHash {
+				t.Errorf("expected receipt in block %s but got %s", tt.want.BlockHash.Hex(), got.BlockHash.Hex())
+			}
+		})
+	}
+
+	opts.FailOnReorg = true
+	c := &chainClient{receipts: []*Receipt{a, nil, b}, heads: []uint64{12}}
+	if _, err := WaitForConfirmations(ctx, c, common.Hash{}, 2, opts); !errors.Is(err, ErrReorged) {
+		t.Errorf("expected %v but got: %v", ErrReorged, err)
+	}
+	c = &chainClient{receipts: []*Receipt{a, b, b}, heads: []uint64{12, 13, 14}}
+	if got, err := WaitForConfirmations(ctx, c, common.Hash{}, 2, opts); err != nil {
+		t.Errorf("expected moved transaction to be confirmed but got: %v", err)
+	} else if got.BlockHash != b.BlockHash {
+		t.Errorf("expected receipt in block %s but got %s", b.BlockHash.Hex(), got.BlockHash.Hex())
+	}
+}
+
+type testRPCError struct{ code int }
+
+func (e *testRPCError) Error() string  { return "rpc error" }
+func (e *testRPCError) ErrorCode() int { return e.code }
+
+func TestWaitForReceiptWithOptions_retries(t *testing.T) {
+	ctx := context.Background()
+	receipt := &Receipt{Status: 1}
+	transient := []error{
+		NotFoundErr,
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+		io.ErrUnexpectedEOF,
+		errors.New("503 Service Unavailable"),
+		errors.New("429 Too Many Requests"),
+	}
+	var calls []time.Time
+	c := &receiptClient{fn: func() (*Receipt, error) {
+		calls = append(calls, time.Now())
+		if len(calls) <= len(transient) {
+			return nil, transient[len(calls)-1]
+		}
+		return receipt, nil
+	}}
+	opts := ReceiptOptions{PollInterval: time.Millisecond, Backoff: 2, MaxInterval: 8 * time.Millisecond}
+	got, err := WaitForReceiptWithOptions(ctx, c, common.Hash{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != receipt {
+		t.Errorf("unexpected receipt: %v", got)
+	}
+	if len(calls) != len(transient)+1 {
+		t.Fatalf("expected %d calls but got %d", len(transient)+1, len(calls))
+	}
+	// Waits of 1, 2, 4, 8, and 8ms.
+	for i, min := range []time.Duration{1, 2, 4, 8, 8} {
+		if d := calls[i+1].Sub(calls[i]); d < min*time.Millisecond {
+			t.Errorf("wait %d: expected at least %s but got %s", i, min*time.Millisecond, d)
+		}
+	}
+
+	calls = nil
+	c.fn = func() (*Receipt, error) {
+		calls = append(calls, time.Now())
+		return nil, &testRPCError{code: -32602}
+	}
+	if _, err := WaitForReceiptWithOptions(ctx, c, common.Hash{}, opts); err == nil {
+		t.Error("expected permanent error")
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected permanent error to be returned after 1 call but got %d", len(calls))
+	}
+
+	c.fn = func() (*Receipt, error) { return nil, NotFoundErr }
+	opts = ReceiptOptions{PollInterval: time.Millisecond, MaxDuration: 20 * time.Millisecond}
+	if _, err := WaitForReceiptWithOptions(ctx, c, common.Hash{}, opts); !errors.Is(err, ErrReceiptTimeout) {
+		t.Errorf("expected %v but got: %v", ErrReceiptTimeout, err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("503 Service Unavailable"), true},
+		{errors.New("429 Too Many Requests"), true},
+		{errors.New("503 Service Unavailable upstream unavailable"), true},
+		{errors.New("521 Web Server Is Down"), true},
+		{fmt.Errorf("post failed: %w", io.EOF), true},
+		{errors.New("400 Bad Request"), false},
+		{errors.New("503 replacement transaction underpriced"), false},
+		{errors.New("5xx nonce too low"), false},
+		{errors.New("5.5 gwei is too low"), false},
+		{errors.New("500"), false},
+		{&testRPCError{code: -32000}, false},
+	} {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%q): expected %t but got %t", tt.err, tt.want, got)
+		}
+	}
+}
+
+const testInfoABI = `[{"constant":true,"inputs":[{"name":"id","type":"uint256"}],"name":"info",
+"outputs":[{"name":"supply","type":"uint256"},{"name":"name","type":"string"},{"name":"owner","type":"address"}],
+"payable":false,"stateMutability":"view","type":"function"}]`
+
+func TestCallContract(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(testInfoABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	supply := big.NewInt(1000)
+	wantInput, err := myabi.Pack("info", big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := myabi.Methods["info"].Outputs.Pack(supply, "Test", owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eth := &FakeEthService{CallFunc: func(msg map[string]interface{}) ([]byte, error) {
+		if data, _ := msg["data"].(string); data != hexutil.Encode(wantInput) {
+			return nil, fmt.Errorf("unexpected data %v", msg["data"])
+		}
+		return output, nil
+	}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+	const address = "0x0000000000000000000000000000000000000001"
+
+	got, err := CallContract(ctx, c, testInfoABI, address, "info", "7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []interface{}{supply, "Test", owner}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v but got %v", want, got)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		abi    string
+		method string
+		params []interface{}
+		want   string
+	}{
+		{"invalid-abi", "{", "info", []interface{}{"7"}, "failed to parse ABI"},
+		{"unknown-method", testInfoABI, "missing", []interface{}{"7"}, `method "missing" not found in ABI`},
+		{"arg-count", testInfoABI, "info", nil, `invalid arguments for method "info"`},
+		{"arg-type", testInfoABI, "info", []interface{}{"seven"}, `invalid arguments for method "info"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CallContract(ctx, c, tt.abi, address, tt.method, tt.params...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q but got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestFilterLogsChunked(t *testing.T) {
+	var logs []types.Log
+	for n := uint64(0); n < 100; n += 7 {
+		logs = append(logs, types.Log{Topics: []common.Hash{}, Data: []byte{}, BlockNumber: n})
+	}
+	eth := &FakeEthService{Logs: logs, MaxLogRange: 10, Head: 99}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	var got []uint64
+	err := FilterLogsChunked(ctx, c, gochain.FilterQuery{}, 32, func(batch []types.Log) error {
+		for _, l := range batch {
+			got = append(got, l.BlockNumber)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var want []uint64
+	for _, l := range logs {
+		want = append(want, l.BlockNumber)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected logs from blocks %v but got %v", want, got)
+	}
+	for _, f := range eth.Filters {
+		from, _ := hexutil.DecodeUint64(f["fromBlock"].(string))
+		to, _ := hexutil.DecodeUint64(f["toBlock"].(string))
+		if to-from+1 > 32 {
+			t.Errorf("expected chunks of at most 32 blocks but queried %d to %d", from, to)
+		}
+	}
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var batches int
+		err := FilterLogsChunked(ctx, c, gochain.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(99)}, 10, func([]types.Log) error {
+			batches++
+			cancel()
+			return nil
+		})
+		if err != context.Canceled {
+			t.Errorf("expected %v but got: %v", context.Canceled, err)
+		}
+		if batches != 1 {
+			t.Errorf("expected iteration to stop after 1 batch but got %d", batches)
+		}
+	})
+
+	t.Run("callback-error", func(t *testing.T) {
+		stop := errors.New("stop")
+		err := FilterLogsChunked(ctx, c, gochain.FilterQuery{ToBlock: big.NewInt(99)}, 10, func([]types.Log) error {
+			return stop
+		})
+		if err != stop {
+			t.Errorf("expected %v but got: %v", stop, err)
+		}
+	})
+
+	t.Run("reversed-range", func(t *testing.T) {
+		eth := &FakeEthService{}
+		c := newTestClient(t, map[string]interface{}{"eth": eth})
+		err := FilterLogsChunked(ctx, c, gochain.FilterQuery{FromBlock: big.NewInt(5), ToBlock: big.NewInt(1)}, 10, func([]types.Log) error {
+			return nil
+		})
+		if err == nil {
+			t.Error("expected error for reversed block range")
+		}
+	})
+}
+
+func TestFilterLogsByAddress(t *testing.T) {
+	const address = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d"
+	topic := common.HexToHash("0x0a")
+	log := types.Log{
+		Address:     common.HexToAddress(address),
+		Topics:      []common.Hash{topic},
+		Data:        []byte{},
+		BlockNumber: 5,
+	}
+	eth := &FakeEthService{Logs: []types.Log{log}}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	ctx := context.Background()
+
+	logs, err := FilterLogsByAddress(ctx, c, address, big.NewInt(1), big.NewInt(16), [][]common.Hash{{topic}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Address != log.Address || logs[0].BlockNumber != 5 {
+		t.Errorf("unexpected logs: %+v", logs)
+	}
+	want := map[string]interface{}{
+		"address":   []interface{}{address},
+		"topics":    []interface{}{[]interface{}{topic.Hex()}},
+		"fromBlock": "0x1",
+		"toBlock":   "0x10",
+	}
+	if len(eth.Filters) != 1 || !reflect.DeepEqual(eth.Filters[0], want) {
+		t.Errorf("expected filter %v but got %v", want, eth.Filters)
+	}
+
+	if _, err := FilterLogsByAddress(ctx, c, "0x01", nil, nil, nil); err == nil {
+		t.Error("expected error for invalid address")
+	}
+	if _, err := FilterLogsByAddress(ctx, c, address, big.NewInt(2), big.NewInt(1), nil); err == nil {
+		t.Error("expected error for reversed block range")
+	}
+	if len(eth.Filters) != 1 {
+		t.Errorf("expected invalid queries to be rejected before the request, got %d requests", len(eth.Filters))
+	}
+}
+
+func TestEstimateGasTransfer(t *testing.T) {
+	const from, to = "0xa25b5e2d2d63dad7fa940e239925f29320f5103d", "0x0000000000000000000000000000000000000001"
+	ctx := context.Background()
+	eth := &FakeEthService{Gas: 21000}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	gas, err := EstimateGasTransfer(ctx, c, from, to, Base(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if gas != 21000 {
+		t.Errorf("expected 21000 but got %d", gas)
+	}
+	want := map[string]interface{}{"from": from, "to": to, "value": hexutil.EncodeBig(Base(1))}
+	if !reflect.DeepEqual(eth.Estimated[0], want) {
+		t.Errorf("expected estimate request %v but got %v", want, eth.Estimated[0])
+	}
+	if _, err := EstimateGasTransfer(ctx, c, from, "0x01", nil); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
+
+func TestEstimateGasLimit(t *testing.T) {
+	ctx := context.Background()
+	to := common.HexToAddress("0x01")
+	msg := CallMsg{To: &to, Data: []byte{1}}
+	eth := &FakeEthService{Gas: 50000}
+	c := newTestClient(t, map[string]interface{}{"eth": eth})
+	for _, tt := range []struct {
+		multiplier float64
+		want       uint64
+	}{{0, 60000}, {1, 50000}, {1.5, 75000}} {
+		if gas, err := EstimateGasLimit(ctx, c, msg, tt.multiplier); err != nil || gas != tt.want {
+			t.Errorf("multiplier %v: expected %d but got %d: %v", tt.multiplier, tt.want, gas, err)
+		}
+	}
+
+	typ, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := abi.Arguments{{Type: typ}}.Pack("not owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload = append(errorSelector, payload...)
+	for _, tt := range []struct {
+		name       string
+		err        error
+		call       []byte
+		wantRevert bool
+		wantReason string
+	}{
+		{name: "reason", err: errors.New("execution reverted: not owner"), wantRevert: true, wantReason: "not owner"},
+		// The reason is decoded from the payload of a replay.
+		{name: "replayed", err: errors.New("execution reverted"), call: payload, wantRevert: true, wantReason: "not owner"},
+		{name: "no reason", err: errors.New("execution reverted"), wantRevert: true},
+		{name: "rpc error", err: errors.New("gas required exceeds allowance"), call: payload},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := &FakeEthService{EstimateErr: tt.err, CallFunc: func(map[string]interface{}) ([]byte, error) {
+				return tt.call, nil
+			}}
+			c := newTestClient(t, map[string]interface{}{"eth": eth})
+			_, err := EstimateGasLimit(ctx, c, msg, 0)
+			var revertErr *RevertError
+			if got := errors.As(err, &revertErr); got != tt.wantRevert {
+				t.Fatalf("expected revert error %t but got: %v", tt.wantRevert, err)
+			}
+			if !tt.wantRevert {
+				return
+			}
+			if revertErr.Reason != tt.wantReason || !strings.Contains(err.Error(), tt.wantReason) {
+				t.Errorf("expected reason %q but got: %v", tt.wantReason, err)
+			}
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) || rpcErr.Method != "eth_estimateGas" {
+				t.Errorf("expected the node's error to be wrapped but got: %v", err)
+			}
+		})
+	}
+}
+
+const testEventsABI = `[
+{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"key","type":"string"},{"indexed":false,"name":"value","type":"string"},{"indexed":false,"name":"data","type":"bytes"}],"name":"Set","type":"event"}]`
+
+func TestDecodeLogs(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(testEventsABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := common.HexToAddress("0xa25b5e2d2d63dad7fa940e239925f29320f5103d")
+	to := common.HexToAddress("0x01")
+	transferData, err := myabi.Events["Transfer"].Inputs.NonIndexed().Pack(big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setData, err := myabi.Events["Set"].Inputs.NonIndexed().Pack("bar", []byte{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyHash := crypto.Keccak256Hash([]byte("foo"))
+	logs := []types.Log{
+		{Topics: []common.Hash{myabi.Events["Transfer"].ID(), from.Hash(), to.Hash()}, Data: transferData},
+		{Topics: []common.Hash{common.HexToHash("0x1234")}},
+		{Topics: []common.Hash{myabi.Events["Set"].ID(), keyHash}, Data: setData},
+		{Data: []byte{1}},
+	}
+
+	decoded, err := DecodeLogs(testEventsABI, logs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(decoded) != len(logs) {
+		t.Fatalf("expected %d decoded logs but got %d", len(logs), len(decoded))
+	}
+	want := []Event{
+		{Name: "Transfer", Fields: map[string]interface{}{"from": from, "to": to, "value": big.NewInt(100)}},
+		{},
+		{Name: "Set", Fields: map[string]interface{}{"key": keyHash, "value": "bar", "data": []byte{1, 2}}},
+		{},
+	}
+	for i, d := range decoded {
+		if !reflect.DeepEqual(d.Event, want[i]) {
+			t.Errorf("log %d: expected %v but got %v", i, want[i], d.Event)
+		}
+		if (d.Note == "") != (want[i].Name != "") {
+			t.Errorf("log %d: unexpected note %q", i, d.Note)
+		}
+	}
+
+	if _, err := DecodeLogs("{", logs); err == nil {
+		t.Error("expected error for invalid ABI")
+	}
+}
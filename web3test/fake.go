@@ -0,0 +1,742 @@
+// Package web3test provides an in-memory web3.Client, and an in-process JSON-RPC server, for testing code which
+// uses web3 without a node.
+package web3test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/gochain/gochain/v3"
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/rlp"
+	"github.com/gochain/gochain/v3/rpc"
+	"github.com/gochain/web3"
+)
+
+// Call is a call made to a FakeClient, with the arguments after ctx.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// FakeClient is an in-memory web3.Client. Balances, code, blocks, transactions and receipts are seeded with its
+// Set and Add methods, every call is recorded for Calls, and errors can be scripted per method with SetError and
+// FailNext. Methods are named as in web3.Client, ie: "GetBalance".
+//
+// Missing blocks, transactions and receipts are reported with a *web3.NotFoundError. Subscriptions fail with an
+// error wrapping web3.ErrSubscriptionUnsupported, like an HTTP client, so web3.SubscribeNewHeads polls instead.
+// RawCall and BatchCall are not supported. A FakeClient is safe for concurrent use.
+type FakeClient struct {
+	mu           sync.Mutex
+	chainID      *big.Int
+	networkID    *big.Int
+	gasPrice     *big.Int
+	gasTipCap    *big.Int
+	gasEstimate  uint64
+	snapshots    []*web3.Snapshot
+	balances     map[common.Address]*big.Int
+	code         map[common.Address][]byte
+	storage      map[common.Address]map[common.Hash]common.Hash
+	nonces       map[common.Address]uint64
+	blocks       map[uint64]*web3.Block
+	blocksByHash map[common.Hash]*web3.Block
+	latest       *web3.Block
+	txs          map[common.Hash]*web3.Transaction
+	receipts     map[common.Hash]*web3.Receipt
+	callFunc     func(web3.CallMsg) ([]byte, error)
+	sent         []*types.Transaction
+	errs         map[string]error
+	next         map[string][]error
+	calls        []Call
+	closed       bool
+}
+
+var _ web3.Client = (*FakeClient)(nil)
+
+// NewFakeClient returns an empty FakeClient with chain and network id 1, and gas prices and tip caps of 1 gwei.
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		chainID:      big.NewInt(1),
+		networkID:    big.NewInt(1),
+		gasPrice:     big.NewInt(1e9),
+		gasTipCap:    big.NewInt(1e9),
+		gasEstimate:  21000,
+		balances:     make(map[common.Address]*big.Int),
+		code:         make(map[common.Address][]byte),
+		storage:      make(map[common.Address]map[common.Hash]common.Hash),
+		nonces:       make(map[common.Address]uint64),
+		blocks:       make(map[uint64]*web3.Block),
+		blocksByHash: make(map[common.Hash]*web3.Block),
+		txs:          make(map[common.Hash]*web3.Transaction),
+		receipts:     make(map[common.Hash]*web3.Receipt),
+		errs:         make(map[string]error),
+		next:         make(map[string][]error),
+	}
+}
+
+// SetChainID sets the chain id, which is also used to recover the senders of sent transactions.
+func (f *FakeClient) SetChainID(id *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chainID = id
+}
+
+// SetNetworkID sets the network id.
+func (f *FakeClient) SetNetworkID(id *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.networkID = id
+}
+
+// SetGasPrice sets the suggested gas price.
+func (f *FakeClient) SetGasPrice(price *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gasPrice = price
+}
+
+// SetGasTipCap sets the suggested priority fee.
+func (f *FakeClient) SetGasTipCap(tip *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gasTipCap = tip
+}
+
+// SetGasEstimate sets the result of EstimateGas. Defaults to 21000.
+func (f *FakeClient) SetGasEstimate(gas uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gasEstimate = gas
+}
+
+// AddSnapshot adds a clique snapshot, which is returned by GetSnapshotAt for blocks from s.Number until the next
+// snapshot. The snapshot with the highest number is returned by GetSnapshot.
+func (f *FakeClient) AddSnapshot(s *web3.Snapshot) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.snapshots = append(f.snapshots, s)
+}
+
+// SetBalance sets the balance of address.
+func (f *FakeClient) SetBalance(address common.Address, balance *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[address] = balance
+}
+
+// SetCode sets the code of address.
+func (f *FakeClient) SetCode(address common.Address, code []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.code[address] = code
+}
+
+// SetStorage sets the value of a storage slot of address.
+func (f *FakeClient) SetStorage(address common.Address, slot, value common.Hash) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.storage[address] == nil {
+		f.storage[address] = make(map[common.Hash]common.Hash)
+	}
+	f.storage[address][slot] = value
+}
+
+// SetNonce sets the pending nonce of address. It is incremented by each transaction sent from address.
+func (f *FakeClient) SetNonce(address common.Address, nonce uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nonces[address] = nonce
+}
+
+// SetCallFunc sets the function which handles Call. Without one, Call fails. It may call the FakeClient's methods.
+func (f *FakeClient) SetCallFunc(fn func(web3.CallMsg) ([]byte, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.callFunc = fn
+}
+
+// AddBlock adds b, which is numbered after the latest block if its Number is nil. The block with the highest
+// number is the latest. Transactions in b.TxDetails are added too.
+func (f *FakeClient) AddBlock(b *web3.Block) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if b.Number == nil {
+		b.Number = big.NewInt(0)
+		if f.latest != nil {
+			b.Number.Add(f.latest.Number, big.NewInt(1))
+		}
+	}
+	f.blocks[b.Number.Uint64()] = b
+	f.blocksByHash[b.Hash] = b
+	if f.latest == nil || b.Number.Cmp(f.latest.Number) >= 0 {
+		f.latest = b
+	}
+	for _, tx := range b.TxDetails {
+		f.txs[tx.Hash] = tx
+	}
+}
+
+// AddTransaction adds tx, to be found by its Hash.
+func (f *FakeClient) AddTransaction(tx *web3.Transaction) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.txs[tx.Hash] = tx
+}
+
+// AddReceipt adds r, to be found by its TxHash. Its logs are returned by FilterLogs.
+func (f *FakeClient) AddReceipt(r *web3.Receipt) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.receipts[r.TxHash] = r
+}
+
+// SetError makes every call to method fail with err, or clears it if err is nil.
+func (f *FakeClient) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// FailNext makes the next calls to method fail with errs, one each and in order, before any set by SetError.
+func (f *FakeClient) FailNext(method string, errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next[method] = append(f.next[method], errs...)
+}
+
+// Calls returns the calls made so far, in order.
+func (f *FakeClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallCount returns the number of calls made so far to method.
+func (f *FakeClient) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int
+	for _, c := range f.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// Sent returns the transactions sent so far with SendRawTransaction.
+func (f *FakeClient) Sent() []*types.Transaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*types.Transaction(nil), f.sent...)
+}
+
+// call records a call to method, and returns the error it should fail with, if any. f.mu must be held.
+func (f *FakeClient) call(ctx context.Context, method string, args ...interface{}) error {
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	if f.closed {
+		return web3.ErrClientClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if errs := f.next[method]; len(errs) > 0 {
+		f.next[method] = errs[1:]
+		return errs[0]
+	}
+	return f.errs[method]
+}
+
+func (f *FakeClient) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetBalance", address, blockNumber); err != nil {
+		return nil, err
+	}
+	if err := web3.ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	return f.balance(address), nil
+}
+
+func (f *FakeClient) GetPendingBalance(ctx context.Context, address string) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetPendingBalance", address); err != nil {
+		return nil, err
+	}
+	if err := web3.ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	return f.balance(address), nil
+}
+
+func (f *FakeClient) balance(address string) *big.Int {
+	if b, ok := f.balances[common.HexToAddress(address)]; ok {
+		return new(big.Int).Set(b)
+	}
+	return new(big.Int)
+}
+
+func (f *FakeClient) GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetCode", address, blockNumber); err != nil {
+		return nil, err
+	}
+	if err := web3.ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	return f.code[common.HexToAddress(address)], nil
+}
+
+func (f *FakeClient) GetStorageAt(ctx context.Context, address string, slot common.Hash, blockNumber *big.Int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetStorageAt", address, slot, blockNumber); err != nil {
+		return nil, err
+	}
+	if err := web3.ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	value := f.storage[common.HexToAddress(address)][slot]
+	return value[:], nil
+}
+
+func (f *FakeClient) GetLatestBlockNumber(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetLatestBlockNumber"); err != nil {
+		return 0, err
+	}
+	if f.latest == nil {
+		return 0, nil
+	}
+	return f.latest.Number.Uint64(), nil
+}
+
+func (f *FakeClient) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*web3.Block, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetBlockByNumber", number, includeTxs); err != nil {
+		return nil, err
+	}
+	if number == nil {
+		if f.latest == nil {
+			return nil, &web3.NotFoundError{Kind: "block", ID: "latest"}
+		}
+		return f.latest, nil
+	}
+	b, ok := f.blocks[number.Uint64()]
+	if !ok {
+		return nil, &web3.NotFoundError{Kind: "block", ID: number.String()}
+	}
+	return b, nil
+}
+
+func (f *FakeClient) GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*web3.Block, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetBlockByHash", hash, includeTxs); err != nil {
+		return nil, err
+	}
+	b, ok := f.blocksByHash[common.HexToHash(hash)]
+	if !ok {
+		return nil, &web3.NotFoundError{Kind: "block", ID: hash}
+	}
+	return b, nil
+}
+
+func (f *FakeClient) GetHeaderByNumber(ctx context.Context, number *big.Int) (*web3.Header, error) {
+	b, err := f.GetBlockByNumber(ctx, number, false)
+	if err != nil {
+		return nil, err
+	}
+	return b.Header(), nil
+}
+
+func (f *FakeClient) GetHeaderByHash(ctx context.Context, hash string) (*web3.Header, error) {
+	b, err := f.GetBlockByHash(ctx, hash, false)
+	if err != nil {
+		return nil, err
+	}
+	return b.Header(), nil
+}
+
+func (f *FakeClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*web3.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetTransactionByHash", hash); err != nil {
+		return nil, err
+	}
+	tx, ok := f.txs[hash]
+	if !ok {
+		return nil, &web3.NotFoundError{Kind: "transaction", ID: hash.Hex()}
+	}
+	return tx, nil
+}
+
+func (f *FakeClient) GetBlockTransactionCount(ctx context.Context, blockHash string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetBlockTransactionCount", blockHash); err != nil {
+		return 0, err
+	}
+	b, ok := f.blocksByHash[common.HexToHash(blockHash)]
+	if !ok {
+		return 0, &web3.NotFoundError{Kind: "block", ID: blockHash}
+	}
+	return uint64(len(b.TxHashes) + len(b.TxDetails)), nil
+}
+
+func (f *FakeClient) GetTransactionInBlock(ctx context.Context, blockHash string, index uint64) (*web3.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetTransactionInBlock", blockHash, index); err != nil {
+		return nil, err
+	}
+	notFound := &web3.NotFoundError{Kind: "transaction", ID: fmt.Sprintf("%d in block %s", index, blockHash)}
+	b, ok := f.blocksByHash[common.HexToHash(blockHash)]
+	if !ok {
+		return nil, notFound
+	}
+	if index < uint64(len(b.TxDetails)) {
+		return b.TxDetails[index], nil
+	}
+	if index < uint64(len(b.TxHashes)) {
+		if tx, ok := f.txs[b.TxHashes[index]]; ok {
+			return tx, nil
+		}
+	}
+	return nil, notFound
+}
+
+func (f *FakeClient) GetSnapshot(ctx context.Context) (*web3.Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetSnapshot"); err != nil {
+		return nil, err
+	}
+	return f.snapshotAt(nil)
+}
+
+func (f *FakeClient) GetSnapshotAt(ctx context.Context, blockNumber *big.Int) (*web3.Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetSnapshotAt", blockNumber); err != nil {
+		return nil, err
+	}
+	return f.snapshotAt(blockNumber)
+}
+
+// snapshotAt returns the latest snapshot at or before blockNumber (nil for latest).
+func (f *FakeClient) snapshotAt(blockNumber *big.Int) (*web3.Snapshot, error) {
+	var found *web3.Snapshot
+	for _, s := range f.snapshots {
+		if (blockNumber == nil || s.Number <= blockNumber.Uint64()) && (found == nil || s.Number > found.Number) {
+			found = s
+		}
+	}
+	if found == nil {
+		id := "latest"
+		if blockNumber != nil {
+			id = blockNumber.String()
+		}
+		return nil, &web3.NotFoundError{Kind: "snapshot at block", ID: id}
+	}
+	return found, nil
+}
+
+// GetID returns the chain and network ids, and the hash of block 0 as the genesis hash, if it was added.
+func (f *FakeClient) GetID(ctx context.Context) (*web3.ID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetID"); err != nil {
+		return nil, err
+	}
+	id := &web3.ID{NetworkID: f.networkID, ChainID: f.chainID}
+	if genesis, ok := f.blocks[0]; ok {
+		id.GenesisHash = genesis.Hash
+	}
+	return id, nil
+}
+
+func (f *FakeClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*web3.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	r, ok := f.receipts[hash]
+	if !ok {
+		return nil, &web3.NotFoundError{Kind: "receipt", ID: hash.Hex()}
+	}
+	return r, nil
+}
+
+func (f *FakeClient) GetChainID(ctx context.Context) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetChainID"); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(f.chainID), nil
+}
+
+func (f *FakeClient) ChainID(ctx context.Context) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "ChainID"); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(f.chainID), nil
+}
+
+// ResetChainID only records the call, since nothing is cached.
+func (f *FakeClient) ResetChainID() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: "ResetChainID"})
+}
+
+func (f *FakeClient) GetTransactionSender(ctx context.Context, tx *types.Transaction) (common.Address, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetTransactionSender", tx); err != nil {
+		return common.Address{}, err
+	}
+	return f.sender(tx)
+}
+
+func (f *FakeClient) sender(tx *types.Transaction) (common.Address, error) {
+	if !tx.Protected() {
+		return types.Sender(types.HomesteadSigner{}, tx)
+	}
+	return types.Sender(types.NewEIP155Signer(f.chainID), tx)
+}
+
+func (f *FakeClient) GetNetworkID(ctx context.Context) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetNetworkID"); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(f.networkID), nil
+}
+
+func (f *FakeClient) GetGasPrice(ctx context.Context) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetGasPrice"); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(f.gasPrice), nil
+}
+
+func (f *FakeClient) GetGasTipCap(ctx context.Context) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetGasTipCap"); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(f.gasTipCap), nil
+}
+
+func (f *FakeClient) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetPendingTransactionCount", account); err != nil {
+		return 0, err
+	}
+	return f.nonces[account], nil
+}
+
+func (f *FakeClient) GetPendingNonce(ctx context.Context, address string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "GetPendingNonce", address); err != nil {
+		return 0, err
+	}
+	if err := web3.ValidateAddress(address); err != nil {
+		return 0, err
+	}
+	return f.nonces[common.HexToAddress(address)], nil
+}
+
+// SendRawTransaction decodes tx and records it for Sent, and increments its sender's nonce. The transaction is
+// not executed, so seed any receipt it should have with AddReceipt.
+func (f *FakeClient) SendRawTransaction(ctx context.Context, tx []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "SendRawTransaction", tx); err != nil {
+		return err
+	}
+	var decoded types.Transaction
+	if err := rlp.DecodeBytes(tx, &decoded); err != nil {
+		return fmt.Errorf("invalid transaction: %w", err)
+	}
+	from, err := f.sender(&decoded)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	f.sent = append(f.sent, &decoded)
+	f.nonces[from]++
+	return nil
+}
+
+func (f *FakeClient) Call(ctx context.Context, msg web3.CallMsg) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "Call", msg); err != nil {
+		return nil, err
+	}
+	fn := f.callFunc
+	if fn == nil {
+		return nil, errors.New("fake client: no call func set")
+	}
+	// Unlocked, so that fn can inspect the fake, ie: with Sent.
+	f.mu.Unlock()
+	defer f.mu.Lock()
+	return fn(msg)
+}
+
+func (f *FakeClient) EstimateGas(ctx context.Context, msg web3.CallMsg) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "EstimateGas", msg); err != nil {
+		return 0, err
+	}
+	return f.gasEstimate, nil
+}
+
+// FilterLogs returns the logs of added receipts which match q, ordered by block and index.
+func (f *FakeClient) FilterLogs(ctx context.Context, q gochain.FilterQuery) ([]types.Log, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "FilterLogs", q); err != nil {
+		return nil, err
+	}
+	var logs []types.Log
+	for _, r := range f.receipts {
+		for _, l := range r.Logs {
+			if matchLog(q, l) {
+				logs = append(logs, *l)
+			}
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	return logs, nil
+}
+
+func matchLog(q gochain.FilterQuery, l *types.Log) bool {
+	if q.BlockHash != nil {
+		if l.BlockHash != *q.BlockHash {
+			return false
+		}
+	} else {
+		if q.FromBlock != nil && l.BlockNumber < q.FromBlock.Uint64() {
+			return false
+		}
+		if q.ToBlock != nil && l.BlockNumber > q.ToBlock.Uint64() {
+			return false
+		}
+	}
+	if len(q.Addresses) > 0 && !containsAddress(q.Addresses, l.Address) {
+		return false
+	}
+	for i, topics := range q.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		if i >= len(l.Topics) || !containsHash(topics, l.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeClient) SubscribeNewHead(ctx context.Context) (<-chan *types.Header, gochain.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "SubscribeNewHead"); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, fmt.Errorf("fake client: %w", web3.ErrSubscriptionUnsupported)
+}
+
+func (f *FakeClient) SubscribePendingTransactions(ctx context.Context) (<-chan common.Hash, gochain.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "SubscribePendingTransactions"); err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, fmt.Errorf("fake client: %w", web3.ErrSubscriptionUnsupported)
+}
+
+func (f *FakeClient) RawCall(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "RawCall", append([]interface{}{method}, args...)...); err != nil {
+		return err
+	}
+	return fmt.Errorf("fake client: unsupported method %s", method)
+}
+
+func (f *FakeClient) BatchCall(ctx context.Context, reqs []rpc.BatchElem) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call(ctx, "BatchCall", reqs); err != nil {
+		return err
+	}
+	for i := range reqs {
+		reqs[i].Error = fmt.Errorf("fake client: unsupported method %s", reqs[i].Method)
+	}
+	return nil
+}
+
+func (f *FakeClient) URL() string { return "" }
+
+func (f *FakeClient) Transport() string { return "" }
+
+func (f *FakeClient) SupportsSubscriptions() bool { return false }
+
+func (f *FakeClient) Stats() web3.ClientStats { return web3.ClientStats{} }
+
+// Close makes all further calls fail with web3.ErrClientClosed.
+func (f *FakeClient) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+}
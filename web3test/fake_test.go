@@ -0,0 +1,145 @@
+package web3test_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+// Well known development key and address.
+const (
+	testKey     = "0x8f2a55949038a9610f50fb23b5883af3b4ecb3c3bb792cbcefbd1542c692be63"
+	testAddress = "0xFE3B557E8Fb62b89F4916B721be55cEb828dBd73"
+)
+
+func TestFakeClient(t *testing.T) {
+	f := web3test.NewFakeClient()
+	ctx := context.Background()
+	addr := common.HexToAddress(testAddress)
+	f.SetBalance(addr, big.NewInt(100))
+	genesis := &web3.Block{Hash: common.HexToHash("0x01")}
+	f.AddBlock(genesis)
+	tx := &web3.Transaction{Hash: common.HexToHash("0x0a"), From: addr}
+	f.AddBlock(&web3.Block{Hash: common.HexToHash("0x02"), TxDetails: []*web3.Transaction{tx}})
+
+	if bal, err := f.GetBalance(ctx, testAddress, nil); err != nil || bal.Int64() != 100 {
+		t.Errorf("expected balance 100 but got %v: %v", bal, err)
+	}
+	if bal, err := f.GetBalance(ctx, common.HexToAddress("0x01").Hex(), nil); err != nil || bal.Sign() != 0 {
+		t.Errorf("expected zero balance for unknown address but got %v: %v", bal, err)
+	}
+	if n, err := f.GetLatestBlockNumber(ctx); err != nil || n != 1 {
+		t.Errorf("expected latest block 1 but got %d: %v", n, err)
+	}
+	if b, err := f.GetBlockByNumber(ctx, nil, true); err != nil || b.Hash != common.HexToHash("0x02") {
+		t.Errorf("expected latest block 0x02 but got %+v: %v", b, err)
+	}
+	if got, err := f.GetTransactionInBlock(ctx, "0x02", 0); err != nil || got != tx {
+		t.Errorf("expected transaction from block but got %+v: %v", got, err)
+	}
+	if id, err := f.GetID(ctx); err != nil || id.GenesisHash != genesis.Hash || id.ChainID.Int64() != 1 {
+		t.Errorf("unexpected id %+v: %v", id, err)
+	}
+
+	_, err := f.GetTransactionReceipt(ctx, tx.Hash)
+	var notFound *web3.NotFoundError
+	if !errors.Is(err, web3.NotFoundErr) || !errors.As(err, &notFound) || notFound.Kind != "receipt" {
+		t.Errorf("expected receipt %v but got: %v", web3.NotFoundErr, err)
+	}
+	f.AddReceipt(&web3.Receipt{TxHash: tx.Hash, Status: 1})
+	if r, err := web3.WaitForReceipt(ctx, f, tx.Hash); err != nil || r.Status != 1 {
+		t.Errorf("expected successful receipt but got %+v: %v", r, err)
+	}
+
+	if got := f.CallCount("GetBalance"); got != 2 {
+		t.Errorf("expected 2 calls to GetBalance but got %d", got)
+	}
+	calls := f.Calls()
+	if len(calls) == 0 || calls[0].Method != "GetBalance" || calls[0].Args[0] != testAddress {
+		t.Errorf("expected first call to be GetBalance(%s) but got %+v", testAddress, calls)
+	}
+
+	f.Close()
+	if _, err := f.GetBalance(ctx, testAddress, nil); err != web3.ErrClientClosed {
+		t.Errorf("expected %v after close but got: %v", web3.ErrClientClosed, err)
+	}
+}
+
+func TestFakeClient_errors(t *testing.T) {
+	f := web3test.NewFakeClient()
+	ctx := context.Background()
+	hash := common.HexToHash("0x01")
+	f.AddReceipt(&web3.Receipt{TxHash: hash, Status: 1})
+
+	errDown := errors.New("node down")
+	f.SetError("GetGasPrice", errDown)
+	if _, err := f.GetGasPrice(ctx); err != errDown {
+		t.Errorf("expected %v but got: %v", errDown, err)
+	}
+	f.SetError("GetGasPrice", nil)
+	if _, err := f.GetGasPrice(ctx); err != nil {
+		t.Errorf("unexpected error after clearing: %v", err)
+	}
+
+	// Not found twice, then found.
+	f.FailNext("GetTransactionReceipt", web3.NotFoundErr, web3.NotFoundErr)
+	opts := web3.ReceiptOptions{PollInterval: time.Millisecond, MaxAttempts: 2}
+	if _, err := web3.WaitForReceiptWithOptions(ctx, f, hash, opts); !errors.Is(err, web3.ErrReceiptTimeout) {
+		t.Errorf("expected %v but got: %v", web3.ErrReceiptTimeout, err)
+	}
+	if _, err := web3.WaitForReceiptWithOptions(ctx, f, hash, opts); err != nil {
+		t.Errorf("unexpected error once the scripted errors ran out: %v", err)
+	}
+	if got := f.CallCount("GetTransactionReceipt"); got != 3 {
+		t.Errorf("expected 3 calls but got %d", got)
+	}
+}
+
+func TestFakeClient_send(t *testing.T) {
+	f := web3test.NewFakeClient()
+	ctx := context.Background()
+	to := common.HexToAddress("0x01")
+	for i := 0; i < 2; i++ {
+		if _, err := web3.Send(ctx, f, testKey, to, big.NewInt(1)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	sent := f.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 transactions but got %d", len(sent))
+	}
+	for i, tx := range sent {
+		if tx.Nonce() != uint64(i) || *tx.To() != to {
+			t.Errorf("tx %d: expected nonce %d to %s but got nonce %d to %s", i, i, to.Hex(), tx.Nonce(), tx.To().Hex())
+		}
+	}
+	if from, err := f.GetTransactionSender(ctx, sent[0]); err != nil || from != common.HexToAddress(testAddress) {
+		t.Errorf("expected sender %s but got %s: %v", testAddress, from.Hex(), err)
+	}
+}
+
+func ExampleFakeClient() {
+	f := web3test.NewFakeClient()
+	f.SetBalance(common.HexToAddress(testAddress), web3.Base(2))
+	f.FailNext("GetBalance", errors.New("connection reset"))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		bal, err := f.GetBalance(ctx, testAddress, nil)
+		if err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		fmt.Println("balance:", bal)
+	}
+	// Output:
+	// error: connection reset
+	// balance: 2000000000000000000
+}
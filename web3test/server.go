@@ -0,0 +1,684 @@
+package web3test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/gochain/v3/common/hexutil"
+	"github.com/gochain/gochain/v3/core/types"
+	"github.com/gochain/gochain/v3/crypto"
+	"github.com/gochain/gochain/v3/rlp"
+	"github.com/gochain/gochain/v3/rpc"
+	"github.com/gochain/web3"
+)
+
+// ClientVersion is the Server's response to web3_clientVersion.
+const ClientVersion = "web3test"
+
+// Server is a JSON-RPC node served over HTTP from in-memory state, to test a web3.Client end to end. It serves
+// web3_clientVersion, eth_chainId, net_version, eth_gasPrice, eth_blockNumber, eth_getBalance, eth_getCode, eth_getTransactionCount,
+// eth_getBlockByNumber, eth_getBlockByHash, eth_getTransactionByHash, eth_getTransactionReceipt, eth_call,
+// eth_estimateGas and eth_sendRawTransaction. Balances, code, and nonces are served from the state after a requested
+// block number, or the latest state for block tags. eth_call is handled by the function from SetCallFunc, or
+// SetStateCallFunc, which also gets the state at the requested block with the call's state overrides applied.
+//
+// Sent transactions are pending until Mine includes them in a block with a successful receipt. Nothing is executed
+// and gas is not charged, so Mine only moves value and stores the code of contract creations. Transactions with a future
+// nonce are queued until the nonces before them are sent, and a transaction with the nonce of an unmined one replaces
+// it if its gas price is at least 10% higher, like a node's transaction pool. Latency and failures can be injected per
+// method with SetLatency, SetJitter, SetError and FailNext, which apply to single requests but not to batches.
+type Server struct {
+	*httptest.Server
+	rpc *rpc.Server
+
+	mu          sync.Mutex
+	chainID     *big.Int
+	gasPrice    *big.Int
+	gasEstimate uint64
+	balances    map[common.Address]*big.Int
+	code        map[common.Address][]byte
+	nonces      map[common.Address]uint64
+	queued      map[common.Address]map[uint64]*web3.Transaction
+	blocks      []*web3.Block
+	txs         map[common.Hash]*web3.Transaction
+	receipts    map[common.Hash]*web3.Receipt
+	pending     []*web3.Transaction
+	states      []*State // after each block
+	callFunc    func(web3.CallMsg) ([]byte, error)
+	stateCall   func(web3.CallMsg, *State) ([]byte, error)
+	latency     map[string]time.Duration
+	jitter      map[string]time.Duration
+	errs        map[string]error
+	statuses    map[string][]int
+	requests    map[string]int
+}
+
+// NewServer starts a Server with chain id 1, a gas price of 1 gwei, and a genesis block. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		rpc:         rpc.NewServer(),
+		chainID:     big.NewInt(1),
+		gasPrice:    big.NewInt(1e9),
+		gasEstimate: 21000,
+		balances:    make(map[common.Address]*big.Int),
+		code:        make(map[common.Address][]byte),
+		nonces:      make(map[common.Address]uint64),
+		queued:      make(map[common.Address]map[uint64]*web3.Transaction),
+		txs:         make(map[common.Hash]*web3.Transaction),
+		receipts:    make(map[common.Hash]*web3.Receipt),
+		latency:     make(map[string]time.Duration),
+		jitter:      make(map[string]time.Duration),
+		errs:        make(map[string]error),
+		statuses:    make(map[string][]int),
+		requests:    make(map[string]int),
+	}
+	s.mine()
+	if err := s.rpc.RegisterName("eth", &EthService{s}); err != nil {
+		panic(err)
+	}
+	if err := s.rpc.RegisterName("net", &NetService{s}); err != nil {
+		panic(err)
+	}
+	if err := s.rpc.RegisterName("web3", &Web3Service{}); err != nil {
+		panic(err)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.Server.Close()
+	s.rpc.Stop()
+}
+
+// SetChainID sets the chain id, which is also used to recover the senders of sent transactions.
+func (s *Server) SetChainID(id *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chainID = id
+}
+
+// SetGasPrice sets the suggested gas price.
+func (s *Server) SetGasPrice(price *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gasPrice = price
+}
+
+// SetGasEstimate sets the result of eth_estimateGas. Defaults to 21000.
+func (s *Server) SetGasEstimate(gas uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gasEstimate = gas
+}
+
+// SetBalance sets the balance of address.
+func (s *Server) SetBalance(address common.Address, balance *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.balances[address] = new(big.Int).Set(balance)
+}
+
+// SetCode sets the code of address.
+func (s *Server) SetCode(address common.Address, code []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.code[address] = code
+}
+
+// SetCallFunc sets the function which handles eth_call. Without one, eth_call fails.
+func (s *Server) SetCallFunc(fn func(web3.CallMsg) ([]byte, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callFunc = fn
+}
+
+// SetStateCallFunc sets the function which handles eth_call, in place of any from SetCallFunc. It gets the state at
+// the requested block, with the call's state overrides applied, which it may modify.
+func (s *Server) SetStateCallFunc(fn func(web3.CallMsg, *State) ([]byte, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateCall = fn
+}
+
+// SetReceipt sets the receipt returned for r.TxHash, in place of any from Mine. Logs must be non-nil.
+func (s *Server) SetReceipt(r *web3.Receipt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receipts[r.TxHash] = r
+}
+
+// SetLatency delays every response to method by d.
+func (s *Server) SetLatency(method string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[method] = d
+}
+
+// SetJitter delays every response to method by a random duration up to d, on top of any from SetLatency, so
+// concurrent requests complete out of order.
+func (s *Server) SetJitter(method string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jitter[method] = d
+}
+
+// SetError makes the node respond to every request for method with err as a JSON-RPC error, or clears it if err
+// is nil.
+func (s *Server) SetError(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errs, method)
+		return
+	}
+	s.errs[method] = err
+}
+
+// FailNext makes the next requests for method fail with the HTTP statuses, one each and in order, ie:
+// http.StatusServiceUnavailable or http.StatusTooManyRequests to test retries.
+func (s *Server) FailNext(method string, statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[method] = append(s.statuses[method], statuses...)
+}
+
+// Requests returns the number of requests received so far for method, including failed ones.
+func (s *Server) Requests(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method]
+}
+
+// Mine includes the pending transactions in a new block, with successful receipts, and returns the block. Value is
+// moved from each sender to its recipient, and contract creations get their input data as code.
+func (s *Server) Mine() *web3.Block {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mine()
+}
+
+func (s *Server) mine() *web3.Block {
+	number := big.NewInt(int64(len(s.blocks)))
+	b := &web3.Block{
+		Sha3Uncles: types.EmptyUncleHash,
+		TxsRoot:    types.EmptyRootHash,
+		LogsBloom:  new(types.Bloom),
+		Difficulty: big.NewInt(1),
+		Number:     number,
+		GasLimit:   8000000,
+		Timestamp:  time.Now().UTC().Truncate(time.Second),
+		TxHashes:   []common.Hash{},
+	}
+	if len(s.blocks) > 0 {
+		b.ParentHash = s.blocks[len(s.blocks)-1].Hash
+	}
+	b.Hash = crypto.Keccak256Hash(b.ParentHash[:], number.Bytes())
+	if len(s.pending) > 0 {
+		b.TxsRoot = crypto.Keccak256Hash(b.Hash[:])
+	}
+	for i, tx := range s.pending {
+		tx.BlockNumber, tx.BlockHash, tx.TransactionIndex = number, b.Hash, uint64(i)
+		b.TxHashes = append(b.TxHashes, tx.Hash)
+		b.GasUsed += tx.GasLimit
+		r := &web3.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: b.GasUsed,
+			Logs:              []*types.Log{},
+			TxHash:            tx.Hash,
+			TxIndex:           uint64(i),
+			GasUsed:           tx.GasLimit,
+			BlockHash:         b.Hash,
+			BlockNumber:       number.Uint64(),
+			From:              tx.From,
+			To:                tx.To,
+		}
+		if tx.To == nil {
+			r.ContractAddress = crypto.CreateAddress(tx.From, tx.Nonce)
+			s.code[r.ContractAddress] = tx.Input
+		}
+		if tx.Value.Sign() > 0 {
+			to := r.ContractAddress
+			if tx.To != nil {
+				to = *tx.To
+			}
+			s.balances[tx.From] = new(big.Int).Sub(s.balance(tx.From), tx.Value)
+			s.balances[to] = new(big.Int).Add(s.balance(to), tx.Value)
+		}
+		s.receipts[tx.Hash] = r
+	}
+	s.pending = nil
+	s.blocks = append(s.blocks, b)
+	s.states = append(s.states, s.latest())
+	return b
+}
+
+// State is the state of the accounts at a block, as seen by an eth_call handler from SetStateCallFunc. Nothing is
+// executed, so Storage only holds the slots set by state overrides.
+type State struct {
+	Balances map[common.Address]*big.Int
+	Code     map[common.Address][]byte
+	Nonces   map[common.Address]uint64
+	Storage  map[common.Address]map[common.Hash]common.Hash
+}
+
+// Balance returns the balance of address, which is zero if it has none.
+func (st *State) Balance(address common.Address) *big.Int {
+	if b, ok := st.Balances[address]; ok {
+		return b
+	}
+	return new(big.Int)
+}
+
+// copy returns a copy of st, which can be modified without affecting it.
+func (st *State) copy() *State {
+	c := &State{
+		Balances: make(map[common.Address]*big.Int, len(st.Balances)),
+		Code:     make(map[common.Address][]byte, len(st.Code)),
+		Nonces:   make(map[common.Address]uint64, len(st.Nonces)),
+		Storage:  make(map[common.Address]map[common.Hash]common.Hash, len(st.Storage)),
+	}
+	for addr, b := range st.Balances {
+		c.Balances[addr] = new(big.Int).Set(b)
+	}
+	for addr, code := range st.Code {
+		c.Code[addr] = code
+	}
+	for addr, n := range st.Nonces {
+		c.Nonces[addr] = n
+	}
+	for addr, slots := range st.Storage {
+		c.Storage[addr] = make(map[common.Hash]common.Hash, len(slots))
+		for k, v := range slots {
+			c.Storage[addr][k] = v
+		}
+	}
+	return c
+}
+
+// apply applies the state overrides of eth_call to st.
+func (st *State) apply(overrides map[common.Address]web3.StateOverride) {
+	for addr, o := range overrides {
+		if o.Balance != nil {
+			st.Balances[addr] = new(big.Int).Set(o.Balance)
+		}
+		if o.Nonce != nil {
+			st.Nonces[addr] = *o.Nonce
+		}
+		if o.Code != nil {
+			st.Code[addr] = o.Code
+		}
+		if o.State != nil {
+			st.Storage[addr] = make(map[common.Hash]common.Hash, len(o.State))
+		}
+		for _, slots := range []map[common.Hash]common.Hash{o.State, o.StateDiff} {
+			for k, v := range slots {
+				if st.Storage[addr] == nil {
+					st.Storage[addr] = make(map[common.Hash]common.Hash)
+				}
+				st.Storage[addr][k] = v
+			}
+		}
+	}
+}
+
+// latest returns a copy of the latest state.
+func (s *Server) latest() *State {
+	st := &State{Balances: s.balances, Code: s.code, Nonces: s.nonces}
+	return st.copy()
+}
+
+// stateAt returns a copy of the state after the block numbered by the hex block argument, or the latest state for a
+// tag, ie: "latest".
+func (s *Server) stateAt(block string) (*State, error) {
+	switch block {
+	case "latest", "pending":
+		return s.latest(), nil
+	case "earliest":
+		return s.states[0].copy(), nil
+	}
+	n, err := hexutil.DecodeUint64(block)
+	if err != nil {
+		return nil, err
+	}
+	if n >= uint64(len(s.states)) {
+		return nil, errors.New("header not found")
+	}
+	return s.states[n].copy(), nil
+}
+
+func (s *Server) balance(address common.Address) *big.Int {
+	if b, ok := s.balances[address]; ok {
+		return b
+	}
+	return new(big.Int)
+}
+
+// serveHTTP applies the injected latency and failures for single requests, and passes them on to the rpc server.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	var batch []struct{ Method string }
+	if json.Unmarshal(body, &batch) == nil {
+		s.mu.Lock()
+		for _, req := range batch {
+			s.requests[req.Method]++
+		}
+		s.mu.Unlock()
+		s.rpc.ServeHTTP(w, r)
+		return
+	}
+	var req struct {
+		ID     json.RawMessage
+		Method string
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.rpc.ServeHTTP(w, r)
+		return
+	}
+	s.mu.Lock()
+	s.requests[req.Method]++
+	latency, rpcErr := s.latency[req.Method], s.errs[req.Method]
+	if jitter := s.jitter[req.Method]; jitter > 0 {
+		latency += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	var status int
+	if statuses := s.statuses[req.Method]; len(statuses) > 0 {
+		status, s.statuses[req.Method] = statuses[0], statuses[1:]
+	}
+	s.mu.Unlock()
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	switch {
+	case status != 0:
+		http.Error(w, http.StatusText(status), status)
+	case rpcErr != nil:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"error":   map[string]interface{}{"code": -32000, "message": rpcErr.Error()},
+		})
+	default:
+		s.rpc.ServeHTTP(w, r)
+	}
+}
+
+// NetService is the net namespace of a Server.
+type NetService struct{ s *Server }
+
+func (n *NetService) Version() string {
+	n.s.mu.Lock()
+	defer n.s.mu.Unlock()
+	return n.s.chainID.String()
+}
+
+// Web3Service is the web3 namespace of a Server.
+type Web3Service struct{}
+
+func (*Web3Service) ClientVersion() string {
+	return ClientVersion
+}
+
+// EthService is the eth namespace of a Server.
+type EthService struct{ s *Server }
+
+func (e *EthService) ChainId() *hexutil.Big {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	return (*hexutil.Big)(new(big.Int).Set(e.s.chainID))
+}
+
+func (e *EthService) GasPrice() *hexutil.Big {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	return (*hexutil.Big)(new(big.Int).Set(e.s.gasPrice))
+}
+
+func (e *EthService) BlockNumber() hexutil.Uint64 {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	return hexutil.Uint64(len(e.s.blocks) - 1)
+}
+
+func (e *EthService) GetBalance(address common.Address, block string) (*hexutil.Big, error) {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	st, err := e.s.stateAt(block)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(st.Balance(address)), nil
+}
+
+func (e *EthService) GetCode(address common.Address, block string) (hexutil.Bytes, error) {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	st, err := e.s.stateAt(block)
+	if err != nil {
+		return nil, err
+	}
+	return st.Code[address], nil
+}
+
+func (e *EthService) GetTransactionCount(address common.Address, block string) (hexutil.Uint64, error) {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	st, err := e.s.stateAt(block)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(st.Nonces[address]), nil
+}
+
+func (e *EthService) GetBlockByNumber(number string, full bool) (*web3.Block, error) {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	n := uint64(len(e.s.blocks) - 1)
+	if number != "latest" && number != "pending" {
+		var err error
+		if n, err = hexutil.DecodeUint64(number); err != nil {
+			return nil, err
+		}
+	}
+	if n >= uint64(len(e.s.blocks)) {
+		return nil, nil
+	}
+	return e.s.block(e.s.blocks[n], full), nil
+}
+
+func (e *EthService) GetBlockByHash(hash common.Hash, full bool) *web3.Block {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	for _, b := range e.s.blocks {
+		if b.Hash == hash {
+			return e.s.block(b, full)
+		}
+	}
+	return nil
+}
+
+// block returns b, with the full transactions in place of their hashes if full is set.
+func (s *Server) block(b *web3.Block, full bool) *web3.Block {
+	if !full {
+		return b
+	}
+	c := *b
+	c.TxHashes = nil
+	c.TxDetails = []*web3.Transaction{}
+	for _, hash := range b.TxHashes {
+		c.TxDetails = append(c.TxDetails, s.txs[hash])
+	}
+	return &c
+}
+
+func (e *EthService) GetTransactionByHash(hash common.Hash) *web3.Transaction {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	return e.s.txs[hash]
+}
+
+func (e *EthService) GetTransactionReceipt(hash common.Hash) *web3.Receipt {
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	return e.s.receipts[hash]
+}
+
+// callArgs are the arguments of eth_call and eth_estimateGas.
+type callArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+// decodeCallArgs converts the loosely typed arguments of eth_call and eth_estimateGas.
+func decodeCallArgs(arg map[string]interface{}) (callArgs, error) {
+	var args callArgs
+	b, err := json.Marshal(arg)
+	if err != nil {
+		return args, err
+	}
+	err = json.Unmarshal(b, &args)
+	return args, err
+}
+
+func (e *EthService) Call(arg map[string]interface{}, block string, overrides *map[common.Address]web3.StateOverride) (hexutil.Bytes, error) {
+	args, err := decodeCallArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	e.s.mu.Lock()
+	fn, stateFn := e.s.callFunc, e.s.stateCall
+	st, err := e.s.stateAt(block)
+	e.s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	msg := web3.CallMsg{
+		From:     args.From,
+		To:       args.To,
+		Gas:      uint64(args.Gas),
+		GasPrice: (*big.Int)(args.GasPrice),
+		Value:    (*big.Int)(args.Value),
+		Data:     args.Data,
+	}
+	if stateFn != nil {
+		if overrides != nil {
+			st.apply(*overrides)
+		}
+		return stateFn(msg, st)
+	}
+	if fn == nil {
+		return nil, errors.New("no call func set")
+	}
+	return fn(msg)
+}
+
+func (e *EthService) EstimateGas(arg map[string]interface{}) (hexutil.Uint64, error) {
+	if _, err := decodeCallArgs(arg); err != nil {
+		return 0, err
+	}
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	return hexutil.Uint64(e.s.gasEstimate), nil
+}
+
+func (e *EthService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	var tx types.Transaction
+	if err := rlp.DecodeBytes(raw, &tx); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid transaction: %v", err)
+	}
+	e.s.mu.Lock()
+	defer e.s.mu.Unlock()
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		if tx.ChainId().Cmp(e.s.chainID) != 0 {
+			return common.Hash{}, errors.New("invalid chain id for signer")
+		}
+		signer = types.NewEIP155Signer(e.s.chainID)
+	}
+	from, err := types.Sender(signer, &tx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid sender: %v", err)
+	}
+	if _, ok := e.s.txs[tx.Hash()]; ok {
+		return common.Hash{}, fmt.Errorf("known transaction: %x", tx.Hash())
+	}
+	replaced, index := e.s.unmined(from, tx.Nonce())
+	if replaced != nil {
+		min := new(big.Int).Div(new(big.Int).Mul(replaced.GasPrice, big.NewInt(110)), big.NewInt(100))
+		if tx.GasPrice().Cmp(min) < 0 {
+			return common.Hash{}, errors.New("replacement transaction underpriced")
+		}
+		delete(e.s.txs, replaced.Hash)
+	} else if tx.Nonce() < e.s.nonces[from] {
+		return common.Hash{}, errors.New("nonce too low")
+	}
+	v, r, sig := tx.RawSignatureValues()
+	t := &web3.Transaction{
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasPrice(),
+		GasLimit: tx.Gas(),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Input:    tx.Data(),
+		From:     from,
+		V:        v,
+		R:        r,
+		S:        sig,
+		Hash:     tx.Hash(),
+	}
+	e.s.txs[t.Hash] = t
+	if index >= 0 {
+		e.s.pending[index] = t
+		return t.Hash, nil
+	}
+	if e.s.queued[from] == nil {
+		e.s.queued[from] = make(map[uint64]*web3.Transaction)
+	}
+	e.s.queued[from][t.Nonce] = t
+	// Move transactions with consecutive nonces to pending.
+	for {
+		next, ok := e.s.queued[from][e.s.nonces[from]]
+		if !ok {
+			break
+		}
+		delete(e.s.queued[from], next.Nonce)
+		e.s.pending = append(e.s.pending, next)
+		e.s.nonces[from]++
+	}
+	return t.Hash, nil
+}
+
+// unmined returns the pending transaction from with nonce and its index, or the queued one with index -1, or nil.
+func (s *Server) unmined(from common.Address, nonce uint64) (*web3.Transaction, int) {
+	for i, tx := range s.pending {
+		if tx.From == from && tx.Nonce == nonce {
+			return tx, i
+		}
+	}
+	return s.queued[from][nonce], -1
+}
@@ -0,0 +1,53 @@
+package web3test_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/gochain/gochain/v3/common"
+	"github.com/gochain/web3"
+	"github.com/gochain/web3/web3test"
+)
+
+func TestServer(t *testing.T) {
+	srv := web3test.NewServer()
+	defer srv.Close()
+	c, err := web3.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	from, to := common.HexToAddress(testAddress), common.HexToAddress("0x01")
+	srv.SetBalance(from, big.NewInt(100))
+
+	id, err := c.GetID(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.ChainID.Int64() != 1 || id.NetworkID.Int64() != 1 || id.GenesisHash == (common.Hash{}) {
+		t.Errorf("unexpected id: %+v", id)
+	}
+
+	tx, err := web3.Send(ctx, c, testKey, to, big.NewInt(40))
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	mined := srv.Mine()
+	block, err := c.GetBlockByNumber(ctx, nil, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if block.Hash != mined.Hash || len(block.TxDetails) != 1 || block.TxDetails[0].Hash != tx.Hash {
+		t.Errorf("expected latest block %s with the sent transaction but got %+v", mined.Hash.Hex(), block)
+	}
+
+	bals, err := web3.GetBalances(ctx, c, []string{from.Hex(), to.Hex()}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bals[0].Int64() != 60 || bals[1].Int64() != 40 {
+		t.Errorf("expected balances 60 and 40 but got %v", bals)
+	}
+}